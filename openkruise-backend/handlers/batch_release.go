@@ -0,0 +1,327 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/openkruise/kruise-dashboard/extensions-backend/pkg/logger"
+	"github.com/openkruise/kruise-dashboard/extensions-backend/pkg/response"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+const (
+	rolloutKind          = "Rollout"
+	batchReleaseKind     = "BatchRelease"
+	batchReleaseResource = "batchreleases"
+
+	batchStateCompleted = "completed"
+	batchStateCurrent   = "current"
+	batchStatePending   = "pending"
+)
+
+func batchReleaseGVRForVersion(version string) schema.GroupVersionResource {
+	return schema.GroupVersionResource{
+		Group:    rolloutAPIGroup,
+		Version:  version,
+		Resource: batchReleaseResource,
+	}
+}
+
+// Batch is one batch of a BatchRelease's release plan. Counts are cumulative over the batches, as
+// in the release plan: a batch is done once that many pods run the update revision.
+type Batch struct {
+	Index           int    `json:"index"`
+	State           string `json:"state" openapi:"enum=completed|current|pending"`
+	CanaryReplicas  string `json:"canaryReplicas"`
+	PlannedReplicas int64  `json:"plannedReplicas"`
+	UpdatedReplicas int64  `json:"updatedReplicas"`
+	ReadyReplicas   int64  `json:"readyReplicas"`
+}
+
+// Event is a Kubernetes event about a rollout or one of the objects driving it.
+type Event struct {
+	Type           string `json:"type"`
+	Reason         string `json:"reason"`
+	Message        string `json:"message"`
+	Kind           string `json:"kind"`
+	Name           string `json:"name"`
+	Count          int32  `json:"count"`
+	FirstTimestamp string `json:"firstTimestamp,omitempty"`
+	LastTimestamp  string `json:"lastTimestamp,omitempty"`
+}
+
+// BatchReleaseDetail is the BatchRelease that executes a rollout's batches.
+type BatchReleaseDetail struct {
+	Name                     string      `json:"name"`
+	Phase                    string      `json:"phase,omitempty"`
+	CurrentBatch             int         `json:"currentBatch" description:"1-based index of the batch in progress"`
+	CurrentBatchState        string      `json:"currentBatchState,omitempty"`
+	ObservedWorkloadReplicas int64       `json:"observedWorkloadReplicas"`
+	StableRevision           string      `json:"stableRevision,omitempty"`
+	UpdateRevision           string      `json:"updateRevision,omitempty"`
+	Message                  string      `json:"message,omitempty"`
+	Batches                  []Batch     `json:"batches"`
+	Conditions               []Condition `json:"conditions"`
+	Events                   []Event     `json:"events"`
+}
+
+// batchPlannedReplicas resolves a batch's canaryReplicas against the workload replicas, rounding
+// percentages up as kruise-rollout does.
+func batchPlannedReplicas(canaryReplicas intstr.IntOrString, workloadReplicas int64) int64 {
+	if canaryReplicas.Type == intstr.Int {
+		return int64(canaryReplicas.IntVal)
+	}
+	percent, err := strconv.Atoi(strings.TrimSuffix(canaryReplicas.StrVal, "%"))
+	if err != nil {
+		return 0
+	}
+	return int64(math.Ceil(float64(workloadReplicas) * float64(percent) / 100))
+}
+
+// batchReleaseBatches lists the batches of a BatchRelease with their planned and observed replicas.
+// status.canaryStatus.currentBatch is 0-based; the returned indexes are 1-based like rollout steps.
+func batchReleaseBatches(obj map[string]interface{}) []Batch {
+	rawBatches, _, _ := unstructured.NestedSlice(obj, "spec", "releasePlan", "batches")
+	workloadReplicas, _, _ := unstructured.NestedInt64(obj, "status", "observedWorkloadReplicas")
+	currentBatch, _, _ := unstructured.NestedInt64(obj, "status", "canaryStatus", "currentBatch")
+	updated, _, _ := unstructured.NestedInt64(obj, "status", "canaryStatus", "updatedReplicas")
+	ready, _, _ := unstructured.NestedInt64(obj, "status", "canaryStatus", "updatedReadyReplicas")
+	phase, _, _ := unstructured.NestedString(obj, "status", "phase")
+
+	batches := make([]Batch, 0, len(rawBatches))
+	for i, raw := range rawBatches {
+		batch, _ := raw.(map[string]interface{})
+		canaryReplicas, ok := intOrStringFromUnstructured(batch, "canaryReplicas")
+		if !ok {
+			continue
+		}
+		planned := batchPlannedReplicas(*canaryReplicas, workloadReplicas)
+
+		result := Batch{Index: i + 1, CanaryReplicas: canaryReplicas.String(), PlannedReplicas: planned}
+		switch {
+		case int64(i) < currentBatch || phase == "Completed":
+			result.State = batchStateCompleted
+		case int64(i) == currentBatch:
+			result.State = batchStateCurrent
+		default:
+			result.State = batchStatePending
+		}
+		if result.State != batchStatePending {
+			result.UpdatedReplicas = min(updated, planned)
+			result.ReadyReplicas = min(ready, planned)
+		}
+		batches = append(batches, result)
+	}
+	return batches
+}
+
+func detailBatchRelease(obj *unstructured.Unstructured) BatchReleaseDetail {
+	phase, _, _ := unstructured.NestedString(obj.Object, "status", "phase")
+	currentBatch, _, _ := unstructured.NestedInt64(obj.Object, "status", "canaryStatus", "currentBatch")
+	batchState, _, _ := unstructured.NestedString(obj.Object, "status", "canaryStatus", "currentBatchState")
+	workloadReplicas, _, _ := unstructured.NestedInt64(obj.Object, "status", "observedWorkloadReplicas")
+	stableRevision, _, _ := unstructured.NestedString(obj.Object, "status", "stableRevision")
+	updateRevision, _, _ := unstructured.NestedString(obj.Object, "status", "updateRevision")
+	message, _, _ := unstructured.NestedString(obj.Object, "status", "message")
+	return BatchReleaseDetail{
+		Name:                     obj.GetName(),
+		Phase:                    phase,
+		CurrentBatch:             int(currentBatch) + 1,
+		CurrentBatchState:        batchState,
+		ObservedWorkloadReplicas: workloadReplicas,
+		StableRevision:           stableRevision,
+		UpdateRevision:           updateRevision,
+		Message:                  message,
+		Batches:                  batchReleaseBatches(obj.Object),
+		Conditions:               statusConditions(obj.Object),
+		Events:                   []Event{},
+	}
+}
+
+func ownedBy(obj, owner *unstructured.Unstructured) bool {
+	for _, ref := range obj.GetOwnerReferences() {
+		if ref.UID == owner.GetUID() {
+			return true
+		}
+	}
+	return false
+}
+
+// findRolloutBatchRelease returns the BatchRelease controlled by rollout, or nil if there is none.
+// The BatchRelease is read at the rollout's API version first and at the other version if that is
+// not served, like getRolloutServedVersion does for rollouts.
+func findRolloutBatchRelease(rollout *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	versions := []string{rolloutAPIVersionV1beta1, rolloutAPIVersionV1alpha1}
+	if gv, err := schema.ParseGroupVersion(rollout.GetAPIVersion()); err == nil && gv.Version == rolloutAPIVersionV1alpha1 {
+		versions = []string{rolloutAPIVersionV1alpha1, rolloutAPIVersionV1beta1}
+	}
+	for _, version := range versions {
+		batchRelease, err := findBatchReleaseInVersion(rollout, batchReleaseGVRForVersion(version))
+		if err == nil || !apierrors.IsNotFound(err) {
+			return batchRelease, err
+		}
+	}
+	return nil, nil
+}
+
+// findBatchReleaseInVersion looks the BatchRelease up by the rollout's name, which kruise-rollout
+// gives it, and lists the namespace only when that object is missing or controlled by another
+// rollout; the owner reference decides when names differ. A NotFound error means the version is
+// not served.
+func findBatchReleaseInVersion(rollout *unstructured.Unstructured, gvr schema.GroupVersionResource) (*unstructured.Unstructured, error) {
+	client := GetDynamicClient().Resource(gvr).Namespace(rollout.GetNamespace())
+	byName, err := client.Get(context.TODO(), rollout.GetName(), metav1.GetOptions{})
+	if err == nil && ownedBy(byName, rollout) {
+		return byName, nil
+	}
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			return nil, err
+		}
+		byName = nil
+	}
+
+	list, err := client.List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for i := range list.Items {
+		if ownedBy(&list.Items[i], rollout) {
+			return &list.Items[i], nil
+		}
+	}
+	return byName, nil
+}
+
+func toEvent(event corev1.Event) Event {
+	first := event.FirstTimestamp
+	last := event.LastTimestamp
+	if last.IsZero() {
+		last = metav1.NewTime(event.EventTime.Time)
+	}
+	return Event{
+		Type:           event.Type,
+		Reason:         event.Reason,
+		Message:        event.Message,
+		Kind:           event.InvolvedObject.Kind,
+		Name:           event.InvolvedObject.Name,
+		Count:          event.Count,
+		FirstTimestamp: formatTimestamp(first),
+		LastTimestamp:  formatTimestamp(last),
+	}
+}
+
+// listObjectEvents returns the events of the given objects, newest first.
+func listObjectEvents(namespace string, objects map[string]string) ([]Event, error) {
+	events := []Event{}
+	for kind, name := range objects {
+		selector := fields.AndSelectors(
+			fields.OneTermEqualSelector("involvedObject.kind", kind),
+			fields.OneTermEqualSelector("involvedObject.name", name),
+		)
+		list, err := GetK8sClient().CoreV1().Events(namespace).List(context.TODO(), metav1.ListOptions{FieldSelector: selector.String()})
+		if err != nil {
+			return nil, fmt.Errorf("list %s events: %w", kind, err)
+		}
+		for _, event := range list.Items {
+			events = append(events, toEvent(event))
+		}
+	}
+	sortEventsNewestFirst(events)
+	return events, nil
+}
+
+func sortEventsNewestFirst(events []Event) {
+	sort.SliceStable(events, func(i, j int) bool {
+		ti, _ := time.Parse(time.RFC3339, events[i].LastTimestamp)
+		tj, _ := time.Parse(time.RFC3339, events[j].LastTimestamp)
+		return ti.After(tj)
+	})
+}
+
+// GetRolloutBatchRelease returns the BatchRelease driving a rollout's batches, with the events of
+// the rollout and the BatchRelease.
+func GetRolloutBatchRelease(c *gin.Context) {
+	rollout, ok := getRolloutV2(c)
+	if !ok {
+		return
+	}
+	namespace := rollout.GetNamespace()
+
+	batchRelease, err := findRolloutBatchRelease(rollout)
+	if err != nil {
+		logger.Log.Error("Failed to list BatchReleases",
+			zap.String("namespace", namespace),
+			zap.String("rollout", rollout.GetName()),
+			zap.Error(err),
+		)
+		response.InternalError(c, err)
+		return
+	}
+	if batchRelease == nil {
+		response.NotFound(c, "BatchRelease")
+		return
+	}
+
+	detail := detailBatchRelease(batchRelease)
+	events, err := listObjectEvents(namespace, map[string]string{
+		rolloutKind:      rollout.GetName(),
+		batchReleaseKind: batchRelease.GetName(),
+	})
+	if err != nil {
+		logger.Log.Warn("Failed to list rollout events",
+			zap.String("namespace", namespace),
+			zap.String("rollout", rollout.GetName()),
+			zap.Error(err),
+		)
+	} else {
+		detail.Events = events
+	}
+	response.Success(c, detail)
+}
+
+// GetRolloutEventsV2 lists the events of a rollout and of its BatchRelease, newest first.
+func GetRolloutEventsV2(c *gin.Context) {
+	rollout, ok := getRolloutV2(c)
+	if !ok {
+		return
+	}
+	namespace := rollout.GetNamespace()
+
+	objects := map[string]string{rolloutKind: rollout.GetName()}
+	batchRelease, err := findRolloutBatchRelease(rollout)
+	if err != nil {
+		logger.Log.Warn("Failed to list BatchReleases",
+			zap.String("namespace", namespace),
+			zap.String("rollout", rollout.GetName()),
+			zap.Error(err),
+		)
+	} else if batchRelease != nil {
+		objects[batchReleaseKind] = batchRelease.GetName()
+	}
+
+	events, err := listObjectEvents(namespace, objects)
+	if err != nil {
+		logger.Log.Error("Failed to list rollout events",
+			zap.String("namespace", namespace),
+			zap.String("rollout", rollout.GetName()),
+			zap.Error(err),
+		)
+		response.InternalError(c, err)
+		return
+	}
+	response.Success(c, newListData(events, ""))
+}
@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+func TestBatchPlannedReplicas(t *testing.T) {
+	if got := batchPlannedReplicas(intstr.FromInt32(2), 10); got != 2 {
+		t.Errorf("count = %d, want 2", got)
+	}
+	// Percentages round up.
+	if got := batchPlannedReplicas(intstr.FromString("25%"), 10); got != 3 {
+		t.Errorf("25%% of 10 = %d, want 3", got)
+	}
+	if got := batchPlannedReplicas(intstr.FromString("100%"), 10); got != 10 {
+		t.Errorf("100%% of 10 = %d, want 10", got)
+	}
+}
+
+func TestBatchReleaseBatches(t *testing.T) {
+	obj := map[string]interface{}{
+		"spec": map[string]interface{}{"releasePlan": map[string]interface{}{"batches": []interface{}{
+			map[string]interface{}{"canaryReplicas": "20%"},
+			map[string]interface{}{"canaryReplicas": "50%"},
+			map[string]interface{}{"canaryReplicas": "100%"},
+		}}},
+		"status": map[string]interface{}{
+			"phase":                    "Progressing",
+			"observedWorkloadReplicas": int64(10),
+			"canaryStatus": map[string]interface{}{
+				"currentBatch":         int64(1),
+				"updatedReplicas":      int64(4),
+				"updatedReadyReplicas": int64(3),
+			},
+		},
+	}
+
+	batches := batchReleaseBatches(obj)
+	if len(batches) != 3 {
+		t.Fatalf("len(batches) = %d, want 3", len(batches))
+	}
+	want := []Batch{
+		{Index: 1, State: batchStateCompleted, CanaryReplicas: "20%", PlannedReplicas: 2, UpdatedReplicas: 2, ReadyReplicas: 2},
+		{Index: 2, State: batchStateCurrent, CanaryReplicas: "50%", PlannedReplicas: 5, UpdatedReplicas: 4, ReadyReplicas: 3},
+		{Index: 3, State: batchStatePending, CanaryReplicas: "100%", PlannedReplicas: 10},
+	}
+	for i := range want {
+		if batches[i] != want[i] {
+			t.Errorf("batches[%d] = %+v, want %+v", i, batches[i], want[i])
+		}
+	}
+}
+
+func TestSortEventsNewestFirst(t *testing.T) {
+	events := []Event{
+		{Reason: "old", LastTimestamp: "2024-01-01T00:00:00Z"},
+		{Reason: "new", LastTimestamp: "2024-01-02T00:00:00Z"},
+	}
+	sortEventsNewestFirst(events)
+	if events[0].Reason != "new" {
+		t.Errorf("events = %+v, want newest first", events)
+	}
+}
+
+func testBatchRelease(version, name string, owner types.UID) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion(schema.GroupVersion{Group: rolloutAPIGroup, Version: version}.String())
+	obj.SetKind(batchReleaseKind)
+	obj.SetNamespace("default")
+	obj.SetName(name)
+	obj.SetOwnerReferences([]metav1.OwnerReference{{Kind: rolloutKind, Name: "web", UID: owner}})
+	return obj
+}
+
+func TestFindRolloutBatchRelease(t *testing.T) {
+	previous := dynamicClient
+	defer func() { dynamicClient = previous }()
+
+	rollout := &unstructured.Unstructured{}
+	rollout.SetAPIVersion(schema.GroupVersion{Group: rolloutAPIGroup, Version: rolloutAPIVersionV1beta1}.String())
+	rollout.SetNamespace("default")
+	rollout.SetName("web")
+	rollout.SetUID("rollout-uid")
+
+	// No list kind is registered: the BatchRelease named after the rollout must be found by Get.
+	dynamicClient = dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(),
+		testBatchRelease(rolloutAPIVersionV1beta1, "web", "rollout-uid"))
+	found, err := findRolloutBatchRelease(rollout)
+	if err != nil || found == nil || found.GetName() != "web" {
+		t.Fatalf("findRolloutBatchRelease() = %v, %v, want web", found, err)
+	}
+
+	// A v1alpha1 rollout whose BatchRelease has another name is matched by its owner reference.
+	rollout.SetAPIVersion(schema.GroupVersion{Group: rolloutAPIGroup, Version: rolloutAPIVersionV1alpha1}.String())
+	dynamicClient = dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{batchReleaseGVRForVersion(rolloutAPIVersionV1alpha1): "BatchReleaseList"},
+		testBatchRelease(rolloutAPIVersionV1alpha1, "other", "another-uid"),
+		testBatchRelease(rolloutAPIVersionV1alpha1, "web-release", "rollout-uid"))
+	found, err = findRolloutBatchRelease(rollout)
+	if err != nil || found == nil || found.GetName() != "web-release" || found.GetAPIVersion() != rolloutAPIGroup+"/"+rolloutAPIVersionV1alpha1 {
+		t.Fatalf("findRolloutBatchRelease() = %v, %v, want v1alpha1 web-release", found, err)
+	}
+}
@@ -0,0 +1,377 @@
+package handlers
+
+import (
+	"context"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/openkruise/kruise-dashboard/extensions-backend/pkg/logger"
+	"github.com/openkruise/kruise-dashboard/extensions-backend/pkg/response"
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	defaultOwnerTeamAnnotation = "kruise-dashboard.io/owner-team"
+
+	workloadPhasePaused   = "Paused"
+	workloadPhaseUpdating = "Updating"
+	workloadPhaseReady    = "Ready"
+	workloadPhaseNotReady = "NotReady"
+)
+
+// ownerTeamAnnotation returns the annotation holding a resource's owner team, configurable via
+// the OWNER_TEAM_ANNOTATION environment variable.
+func ownerTeamAnnotation() string {
+	if key := os.Getenv("OWNER_TEAM_ANNOTATION"); key != "" {
+		return key
+	}
+	return defaultOwnerTeamAnnotation
+}
+
+// listFilters are the server-side filters shared by the all-namespaces list endpoints.
+type listFilters struct {
+	LabelSelector string
+	Phase         string
+	Image         string
+	Team          string
+}
+
+func bindListFilters(c *gin.Context) (listFilters, bool) {
+	filters := listFilters{
+		LabelSelector: c.Query("labelSelector"),
+		Phase:         c.Query("phase"),
+		Image:         c.Query("image"),
+		Team:          c.Query("team"),
+	}
+	if filters.LabelSelector != "" {
+		if _, err := labels.Parse(filters.LabelSelector); err != nil {
+			response.BadRequest(c, "invalid labelSelector: "+err.Error())
+			return filters, false
+		}
+	}
+	return filters, true
+}
+
+func (f listFilters) matchesTeam(obj map[string]interface{}) bool {
+	if f.Team == "" {
+		return true
+	}
+	annotations, _, _ := unstructured.NestedStringMap(obj, "metadata", "annotations")
+	return annotations[ownerTeamAnnotation()] == f.Team
+}
+
+func (f listFilters) matchesImage(images []string) bool {
+	if f.Image == "" {
+		return true
+	}
+	for _, image := range images {
+		if strings.Contains(image, f.Image) {
+			return true
+		}
+	}
+	return false
+}
+
+// templateImages returns the container and initContainer images of the pod template at templatePath.
+func templateImages(obj map[string]interface{}, templatePath []string) []string {
+	images := []string{}
+	for _, field := range []string{"containers", "initContainers"} {
+		path := append(append([]string{}, templatePath...), "spec", field)
+		containers, _, _ := unstructured.NestedSlice(obj, path...)
+		for _, raw := range containers {
+			container, _ := raw.(map[string]interface{})
+			if image, _ := container["image"].(string); image != "" {
+				images = append(images, image)
+			}
+		}
+	}
+	return images
+}
+
+// workloadPhase derives a coarse phase for a workload from its spec and status.
+func workloadPhase(obj map[string]interface{}) string {
+	if phase, found, _ := unstructured.NestedString(obj, "status", "phase"); found && phase != "" {
+		return phase
+	}
+	for _, path := range [][]string{{"spec", "paused"}, {"spec", "updateStrategy", "paused"}, {"spec", "updateStrategy", "rollingUpdate", "paused"}} {
+		if paused, _, _ := unstructured.NestedBool(obj, path...); paused {
+			return workloadPhasePaused
+		}
+	}
+
+	generation, _, _ := unstructured.NestedInt64(obj, "metadata", "generation")
+	observedGeneration, _, _ := unstructured.NestedInt64(obj, "status", "observedGeneration")
+	status := readWorkloadReplicaStatus(obj)
+
+	switch {
+	case observedGeneration < generation || (status.HasReplicas && status.Updated < status.Replicas):
+		return workloadPhaseUpdating
+	case status.Ready >= status.Replicas:
+		return workloadPhaseReady
+	default:
+		return workloadPhaseNotReady
+	}
+}
+
+func rolloutPaused(obj map[string]interface{}) bool {
+	paused, _, _ := unstructured.NestedBool(obj, "spec", "paused")
+	_, stepState, _ := rolloutProgress(obj)
+	return paused || stepState == "StepPaused"
+}
+
+// rolloutMatchesPhase matches status.phase; "Paused" also matches paused rollouts in any phase.
+func rolloutMatchesPhase(obj map[string]interface{}, phase string) bool {
+	if phase == "" {
+		return true
+	}
+	if strings.EqualFold(phase, workloadPhasePaused) && rolloutPaused(obj) {
+		return true
+	}
+	statusPhase, _, _ := unstructured.NestedString(obj, "status", "phase")
+	return strings.EqualFold(statusPhase, phase)
+}
+
+// resolveListTypes parses the comma-separated type query parameter, defaulting to the Kruise types.
+func resolveListTypes(c *gin.Context) (map[string]WorkloadTypeInfo, bool) {
+	result := map[string]WorkloadTypeInfo{}
+	if typeParam := c.Query("type"); typeParam != "" {
+		for _, workloadType := range strings.Split(typeParam, ",") {
+			info, err := ResolveWorkloadType(strings.TrimSpace(workloadType))
+			if err != nil {
+				response.BadRequest(c, err.Error())
+				return nil, false
+			}
+			result[strings.TrimSpace(workloadType)] = info
+		}
+		return result, true
+	}
+	for name, info := range workloadTypeRegistry {
+		if info.GVR.Group != "apps" {
+			result[name] = info
+		}
+	}
+	return result, true
+}
+
+// ListClusterWorkloads lists workloads across all namespaces with server-side filters.
+func ListClusterWorkloads(c *gin.Context) {
+	filters, ok := bindListFilters(c)
+	if !ok {
+		return
+	}
+	workloadTypes, ok := resolveListTypes(c)
+	if !ok {
+		return
+	}
+	q, ok := bindListQuery(c, sortByName, sortByCreationTimestamp, sortByReadiness)
+	if !ok {
+		return
+	}
+
+	ctx := context.TODO()
+	rows := make([]map[string]interface{}, 0)
+	failedTypes := []string{}
+	for workloadType, info := range workloadTypes {
+		list, err := GetDynamicClient().Resource(info.GVR).Namespace(metav1.NamespaceAll).List(ctx, metav1.ListOptions{
+			LabelSelector: filters.LabelSelector,
+		})
+		if err != nil {
+			logger.Log.Warn("Failed to list workloads across namespaces",
+				zap.String("type", workloadType),
+				zap.Error(err),
+			)
+			failedTypes = append(failedTypes, workloadType)
+			continue
+		}
+		for i := range list.Items {
+			obj := list.Items[i].Object
+			images := templateImages(obj, info.TemplatePath)
+			phase := workloadPhase(obj)
+			if filters.Phase != "" && !strings.EqualFold(phase, filters.Phase) {
+				continue
+			}
+			if !filters.matchesImage(images) || !filters.matchesTeam(obj) {
+				continue
+			}
+			annotations := list.Items[i].GetAnnotations()
+			rows = append(rows, map[string]interface{}{
+				"namespace": list.Items[i].GetNamespace(),
+				"name":      list.Items[i].GetName(),
+				"type":      workloadType,
+				"kind":      info.Kind,
+				"phase":     phase,
+				"health":    evaluateWorkloadHealth(info.Kind, obj),
+				"images":    images,
+				"team":      annotations[ownerTeamAnnotation()],
+				"workload":  obj,
+			})
+		}
+	}
+	sortClusterRows(rows, "workload", q)
+	sort.Strings(failedTypes)
+
+	start, end, next, err := pageByOffset(len(rows), q)
+	if err != nil {
+		handleListError(c, err)
+		return
+	}
+	page := projectClusterRows(rows[start:end], "workload", q)
+
+	response.Success(c, gin.H{
+		"items":       page,
+		"total":       len(rows),
+		"continue":    next,
+		"failedTypes": failedTypes,
+	})
+}
+
+// sortClusterRows orders rows by namespace, type and name, or by q.SortBy applied to the object
+// stored under objKey.
+func sortClusterRows(rows []map[string]interface{}, objKey string, q listQuery) {
+	sort.SliceStable(rows, func(i, j int) bool {
+		if q.SortBy != "" {
+			a, _ := rows[i][objKey].(map[string]interface{})
+			b, _ := rows[j][objKey].(map[string]interface{})
+			cmp := compareObjects(a, b, q.SortBy)
+			if q.Desc {
+				return cmp > 0
+			}
+			return cmp < 0
+		}
+		for _, key := range []string{"namespace", "type", "name"} {
+			a, _ := rows[i][key].(string)
+			b, _ := rows[j][key].(string)
+			if a != b {
+				return (a < b) != q.Desc
+			}
+		}
+		return false
+	})
+}
+
+// projectClusterRows drops the full object from each row in summary view and strips managed fields
+// otherwise.
+func projectClusterRows(rows []map[string]interface{}, objKey string, q listQuery) []map[string]interface{} {
+	for _, row := range rows {
+		if q.Summary {
+			delete(row, objKey)
+			continue
+		}
+		if obj, ok := row[objKey].(map[string]interface{}); ok {
+			unstructured.RemoveNestedField(obj, "metadata", "managedFields")
+		}
+	}
+	return rows
+}
+
+// rolloutWorkloadImages maps namespace/kind/name of the workloads referenced by rollouts to their
+// images, listing each referenced kind once across all namespaces.
+func rolloutWorkloadImages(ctx context.Context, rollouts []unstructured.Unstructured) map[string][]string {
+	kinds := map[string]WorkloadTypeInfo{}
+	for i := range rollouts {
+		ref := extractWorkloadRefFromRollout(&rollouts[i])
+		kind, _ := ref["kind"].(string)
+		if kind == "" {
+			continue
+		}
+		if _, info, ok := ResolveWorkloadKind(kind); ok {
+			kinds[info.Kind] = info
+		}
+	}
+
+	images := map[string][]string{}
+	for kind, info := range kinds {
+		list, err := GetDynamicClient().Resource(info.GVR).Namespace(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			logger.Log.Warn("Failed to list rollout workloads across namespaces",
+				zap.String("kind", kind),
+				zap.Error(err),
+			)
+			continue
+		}
+		for i := range list.Items {
+			key := list.Items[i].GetNamespace() + "/" + kind + "/" + list.Items[i].GetName()
+			images[key] = templateImages(list.Items[i].Object, info.TemplatePath)
+		}
+	}
+	return images
+}
+
+// ListClusterRollouts lists rollouts across all namespaces with server-side filters.
+func ListClusterRollouts(c *gin.Context) {
+	filters, ok := bindListFilters(c)
+	if !ok {
+		return
+	}
+
+	q, ok := bindListQuery(c, sortByName, sortByCreationTimestamp)
+	if !ok {
+		return
+	}
+
+	ctx := context.TODO()
+	list, err := GetDynamicClient().Resource(rolloutGVR).Namespace(metav1.NamespaceAll).List(ctx, metav1.ListOptions{
+		LabelSelector: filters.LabelSelector,
+	})
+	if err != nil {
+		logger.Log.Error("Failed to list rollouts across namespaces", zap.Error(err))
+		response.InternalError(c, err)
+		return
+	}
+
+	var workloadImages map[string][]string
+	if filters.Image != "" {
+		workloadImages = rolloutWorkloadImages(ctx, list.Items)
+	}
+
+	rows := make([]map[string]interface{}, 0, len(list.Items))
+	for i := range list.Items {
+		obj := list.Items[i].Object
+		if !rolloutMatchesPhase(obj, filters.Phase) || !filters.matchesTeam(obj) {
+			continue
+		}
+
+		ref := extractWorkloadRefFromRollout(&list.Items[i])
+		refKind, _ := ref["kind"].(string)
+		refName, _ := ref["name"].(string)
+		if filters.Image != "" {
+			if _, info, ok := ResolveWorkloadKind(refKind); ok {
+				refKind = info.Kind
+			}
+			if !filters.matchesImage(workloadImages[list.Items[i].GetNamespace()+"/"+refKind+"/"+refName]) {
+				continue
+			}
+		}
+
+		phase, _, _ := unstructured.NestedString(obj, "status", "phase")
+		annotations := list.Items[i].GetAnnotations()
+		rows = append(rows, map[string]interface{}{
+			"namespace":   list.Items[i].GetNamespace(),
+			"name":        list.Items[i].GetName(),
+			"phase":       phase,
+			"paused":      rolloutPaused(obj),
+			"workloadRef": ref,
+			"team":        annotations[ownerTeamAnnotation()],
+			"rollout":     obj,
+		})
+	}
+	sortClusterRows(rows, "rollout", q)
+
+	start, end, next, err := pageByOffset(len(rows), q)
+	if err != nil {
+		handleListError(c, err)
+		return
+	}
+	page := projectClusterRows(rows[start:end], "rollout", q)
+
+	response.Success(c, gin.H{
+		"items":    page,
+		"total":    len(rows),
+		"continue": next,
+	})
+}
@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"testing"
+)
+
+func TestWorkloadPhase(t *testing.T) {
+	tests := []struct {
+		name string
+		obj  map[string]interface{}
+		want string
+	}{
+		{
+			name: "status phase wins",
+			obj:  map[string]interface{}{"status": map[string]interface{}{"phase": "running"}},
+			want: "running",
+		},
+		{
+			name: "paused update strategy",
+			obj: map[string]interface{}{
+				"spec":   map[string]interface{}{"updateStrategy": map[string]interface{}{"paused": true}},
+				"status": map[string]interface{}{"replicas": int64(3), "updatedReplicas": int64(1)},
+			},
+			want: workloadPhasePaused,
+		},
+		{
+			name: "updating replicas",
+			obj: map[string]interface{}{
+				"status": map[string]interface{}{"replicas": int64(3), "updatedReplicas": int64(1), "readyReplicas": int64(3)},
+			},
+			want: workloadPhaseUpdating,
+		},
+		{
+			name: "stale generation",
+			obj: map[string]interface{}{
+				"metadata": map[string]interface{}{"generation": int64(2)},
+				"status":   map[string]interface{}{"observedGeneration": int64(1), "replicas": int64(1), "updatedReplicas": int64(1), "readyReplicas": int64(1)},
+			},
+			want: workloadPhaseUpdating,
+		},
+		{
+			name: "ready",
+			obj: map[string]interface{}{
+				"status": map[string]interface{}{"replicas": int64(3), "updatedReplicas": int64(3), "readyReplicas": int64(3)},
+			},
+			want: workloadPhaseReady,
+		},
+		{
+			name: "daemonset not ready",
+			obj: map[string]interface{}{
+				"status": map[string]interface{}{"desiredNumberScheduled": int64(3), "updatedNumberScheduled": int64(3), "numberReady": int64(2)},
+			},
+			want: workloadPhaseNotReady,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := workloadPhase(tt.obj); got != tt.want {
+				t.Errorf("workloadPhase() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestListFilters(t *testing.T) {
+	obj := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]interface{}{defaultOwnerTeamAnnotation: "payments"},
+		},
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"containers":     []interface{}{map[string]interface{}{"name": "app", "image": "registry.local/app:v2"}},
+					"initContainers": []interface{}{map[string]interface{}{"name": "init", "image": "busybox:1.36"}},
+				},
+			},
+		},
+	}
+	images := templateImages(obj, defaultTemplatePath)
+	if len(images) != 2 {
+		t.Fatalf("templateImages() = %v, want 2 images", images)
+	}
+
+	tests := []struct {
+		name    string
+		filters listFilters
+		want    bool
+	}{
+		{name: "no filters", filters: listFilters{}, want: true},
+		{name: "image substring", filters: listFilters{Image: "app:v2"}, want: true},
+		{name: "init container image", filters: listFilters{Image: "busybox"}, want: true},
+		{name: "image mismatch", filters: listFilters{Image: "nginx"}, want: false},
+		{name: "team match", filters: listFilters{Team: "payments"}, want: true},
+		{name: "team mismatch", filters: listFilters{Team: "search"}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filters.matchesImage(images) && tt.filters.matchesTeam(obj); got != tt.want {
+				t.Errorf("match = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRolloutMatchesPhase(t *testing.T) {
+	paused := map[string]interface{}{
+		"spec":   map[string]interface{}{"paused": true},
+		"status": map[string]interface{}{"phase": "Progressing"},
+	}
+	stepPaused := map[string]interface{}{
+		"status": map[string]interface{}{
+			"phase":        "Progressing",
+			"canaryStatus": map[string]interface{}{"currentStepState": "StepPaused"},
+		},
+	}
+	healthy := map[string]interface{}{"status": map[string]interface{}{"phase": "Healthy"}}
+
+	if !rolloutMatchesPhase(paused, "paused") || !rolloutMatchesPhase(stepPaused, "Paused") {
+		t.Error("paused rollouts should match phase=Paused")
+	}
+	if !rolloutMatchesPhase(paused, "Progressing") {
+		t.Error("paused rollout should still match its status phase")
+	}
+	if rolloutMatchesPhase(healthy, "Paused") || !rolloutMatchesPhase(healthy, "healthy") {
+		t.Error("healthy rollout phase matching is wrong")
+	}
+}
@@ -0,0 +1,271 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/openkruise/kruise-dashboard/extensions-backend/pkg/logger"
+	"github.com/openkruise/kruise-dashboard/extensions-backend/pkg/prometheus"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	clusterUsageProviderNone       = "none"
+	clusterUsageProviderKubelet    = "kubelet"
+	clusterUsageProviderPrometheus = "prometheus"
+
+	clusterUsageTimeout        = 10 * time.Second
+	kubeletSummaryConcurrency  = 10
+	prometheusStorageFilter    = `mountpoint="/",fstype!~"tmpfs|overlay|squashfs"`
+	prometheusNetworkFilter    = `device!~"lo|veth.*|cali.*|flannel.*|cni.*|docker.*|cilium.*|vxlan.*|tunl.*"`
+	prometheusNetworkRateRange = "5m"
+	// kubeletNetworkBaselineMaxAge is the oldest counter sample a kubelet throughput is computed
+	// from; older baselines would average over a window the caller does not expect.
+	kubeletNetworkBaselineMaxAge = 5 * time.Minute
+)
+
+// clusterUsage is the storage and network usage of the cluster's nodes. The Has fields tell which
+// parts the provider could measure, and the Nodes fields over how many nodes the sums were taken.
+type clusterUsage struct {
+	HasStorage           bool
+	StorageNodes         int32
+	StorageUsedBytes     int64
+	StorageCapacityBytes int64
+
+	HasNetwork                    bool
+	NetworkNodes                  int32
+	NetworkReceiveBytesPerSecond  float64
+	NetworkTransmitBytesPerSecond float64
+}
+
+// clusterUsageProvider measures what the metrics API does not: node filesystem usage and network
+// throughput.
+type clusterUsageProvider interface {
+	Usage(ctx context.Context, nodes []corev1.Node) (clusterUsage, error)
+}
+
+// usageProvider is nil when CLUSTER_USAGE_PROVIDER is unset or none.
+var usageProvider clusterUsageProvider
+
+// InitClusterUsageProvider selects the storage and network provider from CLUSTER_USAGE_PROVIDER:
+// none (default), kubelet (summary API through the node proxy) or prometheus (node-exporter
+// metrics at PROMETHEUS_URL).
+func InitClusterUsageProvider() error {
+	switch name := os.Getenv("CLUSTER_USAGE_PROVIDER"); name {
+	case "", clusterUsageProviderNone:
+		usageProvider = nil
+	case clusterUsageProviderKubelet:
+		usageProvider = newKubeletUsageProvider(fetchKubeletSummary)
+	case clusterUsageProviderPrometheus:
+		address := os.Getenv("PROMETHEUS_URL")
+		if address == "" {
+			return fmt.Errorf("PROMETHEUS_URL is required when CLUSTER_USAGE_PROVIDER is %s", clusterUsageProviderPrometheus)
+		}
+		usageProvider = &prometheusUsageProvider{client: &prometheus.Client{BaseURL: address, HTTPClient: &http.Client{Timeout: clusterUsageTimeout}}}
+	default:
+		return fmt.Errorf("CLUSTER_USAGE_PROVIDER must be one of %s, %s, %s, got %q",
+			clusterUsageProviderNone, clusterUsageProviderKubelet, clusterUsageProviderPrometheus, name)
+	}
+	return nil
+}
+
+// kubeletSummary is the part of the kubelet /stats/summary response used here. The network
+// counters are those of the node's default interface.
+type kubeletSummary struct {
+	Node struct {
+		Fs *struct {
+			CapacityBytes *uint64 `json:"capacityBytes"`
+			UsedBytes     *uint64 `json:"usedBytes"`
+		} `json:"fs"`
+		Network *struct {
+			Time    time.Time `json:"time"`
+			RxBytes *uint64   `json:"rxBytes"`
+			TxBytes *uint64   `json:"txBytes"`
+		} `json:"network"`
+	} `json:"node"`
+}
+
+// networkCounters are the cumulative byte counters of a node at the time the kubelet sampled them,
+// and the throughput computed when they were read, if any.
+type networkCounters struct {
+	time           time.Time
+	rx, tx         uint64
+	hasRate        bool
+	rxRate, txRate float64
+}
+
+// kubeletUsageProvider reads each node's kubelet summary. Throughput is the change of the byte
+// counters between the kubelet's own sample times, so a node reports no network usage until it has
+// been read twice within kubeletNetworkBaselineMaxAge.
+type kubeletUsageProvider struct {
+	fetch func(ctx context.Context, node string) ([]byte, error)
+
+	mu   sync.Mutex
+	last map[string]networkCounters
+}
+
+func newKubeletUsageProvider(fetch func(ctx context.Context, node string) ([]byte, error)) *kubeletUsageProvider {
+	return &kubeletUsageProvider{fetch: fetch, last: map[string]networkCounters{}}
+}
+
+func fetchKubeletSummary(ctx context.Context, node string) ([]byte, error) {
+	return GetK8sClient().CoreV1().RESTClient().Get().
+		AbsPath("/api/v1/nodes", node, "proxy", "stats", "summary").
+		DoRaw(ctx)
+}
+
+func nodeIsReady(node *corev1.Node) bool {
+	for _, cond := range node.Status.Conditions {
+		if cond.Type == corev1.NodeReady {
+			return cond.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// Usage reads the summaries of the ready nodes concurrently. Nodes whose summary cannot be read
+// are left out; it is an error only if none can be read.
+func (p *kubeletUsageProvider) Usage(ctx context.Context, nodes []corev1.Node) (clusterUsage, error) {
+	summaries := map[string]kubeletSummary{}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	var lastErr error
+	slots := make(chan struct{}, kubeletSummaryConcurrency)
+	for i := range nodes {
+		if !nodeIsReady(&nodes[i]) {
+			continue
+		}
+		name := nodes[i].Name
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			var summary kubeletSummary
+			data, err := p.fetch(ctx, name)
+			if err == nil {
+				err = json.Unmarshal(data, &summary)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				logger.Log.Warn("Failed to read kubelet summary", zap.String("node", name), zap.Error(err))
+				lastErr = err
+				return
+			}
+			summaries[name] = summary
+		}()
+	}
+	wg.Wait()
+	if len(summaries) == 0 && lastErr != nil {
+		return clusterUsage{}, lastErr
+	}
+	return p.aggregate(summaries), nil
+}
+
+func (p *kubeletUsageProvider) aggregate(summaries map[string]kubeletSummary) clusterUsage {
+	var usage clusterUsage
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for name, summary := range summaries {
+		if fs := summary.Node.Fs; fs != nil && fs.CapacityBytes != nil && fs.UsedBytes != nil {
+			usage.HasStorage = true
+			usage.StorageNodes++
+			usage.StorageCapacityBytes += int64(*fs.CapacityBytes)
+			usage.StorageUsedBytes += int64(*fs.UsedBytes)
+		}
+
+		network := summary.Node.Network
+		if network == nil || network.Time.IsZero() || network.RxBytes == nil || network.TxBytes == nil {
+			delete(p.last, name)
+			continue
+		}
+		current := networkCounters{time: network.Time, rx: *network.RxBytes, tx: *network.TxBytes}
+		previous, ok := p.last[name]
+		if ok && current.time.Equal(previous.time) {
+			// The kubelet has not sampled again since the last read; keep its baseline and rate.
+			current = previous
+		} else {
+			elapsed := current.time.Sub(previous.time)
+			// Counters that went backwards were reset, for example by a node reboot.
+			if ok && elapsed > 0 && elapsed <= kubeletNetworkBaselineMaxAge && current.rx >= previous.rx && current.tx >= previous.tx {
+				current.hasRate = true
+				current.rxRate = float64(current.rx-previous.rx) / elapsed.Seconds()
+				current.txRate = float64(current.tx-previous.tx) / elapsed.Seconds()
+			}
+			p.last[name] = current
+		}
+		if !current.hasRate {
+			continue
+		}
+		usage.HasNetwork = true
+		usage.NetworkNodes++
+		usage.NetworkReceiveBytesPerSecond += current.rxRate
+		usage.NetworkTransmitBytesPerSecond += current.txRate
+	}
+	for name := range p.last {
+		if _, ok := summaries[name]; !ok {
+			delete(p.last, name)
+		}
+	}
+	return usage
+}
+
+// prometheusUsageProvider computes usage from node-exporter metrics: the root filesystems of the
+// nodes and the throughput of their physical interfaces.
+type prometheusUsageProvider struct {
+	client *prometheus.Client
+}
+
+func (p *prometheusUsageProvider) Usage(ctx context.Context, _ []corev1.Node) (clusterUsage, error) {
+	var usage clusterUsage
+	queries := []struct {
+		query string
+		set   func(float64)
+	}{
+		{"sum(node_filesystem_size_bytes{" + prometheusStorageFilter + "})", func(v float64) { usage.StorageCapacityBytes = int64(v) }},
+		{"sum(node_filesystem_size_bytes{" + prometheusStorageFilter + "} - node_filesystem_avail_bytes{" + prometheusStorageFilter + "})", func(v float64) { usage.StorageUsedBytes = int64(v) }},
+		{"sum(rate(node_network_receive_bytes_total{" + prometheusNetworkFilter + "}[" + prometheusNetworkRateRange + "]))", func(v float64) { usage.NetworkReceiveBytesPerSecond = v }},
+		{"sum(rate(node_network_transmit_bytes_total{" + prometheusNetworkFilter + "}[" + prometheusNetworkRateRange + "]))", func(v float64) { usage.NetworkTransmitBytesPerSecond = v }},
+		{"count(count by (instance) (node_filesystem_size_bytes{" + prometheusStorageFilter + "}))", func(v float64) { usage.StorageNodes = int32(v) }},
+		{"count(count by (instance) (node_network_receive_bytes_total{" + prometheusNetworkFilter + "}))", func(v float64) { usage.NetworkNodes = int32(v) }},
+	}
+	found := make([]bool, len(queries))
+	for i, q := range queries {
+		value, ok, err := p.client.Query(ctx, q.query)
+		if err != nil {
+			return clusterUsage{}, err
+		}
+		if ok {
+			q.set(value)
+			found[i] = true
+		}
+	}
+	usage.HasStorage = found[0] && found[1]
+	usage.HasNetwork = found[2] && found[3]
+	return usage, nil
+}
+
+// readClusterUsage returns the usage from the configured provider. Without a provider, or when it
+// fails, nothing is available.
+func readClusterUsage(nodes []corev1.Node) clusterUsage {
+	if usageProvider == nil {
+		return clusterUsage{}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), clusterUsageTimeout)
+	defer cancel()
+	usage, err := usageProvider.Usage(ctx, nodes)
+	if err != nil {
+		logger.Log.Warn("Failed to read cluster storage and network usage", zap.Error(err))
+		return clusterUsage{}
+	}
+	return usage
+}
@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openkruise/kruise-dashboard/extensions-backend/pkg/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func readyNode(name string, ready bool) corev1.Node {
+	status := corev1.ConditionTrue
+	if !ready {
+		status = corev1.ConditionFalse
+	}
+	return corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status:     corev1.NodeStatus{Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: status}}},
+	}
+}
+
+func TestKubeletUsageProvider(t *testing.T) {
+	counters := map[string]uint64{"a": 1000, "b": 5000}
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	sampled := map[string]time.Time{"a": start, "b": start}
+	provider := newKubeletUsageProvider(func(_ context.Context, node string) ([]byte, error) {
+		if node == "broken" {
+			return nil, errors.New("proxy error")
+		}
+		return []byte(fmt.Sprintf(`{"node":{"fs":{"capacityBytes":1000,"usedBytes":250},"network":{"time":%q,"rxBytes":%d,"txBytes":%d}}}`,
+			sampled[node].Format(time.RFC3339), counters[node], 2*counters[node])), nil
+	})
+	nodes := []corev1.Node{readyNode("a", true), readyNode("b", true), readyNode("broken", true), readyNode("down", false)}
+
+	first, err := provider.Usage(context.Background(), nodes)
+	if err != nil {
+		t.Fatalf("Usage() error = %v", err)
+	}
+	if !first.HasStorage || first.StorageNodes != 2 || first.StorageCapacityBytes != 2000 || first.StorageUsedBytes != 500 {
+		t.Errorf("storage = %+v, want 500/2000 from the two readable nodes", first)
+	}
+	if first.HasNetwork {
+		t.Error("the first call has no previous counters and should not report network usage")
+	}
+
+	sampled["a"] = start.Add(10 * time.Second)
+	sampled["b"] = start.Add(10 * time.Second)
+	counters["a"] += 1000
+	counters["b"] = 10 // reset by a reboot
+	second, err := provider.Usage(context.Background(), nodes)
+	if err != nil {
+		t.Fatalf("Usage() error = %v", err)
+	}
+	if !second.HasNetwork || second.NetworkNodes != 1 || second.NetworkReceiveBytesPerSecond != 100 || second.NetworkTransmitBytesPerSecond != 200 {
+		t.Errorf("network = %+v, want 100/200 B/s from node a only", second)
+	}
+
+	// The kubelet has not sampled node a again: its rate is kept. Node b's baseline is too old.
+	sampled["b"] = sampled["b"].Add(kubeletNetworkBaselineMaxAge + time.Second)
+	counters["b"] += 1000
+	third, err := provider.Usage(context.Background(), nodes)
+	if err != nil {
+		t.Fatalf("Usage() error = %v", err)
+	}
+	if third.NetworkNodes != 1 || third.NetworkReceiveBytesPerSecond != 100 {
+		t.Errorf("network = %+v, want node a's previous rate only", third)
+	}
+
+	if _, err := provider.Usage(context.Background(), []corev1.Node{readyNode("broken", true)}); err == nil {
+		t.Error("Usage() should fail when no summary can be read")
+	}
+}
+
+func TestPrometheusUsageProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query().Get("query")
+		value := ""
+		switch {
+		case strings.Contains(query, "node_filesystem_avail_bytes"):
+			value = "400"
+		case strings.Contains(query, "node_filesystem_size_bytes"):
+			value = "1000"
+		}
+		result := "[]"
+		if value != "" {
+			result = `[{"metric":{},"value":[1700000000,"` + value + `"]}]`
+		}
+		w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":` + result + `}}`))
+	}))
+	defer server.Close()
+
+	provider := &prometheusUsageProvider{client: &prometheus.Client{BaseURL: server.URL}}
+	usage, err := provider.Usage(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("Usage() error = %v", err)
+	}
+	if !usage.HasStorage || usage.StorageCapacityBytes != 1000 || usage.StorageUsedBytes != 400 {
+		t.Errorf("storage = %+v", usage)
+	}
+	if usage.HasNetwork {
+		t.Error("network should be unavailable when the node-exporter series are missing")
+	}
+}
+
+func TestClusterMetricsAvailability(t *testing.T) {
+	nodes := &corev1.NodeList{Items: []corev1.Node{readyNode("a", true), readyNode("b", false)}}
+	pods := &corev1.PodList{Items: []corev1.Pod{{Status: corev1.PodStatus{Phase: corev1.PodRunning}}}}
+
+	metrics := clusterMetrics(0, 0, false, clusterUsage{}, nodes, pods)
+	if metrics.Available != (ClusterMetricsAvailability{}) || metrics.StorageUsage != 0 || metrics.NetworkUsage != nil {
+		t.Errorf("metrics = %+v, want nothing available", metrics)
+	}
+	if metrics.TotalNodes != 2 || metrics.ReadyNodes != 1 || metrics.RunningPods != 1 {
+		t.Errorf("counts = %+v", metrics)
+	}
+
+	usage := clusterUsage{
+		HasStorage: true, StorageNodes: 1, StorageUsedBytes: 250, StorageCapacityBytes: 1000,
+		HasNetwork: true, NetworkNodes: 1, NetworkReceiveBytesPerSecond: 100, NetworkTransmitBytesPerSecond: 50,
+	}
+	metrics = clusterMetrics(40, 60, true, usage, nodes, pods)
+	want := ClusterMetricsAvailability{CPU: true, Memory: true, Storage: true, Network: true}
+	if metrics.Available != want || metrics.StorageUsage != 25 || metrics.NetworkUsage != nil || metrics.NetworkReceiveBytesPerSecond != 100 {
+		t.Errorf("metrics = %+v", metrics)
+	}
+	if metrics.StorageNodes != 1 || metrics.NetworkNodes != 1 || metrics.ReadyNodes != 1 {
+		t.Errorf("metrics = %+v", metrics)
+	}
+}
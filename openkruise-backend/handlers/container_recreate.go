@@ -0,0 +1,539 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/openkruise/kruise-dashboard/extensions-backend/pkg/logger"
+	"github.com/openkruise/kruise-dashboard/extensions-backend/pkg/response"
+	"go.uber.org/zap"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const (
+	crrBatchLabel = "kruise-dashboard.io/recreate-batch"
+	// crrWorkloadLabel holds crrWorkloadKey of the workload, since names may exceed the 63
+	// characters a label value allows.
+	crrWorkloadLabel = "kruise-dashboard.io/recreate-workload"
+
+	crrPhaseCompleted = "Completed"
+
+	crrContainerPhaseSucceeded = "Succeeded"
+	crrContainerPhaseFailed    = "Failed"
+
+	crrBatchPhaseRunning   = "Running"
+	crrBatchPhaseSucceeded = "Succeeded"
+	crrBatchPhaseFailed    = "Failed"
+
+	crrPollInterval      = 3 * time.Second
+	crrBatchStepTimeout  = 10 * time.Minute
+	crrDefaultTTLSeconds = int64(3600)
+	// crrBatchStateTTL keeps a finished batch's tracker as long as kruise keeps its CRRs.
+	crrBatchStateTTL = time.Duration(crrDefaultTTLSeconds) * time.Second
+
+	errorCodeContainerNotFound = "CONTAINER_NOT_FOUND"
+	errorCodePodNotFound       = "POD_NOT_FOUND"
+	errorCodeRecreateNotFound  = "RECREATE_BATCH_NOT_FOUND"
+)
+
+var containerRecreateRequestGVR = schema.GroupVersionResource{
+	Group:    "apps.kruise.io",
+	Version:  "v1alpha1",
+	Resource: "containerrecreaterequests",
+}
+
+type recreateContainersRequest struct {
+	Containers                []string `json:"containers" openapi:"required"`
+	Pods                      []string `json:"pods"`
+	Parallelism               int      `json:"parallelism" openapi:"min=0"`
+	FailurePolicy             string   `json:"failurePolicy" openapi:"enum=Fail|Ignore"`
+	OrderedRecreate           bool     `json:"orderedRecreate"`
+	UnreadyGracePeriodSeconds *int64   `json:"unreadyGracePeriodSeconds" openapi:"min=0"`
+	ActiveDeadlineSeconds     *int64   `json:"activeDeadlineSeconds" openapi:"min=0"`
+}
+
+// crrBatchState tracks the pods of a recreate batch that have not yet been handed to kruise.
+// CRR objects themselves are the source of truth for everything already created.
+type crrBatchState struct {
+	workloadKey string
+
+	mu          sync.Mutex
+	pendingPods []string
+	phase       string
+	message     string
+}
+
+func (s *crrBatchState) snapshot() ([]string, string, string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	pending := append([]string{}, s.pendingPods...)
+	return pending, s.phase, s.message
+}
+
+var crrBatches sync.Map // batch id -> *crrBatchState, removed crrBatchStateTTL after the batch finishes
+
+// crrWorkloadKey identifies a workload in the crrWorkloadLabel of its CRRs.
+func crrWorkloadKey(kind, name string) string {
+	sum := sha256.Sum256([]byte(kind + "/" + name))
+	return hex.EncodeToString(sum[:16])
+}
+
+func bindRecreateContainersRequest(c *gin.Context) (recreateContainersRequest, bool) {
+	var req recreateContainersRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request payload")
+		return req, false
+	}
+	if len(req.Containers) == 0 {
+		response.BadRequest(c, "containers is required")
+		return req, false
+	}
+	if req.Parallelism < 0 {
+		response.BadRequest(c, "parallelism must be a non-negative integer")
+		return req, false
+	}
+	if req.Parallelism == 0 {
+		req.Parallelism = 1
+	}
+	switch req.FailurePolicy {
+	case "":
+		req.FailurePolicy = "Fail"
+	case "Fail", "Ignore":
+	default:
+		response.BadRequest(c, "failurePolicy must be Fail or Ignore")
+		return req, false
+	}
+	return req, true
+}
+
+// podContainerNames returns the regular container names declared in a pod spec.
+func podContainerNames(pod map[string]interface{}) map[string]bool {
+	names := map[string]bool{}
+	containers, _, _ := unstructured.NestedSlice(pod, "spec", "containers")
+	for _, raw := range containers {
+		container, _ := raw.(map[string]interface{})
+		if name, _ := container["name"].(string); name != "" {
+			names[name] = true
+		}
+	}
+	return names
+}
+
+// selectRecreateTargets picks the pods to recreate containers on and verifies every requested
+// container exists in each of them. An empty podNames selects all workload pods.
+func selectRecreateTargets(pods []interface{}, podNames, containers []string) ([]string, string, error) {
+	byName := map[string]map[string]interface{}{}
+	for _, raw := range pods {
+		pod, ok := raw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _, _ := unstructured.NestedString(pod, "metadata", "name")
+		byName[name] = pod
+	}
+
+	targets := podNames
+	if len(targets) == 0 {
+		targets = make([]string, 0, len(byName))
+		for name := range byName {
+			targets = append(targets, name)
+		}
+		sort.Strings(targets)
+	}
+
+	for _, podName := range targets {
+		pod, ok := byName[podName]
+		if !ok {
+			return nil, errorCodePodNotFound, fmt.Errorf("pod %s does not belong to the workload", podName)
+		}
+		declared := podContainerNames(pod)
+		for _, container := range containers {
+			if !declared[container] {
+				return nil, errorCodeContainerNotFound, fmt.Errorf("container %s not found in pod %s", container, podName)
+			}
+		}
+	}
+	return targets, "", nil
+}
+
+func buildContainerRecreateRequest(namespace, podName, batchID, workloadKey string, req recreateContainersRequest) *unstructured.Unstructured {
+	containers := make([]interface{}, 0, len(req.Containers))
+	for _, name := range req.Containers {
+		containers = append(containers, map[string]interface{}{"name": name})
+	}
+
+	strategy := map[string]interface{}{
+		"failurePolicy":   req.FailurePolicy,
+		"orderedRecreate": req.OrderedRecreate,
+	}
+	if req.UnreadyGracePeriodSeconds != nil {
+		strategy["unreadyGracePeriodSeconds"] = *req.UnreadyGracePeriodSeconds
+	}
+
+	spec := map[string]interface{}{
+		"podName":                 podName,
+		"containers":              containers,
+		"strategy":                strategy,
+		"ttlSecondsAfterFinished": crrDefaultTTLSeconds,
+	}
+	if req.ActiveDeadlineSeconds != nil {
+		spec["activeDeadlineSeconds"] = *req.ActiveDeadlineSeconds
+	}
+
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": containerRecreateRequestGVR.GroupVersion().String(),
+		"kind":       "ContainerRecreateRequest",
+		"metadata": map[string]interface{}{
+			"generateName": podName + "-",
+			"namespace":    namespace,
+			"labels": map[string]interface{}{
+				crrBatchLabel:    batchID,
+				crrWorkloadLabel: workloadKey,
+			},
+		},
+		"spec": spec,
+	}}
+}
+
+// crrFinished reports whether a CRR has completed and whether any of its containers failed.
+func crrFinished(crr map[string]interface{}) (bool, bool) {
+	phase, _, _ := unstructured.NestedString(crr, "status", "phase")
+	states, _, _ := unstructured.NestedSlice(crr, "status", "containerRecreateStates")
+	failed := false
+	for _, raw := range states {
+		state, _ := raw.(map[string]interface{})
+		if p, _ := state["phase"].(string); p == crrContainerPhaseFailed {
+			failed = true
+		}
+	}
+	return phase == crrPhaseCompleted, failed
+}
+
+func splitIntoBatches(items []string, size int) [][]string {
+	if size <= 0 {
+		size = 1
+	}
+	batches := make([][]string, 0, (len(items)+size-1)/size)
+	for start := 0; start < len(items); start += size {
+		end := start + size
+		if end > len(items) {
+			end = len(items)
+		}
+		batches = append(batches, items[start:end])
+	}
+	return batches
+}
+
+// waitForCRRs polls the given CRRs until all of them complete. It returns true if any container failed.
+func waitForCRRs(namespace string, names []string) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), crrBatchStepTimeout)
+	defer cancel()
+
+	ticker := time.NewTicker(crrPollInterval)
+	defer ticker.Stop()
+
+	for {
+		done, anyFailed := true, false
+		for _, name := range names {
+			crr, err := GetDynamicClient().Resource(containerRecreateRequestGVR).Namespace(namespace).Get(ctx, name, metav1.GetOptions{})
+			if err != nil {
+				if apierrors.IsNotFound(err) {
+					// Already cleaned up by ttlSecondsAfterFinished.
+					continue
+				}
+				return false, err
+			}
+			finished, failed := crrFinished(crr.Object)
+			if !finished {
+				done = false
+			}
+			anyFailed = anyFailed || failed
+		}
+		if done {
+			return anyFailed, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return anyFailed, fmt.Errorf("timed out waiting for container recreate requests")
+		case <-ticker.C:
+		}
+	}
+}
+
+// runContainerRecreateBatches creates CRRs for the targets in batches of req.Parallelism pods,
+// waiting for each batch to complete before starting the next one.
+func runContainerRecreateBatches(namespace, workloadName, batchID string, targets []string, req recreateContainersRequest, state *crrBatchState) {
+	finish := func(phase, message string) {
+		state.mu.Lock()
+		state.phase = phase
+		state.message = message
+		state.mu.Unlock()
+		time.AfterFunc(crrBatchStateTTL, func() { crrBatches.Delete(batchID) })
+	}
+
+	for _, batch := range splitIntoBatches(targets, req.Parallelism) {
+		created := make([]string, 0, len(batch))
+		for _, podName := range batch {
+			obj := buildContainerRecreateRequest(namespace, podName, batchID, state.workloadKey, req)
+			crr, err := GetDynamicClient().Resource(containerRecreateRequestGVR).Namespace(namespace).Create(context.TODO(), obj, metav1.CreateOptions{})
+			if err != nil {
+				logger.Log.Error("Failed to create container recreate request",
+					zap.String("namespace", namespace),
+					zap.String("pod", podName),
+					zap.String("batch", batchID),
+					zap.Error(err),
+				)
+				finish(crrBatchPhaseFailed, fmt.Sprintf("failed to create recreate request for pod %s: %v", podName, err))
+				return
+			}
+			created = append(created, crr.GetName())
+			state.mu.Lock()
+			state.pendingPods = state.pendingPods[1:]
+			state.mu.Unlock()
+		}
+
+		failed, err := waitForCRRs(namespace, created)
+		if err != nil {
+			logger.Log.Warn("Container recreate batch did not complete",
+				zap.String("namespace", namespace),
+				zap.String("batch", batchID),
+				zap.Error(err),
+			)
+			finish(crrBatchPhaseFailed, err.Error())
+			return
+		}
+		if failed && req.FailurePolicy == "Fail" {
+			finish(crrBatchPhaseFailed, "container recreation failed, remaining pods were skipped")
+			return
+		}
+	}
+
+	logger.Log.Info("Container recreate batch finished",
+		zap.String("namespace", namespace),
+		zap.String("workload", workloadName),
+		zap.String("batch", batchID),
+	)
+	finish(crrBatchPhaseSucceeded, "")
+}
+
+// RecreateWorkloadContainers recreates selected containers on a workload's pods via
+// ContainerRecreateRequest, without rolling the pod template.
+func RecreateWorkloadContainers(c *gin.Context) {
+	namespace := c.Param("namespace")
+	workloadType := c.Param("type")
+	name := c.Param("name")
+
+	req, ok := bindRecreateContainersRequest(c)
+	if !ok {
+		return
+	}
+
+	info, err := ResolveWorkloadType(workloadType)
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	workload, err := GetDynamicClient().Resource(info.GVR).Namespace(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		logger.Log.Error("Failed to get workload for container recreate",
+			zap.String("namespace", namespace),
+			zap.String("type", workloadType),
+			zap.String("name", name),
+			zap.Error(err),
+		)
+		response.InternalError(c, err)
+		return
+	}
+
+	pods, err := listWorkloadPods(namespace, name, info, workload)
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+
+	targets, code, err := selectRecreateTargets(pods, req.Pods, req.Containers)
+	if err != nil {
+		response.Error(c, http.StatusNotFound, err.Error(), nil, code)
+		return
+	}
+	if len(targets) == 0 {
+		response.Error(c, http.StatusNotFound, "Workload has no pods", nil, errorCodePodNotFound)
+		return
+	}
+
+	batchID := strings.Split(uuid.New().String(), "-")[0]
+	state := &crrBatchState{
+		workloadKey: crrWorkloadKey(info.Kind, name),
+		pendingPods: append([]string{}, targets...),
+		phase:       crrBatchPhaseRunning,
+	}
+	crrBatches.Store(batchID, state)
+
+	go runContainerRecreateBatches(namespace, name, batchID, targets, req, state)
+
+	logger.Log.Info("Started container recreate batch",
+		zap.String("namespace", namespace),
+		zap.String("type", workloadType),
+		zap.String("name", name),
+		zap.String("batch", batchID),
+		zap.Strings("containers", req.Containers),
+		zap.Int("pods", len(targets)),
+	)
+
+	response.Success(c, gin.H{
+		"message":     fmt.Sprintf("Recreating containers on %d pods", len(targets)),
+		"batchId":     batchID,
+		"pods":        targets,
+		"containers":  req.Containers,
+		"parallelism": req.Parallelism,
+	})
+}
+
+func summarizeContainerRecreateRequest(crr map[string]interface{}) map[string]interface{} {
+	name, _, _ := unstructured.NestedString(crr, "metadata", "name")
+	podName, _, _ := unstructured.NestedString(crr, "spec", "podName")
+	phase, _, _ := unstructured.NestedString(crr, "status", "phase")
+	message, _, _ := unstructured.NestedString(crr, "status", "message")
+	completionTime, _, _ := unstructured.NestedString(crr, "status", "completionTime")
+	if phase == "" {
+		phase = "Pending"
+	}
+
+	states, _, _ := unstructured.NestedSlice(crr, "status", "containerRecreateStates")
+	containers := make([]map[string]interface{}, 0, len(states))
+	for _, raw := range states {
+		state, _ := raw.(map[string]interface{})
+		if state == nil {
+			continue
+		}
+		containers = append(containers, map[string]interface{}{
+			"name":    state["name"],
+			"phase":   state["phase"],
+			"message": state["message"],
+		})
+	}
+
+	return map[string]interface{}{
+		"name":           name,
+		"pod":            podName,
+		"phase":          phase,
+		"message":        message,
+		"completionTime": completionTime,
+		"containers":     containers,
+	}
+}
+
+// GetWorkloadContainerRecreateStatus reports per-pod and per-container progress of a recreate batch.
+func GetWorkloadContainerRecreateStatus(c *gin.Context) {
+	namespace := c.Param("namespace")
+	name := c.Param("name")
+	batchID := c.Param("batch")
+
+	info, err := ResolveWorkloadType(c.Param("type"))
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+	// Batch IDs are label values; anything else cannot name a batch and must not reach the selector.
+	if errs := validation.IsValidLabelValue(batchID); len(errs) > 0 {
+		response.BadRequest(c, fmt.Sprintf("invalid batch ID %q: %s", batchID, strings.Join(errs, "; ")))
+		return
+	}
+	workloadKey := crrWorkloadKey(info.Kind, name)
+
+	list, err := GetDynamicClient().Resource(containerRecreateRequestGVR).Namespace(namespace).List(context.TODO(), metav1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(labels.Set{crrBatchLabel: batchID, crrWorkloadLabel: workloadKey}).String(),
+	})
+	if err != nil {
+		logger.Log.Error("Failed to list container recreate requests",
+			zap.String("namespace", namespace),
+			zap.String("name", name),
+			zap.String("batch", batchID),
+			zap.Error(err),
+		)
+		response.InternalError(c, err)
+		return
+	}
+
+	pendingPods, phase, message := []string{}, "", ""
+	if raw, ok := crrBatches.Load(batchID); ok && raw.(*crrBatchState).workloadKey == workloadKey {
+		pendingPods, phase, message = raw.(*crrBatchState).snapshot()
+	} else if len(list.Items) == 0 {
+		response.Error(c, http.StatusNotFound, "Recreate batch not found", nil, errorCodeRecreateNotFound)
+		return
+	}
+
+	requests := make([]map[string]interface{}, 0, len(list.Items))
+	requested := map[string]bool{}
+	succeeded, failed, inProgress := 0, 0, 0
+	for i := range list.Items {
+		summary := summarizeContainerRecreateRequest(list.Items[i].Object)
+		requests = append(requests, summary)
+		requested[summary["pod"].(string)] = true
+		finished, anyFailed := crrFinished(list.Items[i].Object)
+		switch {
+		case anyFailed:
+			failed++
+		case finished:
+			succeeded++
+		default:
+			inProgress++
+		}
+	}
+	sort.Slice(requests, func(i, j int) bool {
+		return requests[i]["pod"].(string) < requests[j]["pod"].(string)
+	})
+	// The snapshot may predate the list, so a pod can already have its CRR.
+	stillPending := make([]string, 0, len(pendingPods))
+	for _, pod := range pendingPods {
+		if !requested[pod] {
+			stillPending = append(stillPending, pod)
+		}
+	}
+	pendingPods = stillPending
+
+	if phase == "" {
+		// The tracker is lost after a backend restart; derive the phase from the CRRs.
+		switch {
+		case inProgress > 0:
+			phase = crrBatchPhaseRunning
+		case failed > 0:
+			phase = crrBatchPhaseFailed
+		default:
+			phase = crrBatchPhaseSucceeded
+		}
+	}
+
+	response.Success(c, gin.H{
+		"batchId":     batchID,
+		"workload":    name,
+		"namespace":   namespace,
+		"phase":       phase,
+		"message":     message,
+		"pendingPods": pendingPods,
+		"requests":    requests,
+		"summary": gin.H{
+			"total":      len(requests) + len(pendingPods),
+			"succeeded":  succeeded,
+			"failed":     failed,
+			"inProgress": inProgress,
+			"pending":    len(pendingPods),
+		},
+	})
+}
@@ -0,0 +1,200 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+func testPod(name string, containers ...string) map[string]interface{} {
+	list := make([]interface{}, 0, len(containers))
+	for _, c := range containers {
+		list = append(list, map[string]interface{}{"name": c})
+	}
+	return map[string]interface{}{
+		"metadata": map[string]interface{}{"name": name},
+		"spec":     map[string]interface{}{"containers": list},
+	}
+}
+
+func TestSelectRecreateTargets(t *testing.T) {
+	pods := []interface{}{
+		testPod("web-b", "app", "sidecar"),
+		testPod("web-a", "app", "sidecar"),
+	}
+
+	tests := []struct {
+		name       string
+		podNames   []string
+		containers []string
+		want       []string
+		wantCode   string
+	}{
+		{
+			name:       "all pods sorted by name",
+			containers: []string{"sidecar"},
+			want:       []string{"web-a", "web-b"},
+		},
+		{
+			name:       "selected pod",
+			podNames:   []string{"web-b"},
+			containers: []string{"app"},
+			want:       []string{"web-b"},
+		},
+		{
+			name:       "pod outside workload",
+			podNames:   []string{"other"},
+			containers: []string{"app"},
+			wantCode:   errorCodePodNotFound,
+		},
+		{
+			name:       "unknown container",
+			containers: []string{"missing"},
+			wantCode:   errorCodeContainerNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, code, err := selectRecreateTargets(pods, tt.podNames, tt.containers)
+			if tt.wantCode != "" {
+				if err == nil || code != tt.wantCode {
+					t.Fatalf("selectRecreateTargets() code = %q, err = %v, want code %q", code, err, tt.wantCode)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("selectRecreateTargets() unexpected error: %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("selectRecreateTargets() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("selectRecreateTargets()[%d] = %q, want %q", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestSplitIntoBatches(t *testing.T) {
+	batches := splitIntoBatches([]string{"a", "b", "c", "d", "e"}, 2)
+	if len(batches) != 3 {
+		t.Fatalf("len(batches) = %d, want 3", len(batches))
+	}
+	if len(batches[2]) != 1 || batches[2][0] != "e" {
+		t.Errorf("last batch = %v, want [e]", batches[2])
+	}
+	if got := splitIntoBatches([]string{"a"}, 0); len(got) != 1 {
+		t.Errorf("zero size should fall back to 1, got %v", got)
+	}
+}
+
+func TestCRRFinished(t *testing.T) {
+	crr := map[string]interface{}{
+		"status": map[string]interface{}{
+			"phase": "Completed",
+			"containerRecreateStates": []interface{}{
+				map[string]interface{}{"name": "app", "phase": "Succeeded"},
+				map[string]interface{}{"name": "sidecar", "phase": "Failed"},
+			},
+		},
+	}
+	finished, failed := crrFinished(crr)
+	if !finished || !failed {
+		t.Errorf("crrFinished() = (%v, %v), want (true, true)", finished, failed)
+	}
+
+	finished, failed = crrFinished(map[string]interface{}{})
+	if finished || failed {
+		t.Errorf("crrFinished(empty) = (%v, %v), want (false, false)", finished, failed)
+	}
+}
+
+func TestBuildContainerRecreateRequest(t *testing.T) {
+	req := recreateContainersRequest{Containers: []string{"sidecar"}, FailurePolicy: "Fail"}
+	longName := strings.Repeat("web", 30)
+	obj := buildContainerRecreateRequest("default", "web-a", "abc123", crrWorkloadKey("CloneSet", longName), req)
+
+	if obj.GetKind() != "ContainerRecreateRequest" {
+		t.Errorf("kind = %q, want ContainerRecreateRequest", obj.GetKind())
+	}
+	if obj.GetLabels()[crrBatchLabel] != "abc123" {
+		t.Errorf("batch label = %q, want abc123", obj.GetLabels()[crrBatchLabel])
+	}
+	if errs := validation.IsValidLabelValue(obj.GetLabels()[crrWorkloadLabel]); len(errs) > 0 {
+		t.Errorf("workload label of a %d-character name is invalid: %v", len(longName), errs)
+	}
+	if crrWorkloadKey("CloneSet", "web") == crrWorkloadKey("StatefulSet", "web") {
+		t.Error("workloads of different kinds with the same name should not share a key")
+	}
+	podName, _, _ := unstructured.NestedString(obj.Object, "spec", "podName")
+	if podName != "web-a" {
+		t.Errorf("spec.podName = %q, want web-a", podName)
+	}
+}
+
+func TestContainerRecreateStatusCountsEachPodOnce(t *testing.T) {
+	previous := dynamicClient
+	defer func() { dynamicClient = previous }()
+
+	key := crrWorkloadKey("CloneSet", "web")
+	crr := buildContainerRecreateRequest("default", "web-a", "abc123", key, recreateContainersRequest{Containers: []string{"sidecar"}})
+	crr.SetName("web-a-x1")
+	dynamicClient = dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{containerRecreateRequestGVR: "ContainerRecreateRequestList"}, crr)
+
+	// The tracker has not yet dropped web-a although its CRR exists.
+	crrBatches.Store("abc123", &crrBatchState{workloadKey: key, pendingPods: []string{"web-a", "web-b"}, phase: crrBatchPhaseRunning})
+	defer crrBatches.Delete("abc123")
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/api/v1/workload/:namespace/:type/:name/recreate-containers/:batch", GetWorkloadContainerRecreateStatus)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/workload/default/cloneset/web/recreate-containers/abc123", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+
+	var resp struct {
+		Data struct {
+			PendingPods []string       `json:"pendingPods"`
+			Summary     map[string]int `json:"summary"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if resp.Data.Summary["total"] != 2 || resp.Data.Summary["pending"] != 1 || len(resp.Data.PendingPods) != 1 || resp.Data.PendingPods[0] != "web-b" {
+		t.Errorf("pendingPods = %v, summary = %v, want web-b pending of 2", resp.Data.PendingPods, resp.Data.Summary)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/workload/default/statefulset/web/recreate-containers/abc123", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("another workload's batch: status = %d, want 404", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/workload/default/cloneset/web/recreate-containers/unknown", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("unknown batch: status = %d, want 404", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/workload/default/cloneset/web/recreate-containers/abc123,app=web", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("batch ID with a comma: status = %d, want 400", w.Code)
+	}
+}
@@ -0,0 +1,389 @@
+package handlers
+
+import (
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// WorkloadSummary is the compact projection of a workload returned by list endpoints with view=summary.
+type WorkloadSummary struct {
+	Name              string            `json:"name"`
+	Namespace         string            `json:"namespace"`
+	Type              string            `json:"type"`
+	Kind              string            `json:"kind"`
+	Phase             string            `json:"phase"`
+	Health            WorkloadHealth    `json:"health"`
+	Replicas          int64             `json:"replicas"`
+	ReadyReplicas     int64             `json:"readyReplicas"`
+	UpdatedReplicas   int64             `json:"updatedReplicas"`
+	AvailableReplicas int64             `json:"availableReplicas"`
+	Images            []string          `json:"images"`
+	Labels            map[string]string `json:"labels,omitempty"`
+	CreationTimestamp string            `json:"creationTimestamp"`
+	// Autoscaler is the HPA targeting the workload; only the detail endpoint looks it up.
+	Autoscaler *HPASummary `json:"autoscaler,omitempty"`
+}
+
+// RolloutSummary is the compact projection of a rollout returned by list endpoints with view=summary.
+type RolloutSummary struct {
+	Name              string                 `json:"name"`
+	Namespace         string                 `json:"namespace"`
+	Strategy          string                 `json:"strategy" openapi:"enum=canary|partition|blueGreen"`
+	Phase             string                 `json:"phase"`
+	Paused            bool                   `json:"paused"`
+	WorkloadRef       map[string]interface{} `json:"workloadRef,omitempty"`
+	CurrentStepIndex  int64                  `json:"currentStepIndex"`
+	CurrentStepState  string                 `json:"currentStepState,omitempty"`
+	TotalSteps        int                    `json:"totalSteps"`
+	Message           string                 `json:"message,omitempty"`
+	CreationTimestamp string                 `json:"creationTimestamp"`
+}
+
+// workloadReplicaStatus is the replica counters of a workload status. DaemonSets report scheduled
+// pods instead of replicas and are mapped onto the same fields.
+type workloadReplicaStatus struct {
+	Replicas    int64
+	Ready       int64
+	Updated     int64
+	Available   int64
+	HasReplicas bool
+}
+
+func readWorkloadReplicaStatus(obj map[string]interface{}) workloadReplicaStatus {
+	var status workloadReplicaStatus
+	status.Replicas, status.HasReplicas, _ = unstructured.NestedInt64(obj, "status", "replicas")
+	if status.HasReplicas {
+		status.Ready, _, _ = unstructured.NestedInt64(obj, "status", "readyReplicas")
+		status.Updated, _, _ = unstructured.NestedInt64(obj, "status", "updatedReplicas")
+		status.Available, _, _ = unstructured.NestedInt64(obj, "status", "availableReplicas")
+		return status
+	}
+	status.Replicas, status.HasReplicas, _ = unstructured.NestedInt64(obj, "status", "desiredNumberScheduled")
+	status.Ready, _, _ = unstructured.NestedInt64(obj, "status", "numberReady")
+	status.Updated, _, _ = unstructured.NestedInt64(obj, "status", "updatedNumberScheduled")
+	status.Available, _, _ = unstructured.NestedInt64(obj, "status", "numberAvailable")
+	return status
+}
+
+func formatTimestamp(t metav1.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func summarizeWorkload(obj *unstructured.Unstructured, workloadType string, info WorkloadTypeInfo) WorkloadSummary {
+	status := readWorkloadReplicaStatus(obj.Object)
+	return WorkloadSummary{
+		Name:              obj.GetName(),
+		Namespace:         obj.GetNamespace(),
+		Type:              workloadType,
+		Kind:              info.Kind,
+		Phase:             workloadPhase(obj.Object),
+		Health:            evaluateWorkloadHealth(info.Kind, obj.Object),
+		Replicas:          status.Replicas,
+		ReadyReplicas:     status.Ready,
+		UpdatedReplicas:   status.Updated,
+		AvailableReplicas: status.Available,
+		Images:            templateImages(obj.Object, info.TemplatePath),
+		Labels:            obj.GetLabels(),
+		CreationTimestamp: formatTimestamp(obj.GetCreationTimestamp()),
+	}
+}
+
+func summarizeRollout(obj *unstructured.Unstructured) RolloutSummary {
+	phase, _, _ := unstructured.NestedString(obj.Object, "status", "phase")
+	message, _, _ := unstructured.NestedString(obj.Object, "status", "message")
+	stepIndex, stepState, _ := rolloutProgress(obj.Object)
+	steps, _, _ := unstructured.NestedSlice(obj.Object, rolloutStepsPath(obj.Object)...)
+	return RolloutSummary{
+		Name:              obj.GetName(),
+		Namespace:         obj.GetNamespace(),
+		Strategy:          rolloutStrategyType(obj.Object),
+		Phase:             phase,
+		Paused:            rolloutPaused(obj.Object),
+		WorkloadRef:       extractWorkloadRefFromRollout(obj),
+		CurrentStepIndex:  stepIndex,
+		CurrentStepState:  stepState,
+		TotalSteps:        len(steps),
+		Message:           message,
+		CreationTimestamp: formatTimestamp(obj.GetCreationTimestamp()),
+	}
+}
+
+// ListData is the envelope of every /api/v2 list: items of one type, the number of items in this
+// page, and the token of the next page if there is one.
+type ListData[T any] struct {
+	Items    []T    `json:"items"`
+	Count    int    `json:"count"`
+	Continue string `json:"continue,omitempty"`
+}
+
+func newListData[T any](items []T, next string) ListData[T] {
+	if items == nil {
+		items = []T{}
+	}
+	return ListData[T]{Items: items, Count: len(items), Continue: next}
+}
+
+// ContainerSummary is a container or initContainer of a pod template.
+type ContainerSummary struct {
+	Name  string `json:"name"`
+	Image string `json:"image"`
+	Type  string `json:"type"`
+}
+
+// PodSummary is the compact projection of a pod.
+type PodSummary struct {
+	Name              string             `json:"name"`
+	Namespace         string             `json:"namespace"`
+	Phase             string             `json:"phase"`
+	Ready             bool               `json:"ready"`
+	Restarts          int64              `json:"restarts"`
+	NodeName          string             `json:"nodeName,omitempty"`
+	PodIP             string             `json:"podIP,omitempty"`
+	Revision          string             `json:"revision,omitempty"`
+	Containers        []ContainerSummary `json:"containers"`
+	CreationTimestamp string             `json:"creationTimestamp"`
+}
+
+// Revision is a group of pods sharing a pod template revision of a rollout's workload.
+type Revision struct {
+	Name            string             `json:"name"`
+	Revision        string             `json:"revision,omitempty"`
+	PodTemplateHash string             `json:"podTemplateHash"`
+	IsStable        bool               `json:"isStable"`
+	IsCanary        bool               `json:"isCanary"`
+	Role            string             `json:"role"`
+	Replicas        int64              `json:"replicas"`
+	ReadyReplicas   int64              `json:"readyReplicas"`
+	Pods            []PodSummary       `json:"pods"`
+	Containers      []ContainerSummary `json:"containers"`
+}
+
+// RolloutStep is one step of a rollout. State is completed, current or pending relative to
+// the currentStepIndex of status.canaryStatus or status.blueGreenStatus.
+type RolloutStep struct {
+	Index         int                      `json:"index"`
+	State         string                   `json:"state"`
+	Replicas      string                   `json:"replicas,omitempty"`
+	Traffic       string                   `json:"traffic,omitempty"`
+	Pause         bool                     `json:"pause"`
+	PauseDuration *int64                   `json:"pauseDuration,omitempty"`
+	Matches       []map[string]interface{} `json:"matches,omitempty"`
+}
+
+// Condition is a status condition.
+type Condition struct {
+	Type               string `json:"type"`
+	Status             string `json:"status"`
+	Reason             string `json:"reason,omitempty"`
+	Message            string `json:"message,omitempty"`
+	LastTransitionTime string `json:"lastTransitionTime,omitempty"`
+}
+
+// RolloutDetail is a rollout with its steps, revisions and conditions.
+type RolloutDetail struct {
+	RolloutSummary
+	Disabled           bool          `json:"disabled"`
+	StableRevision     string        `json:"stableRevision,omitempty"`
+	CanaryRevision     string        `json:"canaryRevision,omitempty"`
+	ObservedGeneration int64         `json:"observedGeneration"`
+	Steps              []RolloutStep `json:"steps"`
+	// Actions are the /rollout actions that apply to the rollout's strategy and state.
+	Actions    []string    `json:"actions"`
+	Conditions []Condition `json:"conditions"`
+	// TrafficRouting is set for rollouts with spec.strategy.canary.trafficRoutings.
+	TrafficRouting *TrafficRouting `json:"trafficRouting,omitempty"`
+}
+
+func summarizeContainers(raw []map[string]interface{}) []ContainerSummary {
+	containers := make([]ContainerSummary, 0, len(raw))
+	for _, container := range raw {
+		name, _ := container["name"].(string)
+		image, _ := container["image"].(string)
+		containerType, _ := container["type"].(string)
+		containers = append(containers, ContainerSummary{Name: name, Image: image, Type: containerType})
+	}
+	return containers
+}
+
+func summarizePod(pod map[string]interface{}) PodSummary {
+	obj := unstructured.Unstructured{Object: pod}
+	phase, _, _ := unstructured.NestedString(pod, "status", "phase")
+	nodeName, _, _ := unstructured.NestedString(pod, "spec", "nodeName")
+	podIP, _, _ := unstructured.NestedString(pod, "status", "podIP")
+
+	var restarts int64
+	statuses, _, _ := unstructured.NestedSlice(pod, "status", "containerStatuses")
+	for _, raw := range statuses {
+		status, _ := raw.(map[string]interface{})
+		count, _, _ := unstructured.NestedInt64(status, "restartCount")
+		restarts += count
+	}
+
+	podLabels := obj.GetLabels()
+	revision := podLabels["controller-revision-hash"]
+	if revision == "" {
+		revision = podLabels["pod-template-hash"]
+	}
+
+	containers := []ContainerSummary{}
+	for _, field := range []string{"containers", "initContainers"} {
+		containerType := "container"
+		if field == "initContainers" {
+			containerType = "initContainer"
+		}
+		specContainers, _, _ := unstructured.NestedSlice(pod, "spec", field)
+		for _, raw := range specContainers {
+			container, _ := raw.(map[string]interface{})
+			name, _ := container["name"].(string)
+			image, _ := container["image"].(string)
+			containers = append(containers, ContainerSummary{Name: name, Image: image, Type: containerType})
+		}
+	}
+
+	return PodSummary{
+		Name:              obj.GetName(),
+		Namespace:         obj.GetNamespace(),
+		Phase:             phase,
+		Ready:             isPodReady(pod),
+		Restarts:          restarts,
+		NodeName:          nodeName,
+		PodIP:             podIP,
+		Revision:          revision,
+		Containers:        containers,
+		CreationTimestamp: formatTimestamp(obj.GetCreationTimestamp()),
+	}
+}
+
+func summarizePods(pods []interface{}) []PodSummary {
+	summaries := make([]PodSummary, 0, len(pods))
+	for _, raw := range pods {
+		if pod, ok := raw.(map[string]interface{}); ok {
+			summaries = append(summaries, summarizePod(pod))
+		}
+	}
+	return summaries
+}
+
+// revisionFromMap converts a revision built by buildRevisionsForWorkload.
+func revisionFromMap(raw map[string]interface{}) Revision {
+	revision := Revision{}
+	revision.Name, _ = raw["name"].(string)
+	revision.Revision, _ = raw["revision"].(string)
+	revision.PodTemplateHash, _ = raw["podTemplateHash"].(string)
+	revision.IsStable, _ = raw["isStable"].(bool)
+	revision.IsCanary, _ = raw["isCanary"].(bool)
+	revision.Role, _ = raw["role"].(string)
+	revision.Replicas, _ = raw["replicas"].(int64)
+	revision.ReadyReplicas, _ = raw["readyReplicas"].(int64)
+	pods, _ := raw["pods"].([]interface{})
+	revision.Pods = summarizePods(pods)
+	containers, _ := raw["containers"].([]map[string]interface{})
+	revision.Containers = summarizeContainers(containers)
+	return revision
+}
+
+// intOrStringText renders an int-or-percent field such as a step's replicas.
+func intOrStringText(obj map[string]interface{}, fields ...string) string {
+	value, ok := intOrStringFromUnstructured(obj, fields...)
+	if !ok {
+		return ""
+	}
+	return value.String()
+}
+
+func rolloutSteps(obj map[string]interface{}) []RolloutStep {
+	rawSteps, _, _ := unstructured.NestedSlice(obj, rolloutStepsPath(obj)...)
+	currentIndex, _, _ := rolloutProgress(obj)
+	steps := make([]RolloutStep, 0, len(rawSteps))
+	for i, raw := range rawSteps {
+		step, _ := raw.(map[string]interface{})
+		if step == nil {
+			continue
+		}
+		result := RolloutStep{
+			Index:    i + 1,
+			State:    rolloutStepState(i+1, currentIndex),
+			Replicas: intOrStringText(step, "replicas"),
+			Traffic:  intOrStringText(step, "traffic"),
+		}
+		if result.Traffic == "" {
+			// v1alpha1 steps carry an integer weight instead of traffic.
+			if weight, found, _ := unstructured.NestedInt64(step, "weight"); found {
+				result.Traffic = fmt.Sprintf("%d%%", weight)
+			}
+		}
+		if matches, found, _ := unstructured.NestedSlice(step, "matches"); found {
+			for _, match := range matches {
+				if m, ok := match.(map[string]interface{}); ok {
+					result.Matches = append(result.Matches, m)
+				}
+			}
+		}
+		if pause, found, _ := unstructured.NestedMap(step, "pause"); found {
+			result.Pause = true
+			if duration, ok, _ := unstructured.NestedInt64(pause, "duration"); ok {
+				result.PauseDuration = &duration
+			}
+		}
+		steps = append(steps, result)
+	}
+	return steps
+}
+
+const (
+	rolloutStepStateCompleted = "completed"
+	rolloutStepStateCurrent   = "current"
+	rolloutStepStatePending   = "pending"
+)
+
+func rolloutStepState(index int, currentIndex int64) string {
+	switch {
+	case int64(index) < currentIndex:
+		return rolloutStepStateCompleted
+	case int64(index) == currentIndex:
+		return rolloutStepStateCurrent
+	default:
+		return rolloutStepStatePending
+	}
+}
+
+func statusConditions(obj map[string]interface{}) []Condition {
+	rawConditions, _, _ := unstructured.NestedSlice(obj, "status", "conditions")
+	conditions := make([]Condition, 0, len(rawConditions))
+	for _, raw := range rawConditions {
+		cond, _ := raw.(map[string]interface{})
+		if cond == nil {
+			continue
+		}
+		condition := Condition{}
+		condition.Type, _ = cond["type"].(string)
+		condition.Status, _ = cond["status"].(string)
+		condition.Reason, _ = cond["reason"].(string)
+		condition.Message, _ = cond["message"].(string)
+		condition.LastTransitionTime, _ = cond["lastTransitionTime"].(string)
+		conditions = append(conditions, condition)
+	}
+	return conditions
+}
+
+func detailRollout(obj *unstructured.Unstructured) RolloutDetail {
+	disabled, _, _ := unstructured.NestedBool(obj.Object, "spec", "disabled")
+	observedGeneration, _, _ := unstructured.NestedInt64(obj.Object, "status", "observedGeneration")
+	stableRevision, canaryRevision := extractRolloutRevisions(obj)
+	return RolloutDetail{
+		RolloutSummary:     summarizeRollout(obj),
+		Disabled:           disabled,
+		StableRevision:     stableRevision,
+		CanaryRevision:     canaryRevision,
+		ObservedGeneration: observedGeneration,
+		Steps:              rolloutSteps(obj.Object),
+		Actions:            rolloutActions(obj.Object),
+		Conditions:         statusConditions(obj.Object),
+	}
+}
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"encoding/json"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestNewListDataNeverNull(t *testing.T) {
+	data, err := json.Marshal(newListData[RolloutSummary](nil, ""))
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if string(data) != `{"items":[],"count":0}` {
+		t.Errorf("newListData(nil) = %s, want empty items", data)
+	}
+}
+
+func TestSummarizePod(t *testing.T) {
+	pod := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"name":      "web-abc",
+			"namespace": "default",
+			"labels":    map[string]interface{}{"controller-revision-hash": "web-7d9f"},
+		},
+		"spec": map[string]interface{}{
+			"nodeName":       "node-1",
+			"containers":     []interface{}{map[string]interface{}{"name": "app", "image": "app:v2"}},
+			"initContainers": []interface{}{map[string]interface{}{"name": "init", "image": "busybox"}},
+		},
+		"status": map[string]interface{}{
+			"phase":      "Running",
+			"conditions": []interface{}{map[string]interface{}{"type": "Ready", "status": "True"}},
+			"containerStatuses": []interface{}{
+				map[string]interface{}{"name": "app", "restartCount": int64(2)},
+				map[string]interface{}{"name": "sidecar", "restartCount": int64(1)},
+			},
+		},
+	}
+
+	got := summarizePod(pod)
+	if got.Name != "web-abc" || got.Phase != "Running" || !got.Ready || got.NodeName != "node-1" {
+		t.Errorf("summarizePod() = %+v", got)
+	}
+	if got.Restarts != 3 {
+		t.Errorf("Restarts = %d, want 3", got.Restarts)
+	}
+	if got.Revision != "web-7d9f" {
+		t.Errorf("Revision = %q, want web-7d9f", got.Revision)
+	}
+	if len(got.Containers) != 2 || got.Containers[1].Type != "initContainer" {
+		t.Errorf("Containers = %+v", got.Containers)
+	}
+}
+
+func TestDetailRollout(t *testing.T) {
+	rollout := &unstructured.Unstructured{Object: map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "web", "namespace": "default"},
+		"spec": map[string]interface{}{
+			"workloadRef": map[string]interface{}{"kind": "CloneSet", "name": "web"},
+			"strategy": map[string]interface{}{
+				"canary": map[string]interface{}{
+					"steps": []interface{}{
+						map[string]interface{}{"replicas": int64(1), "pause": map[string]interface{}{}},
+						map[string]interface{}{"replicas": "50%", "traffic": "50%", "pause": map[string]interface{}{"duration": int64(60)}},
+						map[string]interface{}{"replicas": "100%"},
+					},
+				},
+			},
+		},
+		"status": map[string]interface{}{
+			"phase": "Progressing",
+			"canaryStatus": map[string]interface{}{
+				"currentStepIndex": int64(2),
+				"currentStepState": "StepPaused",
+				"canaryRevision":   "web-new",
+			},
+			"conditions": []interface{}{
+				map[string]interface{}{"type": "Progressing", "status": "True", "reason": "InRolling"},
+			},
+		},
+	}}
+
+	got := detailRollout(rollout)
+	if got.TotalSteps != 3 || len(got.Steps) != 3 {
+		t.Fatalf("steps = %d/%d, want 3", got.TotalSteps, len(got.Steps))
+	}
+	if got.Steps[0].Replicas != "1" || !got.Steps[0].Pause || got.Steps[0].PauseDuration != nil {
+		t.Errorf("Steps[0] = %+v", got.Steps[0])
+	}
+	if got.Steps[1].Traffic != "50%" || got.Steps[1].PauseDuration == nil || *got.Steps[1].PauseDuration != 60 {
+		t.Errorf("Steps[1] = %+v", got.Steps[1])
+	}
+	if got.Steps[2].Pause {
+		t.Errorf("Steps[2].Pause = true, want false")
+	}
+	if !got.Paused || got.CurrentStepIndex != 2 || got.CanaryRevision != "web-new" {
+		t.Errorf("detailRollout() = %+v", got)
+	}
+	if len(got.Conditions) != 1 || got.Conditions[0].Reason != "InRolling" {
+		t.Errorf("Conditions = %+v", got.Conditions)
+	}
+}
@@ -0,0 +1,271 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const (
+	healthHealthy     = "Healthy"
+	healthProgressing = "Progressing"
+	healthDegraded    = "Degraded"
+	healthSuspended   = "Suspended"
+	healthUnknown     = "Unknown"
+
+	// rolloutControlAnnotation is set by kruise-rollout on a workload whose release its BatchRelease
+	// controls; rolloutInProgressingAnnotation while a Rollout of the workload is progressing. Such a
+	// Deployment is paused by kruise-rollout to release it in batches, not suspended by a user.
+	rolloutControlAnnotation       = "batchrelease.rollouts.kruise.io/control-info"
+	rolloutInProgressingAnnotation = "rollouts.kruise.io/in-progressing"
+)
+
+// WorkloadHealth is the health of a workload evaluated from its conditions, observedGeneration
+// and replica counts, with the reason for it.
+type WorkloadHealth struct {
+	Status string `json:"status" openapi:"enum=Healthy|Progressing|Degraded|Suspended|Unknown"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// workloadHealthEvaluators evaluate the health of a workload by kind. Kinds without an evaluator,
+// such as custom workload types, are evaluated from their replica counts.
+var workloadHealthEvaluators = map[string]func(obj map[string]interface{}) WorkloadHealth{
+	"Deployment":      deploymentHealth,
+	"CloneSet":        cloneSetHealth,
+	"StatefulSet":     statefulSetHealth,
+	"DaemonSet":       daemonSetHealth,
+	"BroadcastJob":    broadcastJobHealth,
+	"AdvancedCronJob": advancedCronJobHealth,
+}
+
+// evaluateWorkloadHealth evaluates the health of a workload of the given kind.
+func evaluateWorkloadHealth(kind string, obj map[string]interface{}) WorkloadHealth {
+	if evaluate, ok := workloadHealthEvaluators[kind]; ok {
+		return evaluate(obj)
+	}
+	if _, found, _ := unstructured.NestedFieldNoCopy(obj, "status"); !found {
+		return WorkloadHealth{Status: healthUnknown, Reason: "workload has no status"}
+	}
+	if health, done := generationHealth(obj); done {
+		return health
+	}
+	status := readWorkloadReplicaStatus(obj)
+	if !status.HasReplicas {
+		return WorkloadHealth{Status: healthUnknown, Reason: "workload status has no replica counts"}
+	}
+	desired := specReplicas(obj)
+	return replicaHealth(desired, status.Replicas, desired, status.Updated, status.Available)
+}
+
+// generationHealth reports a workload whose latest spec the controller has not yet observed.
+func generationHealth(obj map[string]interface{}) (WorkloadHealth, bool) {
+	generation, _, _ := unstructured.NestedInt64(obj, "metadata", "generation")
+	observedGeneration, found, _ := unstructured.NestedInt64(obj, "status", "observedGeneration")
+	if found && observedGeneration < generation {
+		return WorkloadHealth{Status: healthProgressing, Reason: fmt.Sprintf("waiting for the controller to observe generation %d", generation)}, true
+	}
+	return WorkloadHealth{}, false
+}
+
+// workloadCondition returns the status, reason and message of a status condition.
+func workloadCondition(obj map[string]interface{}, conditionType string) (string, string, string, bool) {
+	conditions, _, _ := unstructured.NestedSlice(obj, "status", "conditions")
+	for _, raw := range conditions {
+		condition, _ := raw.(map[string]interface{})
+		if condition["type"] != conditionType {
+			continue
+		}
+		status, _ := condition["status"].(string)
+		reason, _ := condition["reason"].(string)
+		message, _ := condition["message"].(string)
+		return status, reason, message, true
+	}
+	return "", "", "", false
+}
+
+func conditionReason(reason, message string) string {
+	if message == "" {
+		return reason
+	}
+	return reason + ": " + message
+}
+
+// specReplicas returns spec.replicas, which defaults to 1.
+func specReplicas(obj map[string]interface{}) int64 {
+	if replicas, found, _ := unstructured.NestedInt64(obj, "spec", "replicas"); found {
+		return replicas
+	}
+	return 1
+}
+
+// replicaHealth evaluates a workload from its replica counts. expectedUpdated is lower than desired
+// while a partition holds back part of the update.
+func replicaHealth(desired, current, expectedUpdated, updated, available int64) WorkloadHealth {
+	switch {
+	case updated < expectedUpdated:
+		return WorkloadHealth{Status: healthProgressing, Reason: fmt.Sprintf("%d of %d replicas updated", updated, expectedUpdated)}
+	case current > desired:
+		return WorkloadHealth{Status: healthProgressing, Reason: fmt.Sprintf("%d old replicas pending termination", current-desired)}
+	case available < desired:
+		return WorkloadHealth{Status: healthDegraded, Reason: fmt.Sprintf("%d of %d replicas unavailable", desired-available, desired)}
+	case expectedUpdated < desired:
+		return WorkloadHealth{Status: healthHealthy, Reason: fmt.Sprintf("update held at partition, %d of %d replicas updated", updated, desired)}
+	}
+	return WorkloadHealth{Status: healthHealthy, Reason: fmt.Sprintf("%d of %d replicas available", available, desired)}
+}
+
+// partitionedReplicas returns the replicas expected to be updated under a partition at path. A
+// percentage partition counts replicas rounded up, as kruise does.
+func partitionedReplicas(obj map[string]interface{}, desired int64, path ...string) int64 {
+	raw, found, _ := unstructured.NestedFieldNoCopy(obj, path...)
+	if !found {
+		return desired
+	}
+	var partition int64
+	switch value := raw.(type) {
+	case int64:
+		partition = value
+	case float64:
+		partition = int64(value)
+	case string:
+		var percent int64
+		if _, err := fmt.Sscanf(strings.TrimSuffix(value, "%"), "%d", &percent); err == nil {
+			partition = (desired*percent + 99) / 100
+		}
+	}
+	if partition > desired {
+		partition = desired
+	}
+	if partition < 0 {
+		partition = 0
+	}
+	return desired - partition
+}
+
+// rolloutControlled reports whether kruise-rollout is releasing the workload.
+func rolloutControlled(obj map[string]interface{}) bool {
+	annotations, _, _ := unstructured.NestedStringMap(obj, "metadata", "annotations")
+	return annotations[rolloutControlAnnotation] != "" || annotations[rolloutInProgressingAnnotation] != ""
+}
+
+func deploymentHealth(obj map[string]interface{}) WorkloadHealth {
+	if paused, _, _ := unstructured.NestedBool(obj, "spec", "paused"); paused && !rolloutControlled(obj) {
+		return WorkloadHealth{Status: healthSuspended, Reason: "rollout is paused"}
+	}
+	if health, done := generationHealth(obj); done {
+		return health
+	}
+	if status, reason, message, ok := workloadCondition(obj, "Progressing"); ok && status == "False" && reason == "ProgressDeadlineExceeded" {
+		return WorkloadHealth{Status: healthDegraded, Reason: conditionReason(reason, message)}
+	}
+	if status, reason, message, ok := workloadCondition(obj, "ReplicaFailure"); ok && status == "True" {
+		return WorkloadHealth{Status: healthDegraded, Reason: conditionReason(reason, message)}
+	}
+
+	desired := specReplicas(obj)
+	current, _, _ := unstructured.NestedInt64(obj, "status", "replicas")
+	updated, _, _ := unstructured.NestedInt64(obj, "status", "updatedReplicas")
+	available, _, _ := unstructured.NestedInt64(obj, "status", "availableReplicas")
+	return replicaHealth(desired, current, desired, updated, available)
+}
+
+func cloneSetHealth(obj map[string]interface{}) WorkloadHealth {
+	if paused, _, _ := unstructured.NestedBool(obj, "spec", "updateStrategy", "paused"); paused {
+		return WorkloadHealth{Status: healthSuspended, Reason: "update is paused"}
+	}
+	if health, done := generationHealth(obj); done {
+		return health
+	}
+	if status, reason, message, ok := workloadCondition(obj, "FailedScale"); ok && status == "True" {
+		return WorkloadHealth{Status: healthDegraded, Reason: conditionReason(reason, message)}
+	}
+	if status, reason, message, ok := workloadCondition(obj, "FailedUpdate"); ok && status == "True" {
+		return WorkloadHealth{Status: healthDegraded, Reason: conditionReason(reason, message)}
+	}
+
+	desired := specReplicas(obj)
+	current, _, _ := unstructured.NestedInt64(obj, "status", "replicas")
+	updated, _, _ := unstructured.NestedInt64(obj, "status", "updatedReplicas")
+	available, _, _ := unstructured.NestedInt64(obj, "status", "availableReplicas")
+	expected, found, _ := unstructured.NestedInt64(obj, "status", "expectedUpdatedReplicas")
+	if !found {
+		expected = partitionedReplicas(obj, desired, "spec", "updateStrategy", "partition")
+	}
+	return replicaHealth(desired, current, expected, updated, available)
+}
+
+func statefulSetHealth(obj map[string]interface{}) WorkloadHealth {
+	if paused, _, _ := unstructured.NestedBool(obj, "spec", "updateStrategy", "rollingUpdate", "paused"); paused {
+		return WorkloadHealth{Status: healthSuspended, Reason: "update is paused"}
+	}
+	if health, done := generationHealth(obj); done {
+		return health
+	}
+
+	desired := specReplicas(obj)
+	current, _, _ := unstructured.NestedInt64(obj, "status", "replicas")
+	updated, _, _ := unstructured.NestedInt64(obj, "status", "updatedReplicas")
+	available, found, _ := unstructured.NestedInt64(obj, "status", "availableReplicas")
+	if !found {
+		available, _, _ = unstructured.NestedInt64(obj, "status", "readyReplicas")
+	}
+	expected := partitionedReplicas(obj, desired, "spec", "updateStrategy", "rollingUpdate", "partition")
+	return replicaHealth(desired, current, expected, updated, available)
+}
+
+func daemonSetHealth(obj map[string]interface{}) WorkloadHealth {
+	if paused, _, _ := unstructured.NestedBool(obj, "spec", "updateStrategy", "rollingUpdate", "paused"); paused {
+		return WorkloadHealth{Status: healthSuspended, Reason: "update is paused"}
+	}
+	if health, done := generationHealth(obj); done {
+		return health
+	}
+
+	desired, _, _ := unstructured.NestedInt64(obj, "status", "desiredNumberScheduled")
+	updated, _, _ := unstructured.NestedInt64(obj, "status", "updatedNumberScheduled")
+	available, _, _ := unstructured.NestedInt64(obj, "status", "numberAvailable")
+	if misscheduled, _, _ := unstructured.NestedInt64(obj, "status", "numberMisscheduled"); misscheduled > 0 {
+		return WorkloadHealth{Status: healthDegraded, Reason: fmt.Sprintf("%d pods running on nodes they should not run on", misscheduled)}
+	}
+	expected := partitionedReplicas(obj, desired, "spec", "updateStrategy", "rollingUpdate", "partition")
+	return replicaHealth(desired, desired, expected, updated, available)
+}
+
+func broadcastJobHealth(obj map[string]interface{}) WorkloadHealth {
+	if paused, _, _ := unstructured.NestedBool(obj, "spec", "paused"); paused {
+		return WorkloadHealth{Status: healthSuspended, Reason: "job is paused"}
+	}
+	if status, reason, message, ok := workloadCondition(obj, "Failed"); ok && status == "True" {
+		return WorkloadHealth{Status: healthDegraded, Reason: conditionReason(reason, message)}
+	}
+
+	desired, _, _ := unstructured.NestedInt64(obj, "status", "desired")
+	succeeded, _, _ := unstructured.NestedInt64(obj, "status", "succeeded")
+	failed, _, _ := unstructured.NestedInt64(obj, "status", "failed")
+	active, _, _ := unstructured.NestedInt64(obj, "status", "active")
+	if status, _, _, ok := workloadCondition(obj, "Complete"); ok && status == "True" {
+		return WorkloadHealth{Status: healthHealthy, Reason: fmt.Sprintf("completed on %d of %d nodes", succeeded, desired)}
+	}
+	if failed > 0 {
+		return WorkloadHealth{Status: healthDegraded, Reason: fmt.Sprintf("%d pods failed, %d of %d succeeded", failed, succeeded, desired)}
+	}
+	if _, found, _ := unstructured.NestedFieldNoCopy(obj, "status"); !found {
+		return WorkloadHealth{Status: healthUnknown, Reason: "job has no status"}
+	}
+	return WorkloadHealth{Status: healthProgressing, Reason: fmt.Sprintf("%d active, %d of %d succeeded", active, succeeded, desired)}
+}
+
+func advancedCronJobHealth(obj map[string]interface{}) WorkloadHealth {
+	if paused, _, _ := unstructured.NestedBool(obj, "spec", "paused"); paused {
+		return WorkloadHealth{Status: healthSuspended, Reason: "schedule is paused"}
+	}
+	active, _, _ := unstructured.NestedSlice(obj, "status", "active")
+	if len(active) > 0 {
+		return WorkloadHealth{Status: healthProgressing, Reason: fmt.Sprintf("%d jobs running", len(active))}
+	}
+	if lastSchedule, found, _ := unstructured.NestedString(obj, "status", "lastScheduleTime"); found && lastSchedule != "" {
+		return WorkloadHealth{Status: healthHealthy, Reason: "last scheduled at " + lastSchedule}
+	}
+	return WorkloadHealth{Status: healthHealthy, Reason: "not scheduled yet"}
+}
@@ -0,0 +1,148 @@
+package handlers
+
+import "testing"
+
+func TestEvaluateWorkloadHealth(t *testing.T) {
+	tests := []struct {
+		name string
+		kind string
+		obj  map[string]interface{}
+		want string
+	}{
+		{
+			name: "deployment available",
+			kind: "Deployment",
+			obj: map[string]interface{}{
+				"metadata": map[string]interface{}{"generation": int64(2)},
+				"spec":     map[string]interface{}{"replicas": int64(3)},
+				"status":   map[string]interface{}{"observedGeneration": int64(2), "replicas": int64(3), "updatedReplicas": int64(3), "availableReplicas": int64(3)},
+			},
+			want: healthHealthy,
+		},
+		{
+			name: "deployment generation not observed",
+			kind: "Deployment",
+			obj: map[string]interface{}{
+				"metadata": map[string]interface{}{"generation": int64(3)},
+				"spec":     map[string]interface{}{"replicas": int64(3)},
+				"status":   map[string]interface{}{"observedGeneration": int64(2), "replicas": int64(3), "updatedReplicas": int64(3), "availableReplicas": int64(3)},
+			},
+			want: healthProgressing,
+		},
+		{
+			name: "deployment progress deadline exceeded",
+			kind: "Deployment",
+			obj: map[string]interface{}{
+				"spec": map[string]interface{}{"replicas": int64(3)},
+				"status": map[string]interface{}{
+					"replicas": int64(4), "updatedReplicas": int64(1), "availableReplicas": int64(3),
+					"conditions": []interface{}{map[string]interface{}{"type": "Progressing", "status": "False", "reason": "ProgressDeadlineExceeded"}},
+				},
+			},
+			want: healthDegraded,
+		},
+		{
+			name: "deployment paused",
+			kind: "Deployment",
+			obj:  map[string]interface{}{"spec": map[string]interface{}{"paused": true}},
+			want: healthSuspended,
+		},
+		{
+			name: "deployment paused by kruise-rollout during a canary step",
+			kind: "Deployment",
+			obj: map[string]interface{}{
+				"metadata": map[string]interface{}{"annotations": map[string]interface{}{rolloutControlAnnotation: `{"name":"web"}`}},
+				"spec":     map[string]interface{}{"replicas": int64(4), "paused": true},
+				"status":   map[string]interface{}{"replicas": int64(4), "updatedReplicas": int64(1), "availableReplicas": int64(4)},
+			},
+			want: healthProgressing,
+		},
+		{
+			name: "cloneset held at partition",
+			kind: "CloneSet",
+			obj: map[string]interface{}{
+				"spec":   map[string]interface{}{"replicas": int64(4), "updateStrategy": map[string]interface{}{"partition": "50%"}},
+				"status": map[string]interface{}{"replicas": int64(4), "updatedReplicas": int64(2), "availableReplicas": int64(4)},
+			},
+			want: healthHealthy,
+		},
+		{
+			name: "cloneset updating",
+			kind: "CloneSet",
+			obj: map[string]interface{}{
+				"spec":   map[string]interface{}{"replicas": int64(4)},
+				"status": map[string]interface{}{"replicas": int64(4), "updatedReplicas": int64(1), "availableReplicas": int64(4), "expectedUpdatedReplicas": int64(4)},
+			},
+			want: healthProgressing,
+		},
+		{
+			name: "statefulset unavailable replicas",
+			kind: "StatefulSet",
+			obj: map[string]interface{}{
+				"spec":   map[string]interface{}{"replicas": int64(3)},
+				"status": map[string]interface{}{"replicas": int64(3), "updatedReplicas": int64(3), "availableReplicas": int64(1)},
+			},
+			want: healthDegraded,
+		},
+		{
+			name: "daemonset misscheduled",
+			kind: "DaemonSet",
+			obj: map[string]interface{}{"status": map[string]interface{}{
+				"desiredNumberScheduled": int64(2), "updatedNumberScheduled": int64(2), "numberAvailable": int64(2), "numberMisscheduled": int64(1),
+			}},
+			want: healthDegraded,
+		},
+		{
+			name: "broadcastjob complete",
+			kind: "BroadcastJob",
+			obj: map[string]interface{}{"status": map[string]interface{}{
+				"desired": int64(3), "succeeded": int64(3),
+				"conditions": []interface{}{map[string]interface{}{"type": "Complete", "status": "True"}},
+			}},
+			want: healthHealthy,
+		},
+		{
+			name: "broadcastjob running",
+			kind: "BroadcastJob",
+			obj:  map[string]interface{}{"status": map[string]interface{}{"desired": int64(3), "active": int64(2), "succeeded": int64(1)}},
+			want: healthProgressing,
+		},
+		{
+			name: "advancedcronjob paused",
+			kind: "AdvancedCronJob",
+			obj:  map[string]interface{}{"spec": map[string]interface{}{"paused": true}},
+			want: healthSuspended,
+		},
+		{
+			name: "custom kind without status",
+			kind: "Rollout",
+			obj:  map[string]interface{}{"spec": map[string]interface{}{}},
+			want: healthUnknown,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			health := evaluateWorkloadHealth(tt.kind, tt.obj)
+			if health.Status != tt.want {
+				t.Errorf("health = %+v, want %s", health, tt.want)
+			}
+			if health.Reason == "" {
+				t.Error("health has no reason")
+			}
+		})
+	}
+}
+
+func TestPartitionedReplicas(t *testing.T) {
+	obj := map[string]interface{}{"spec": map[string]interface{}{"partition": int64(2)}}
+	if got := partitionedReplicas(obj, 5, "spec", "partition"); got != 3 {
+		t.Errorf("partition 2 of 5 = %d, want 3", got)
+	}
+	obj["spec"] = map[string]interface{}{"partition": "30%"}
+	if got := partitionedReplicas(obj, 5, "spec", "partition"); got != 3 {
+		t.Errorf("partition 30%% of 5 = %d, want 3", got)
+	}
+	if got := partitionedReplicas(obj, 5, "spec", "missing"); got != 5 {
+		t.Errorf("no partition = %d, want 5", got)
+	}
+}
@@ -0,0 +1,289 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/openkruise/kruise-dashboard/extensions-backend/pkg/logger"
+	"github.com/openkruise/kruise-dashboard/extensions-backend/pkg/response"
+	"go.uber.org/zap"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	errorCodeHPAControlsReplicas = "HPA_CONTROLS_REPLICAS"
+	errorCodeHPANotFound         = "HPA_NOT_FOUND"
+	errorCodeHPALookupFailed     = "HPA_LOOKUP_FAILED"
+)
+
+// HPAMetric is one metric of a HorizontalPodAutoscaler with its target and current value.
+type HPAMetric struct {
+	Type    string `json:"type" openapi:"enum=Resource|ContainerResource|Pods|Object|External"`
+	Name    string `json:"name"`
+	Target  string `json:"target"`
+	Current string `json:"current,omitempty"`
+}
+
+// HPASummary describes the HorizontalPodAutoscaler targeting a workload. Active is false when the
+// HPA reports ScalingActive=False and therefore leaves the replica count alone.
+type HPASummary struct {
+	Name            string      `json:"name"`
+	MinReplicas     int32       `json:"minReplicas"`
+	MaxReplicas     int32       `json:"maxReplicas"`
+	CurrentReplicas int32       `json:"currentReplicas"`
+	DesiredReplicas int32       `json:"desiredReplicas"`
+	Metrics         []HPAMetric `json:"metrics"`
+	Active          bool        `json:"active"`
+	Message         string      `json:"message,omitempty"`
+}
+
+type updateHPARequest struct {
+	MinReplicas *int32 `json:"minReplicas" openapi:"min=1"`
+	MaxReplicas *int32 `json:"maxReplicas" openapi:"min=1"`
+}
+
+// hpaTargets reports whether an HPA scales the workload. Versions are ignored: an HPA may name an
+// older version of the workload's API group.
+func hpaTargets(hpa *autoscalingv2.HorizontalPodAutoscaler, gvr schema.GroupVersionResource, kind, name string) bool {
+	ref := hpa.Spec.ScaleTargetRef
+	if ref.Kind != kind || ref.Name != name {
+		return false
+	}
+	group, err := schema.ParseGroupVersion(ref.APIVersion)
+	return err == nil && group.Group == gvr.Group
+}
+
+// findWorkloadHPA returns the HPA targeting a workload, or nil if there is none.
+func findWorkloadHPA(namespace string, info WorkloadTypeInfo, name string) (*autoscalingv2.HorizontalPodAutoscaler, error) {
+	list, err := GetK8sClient().AutoscalingV2().HorizontalPodAutoscalers(namespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for i := range list.Items {
+		if hpaTargets(&list.Items[i], info.GVR, info.Kind, name) {
+			return &list.Items[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func hpaMetricTarget(target autoscalingv2.MetricTarget) string {
+	switch {
+	case target.AverageUtilization != nil:
+		return fmt.Sprintf("%d%%", *target.AverageUtilization)
+	case target.AverageValue != nil:
+		return target.AverageValue.String()
+	case target.Value != nil:
+		return target.Value.String()
+	}
+	return ""
+}
+
+func hpaMetricValue(value autoscalingv2.MetricValueStatus) string {
+	switch {
+	case value.AverageUtilization != nil:
+		return fmt.Sprintf("%d%%", *value.AverageUtilization)
+	case value.AverageValue != nil:
+		return value.AverageValue.String()
+	case value.Value != nil:
+		return value.Value.String()
+	}
+	return ""
+}
+
+// hpaMetricSpec returns the name and target of a metric spec.
+func hpaMetricSpec(metric autoscalingv2.MetricSpec) (string, string) {
+	switch {
+	case metric.Resource != nil:
+		return string(metric.Resource.Name), hpaMetricTarget(metric.Resource.Target)
+	case metric.ContainerResource != nil:
+		return metric.ContainerResource.Container + "/" + string(metric.ContainerResource.Name), hpaMetricTarget(metric.ContainerResource.Target)
+	case metric.Pods != nil:
+		return metric.Pods.Metric.Name, hpaMetricTarget(metric.Pods.Target)
+	case metric.Object != nil:
+		return metric.Object.Metric.Name, hpaMetricTarget(metric.Object.Target)
+	case metric.External != nil:
+		return metric.External.Metric.Name, hpaMetricTarget(metric.External.Target)
+	}
+	return "", ""
+}
+
+// hpaMetricStatus returns the name and current value of a metric status.
+func hpaMetricStatus(metric autoscalingv2.MetricStatus) (string, string) {
+	switch {
+	case metric.Resource != nil:
+		return string(metric.Resource.Name), hpaMetricValue(metric.Resource.Current)
+	case metric.ContainerResource != nil:
+		return metric.ContainerResource.Container + "/" + string(metric.ContainerResource.Name), hpaMetricValue(metric.ContainerResource.Current)
+	case metric.Pods != nil:
+		return metric.Pods.Metric.Name, hpaMetricValue(metric.Pods.Current)
+	case metric.Object != nil:
+		return metric.Object.Metric.Name, hpaMetricValue(metric.Object.Current)
+	case metric.External != nil:
+		return metric.External.Metric.Name, hpaMetricValue(metric.External.Current)
+	}
+	return "", ""
+}
+
+func summarizeHPA(hpa *autoscalingv2.HorizontalPodAutoscaler) *HPASummary {
+	summary := &HPASummary{
+		Name:            hpa.Name,
+		MinReplicas:     1,
+		MaxReplicas:     hpa.Spec.MaxReplicas,
+		CurrentReplicas: hpa.Status.CurrentReplicas,
+		DesiredReplicas: hpa.Status.DesiredReplicas,
+		Metrics:         []HPAMetric{},
+		Active:          true,
+	}
+	if hpa.Spec.MinReplicas != nil {
+		summary.MinReplicas = *hpa.Spec.MinReplicas
+	}
+
+	current := map[string]string{}
+	for _, status := range hpa.Status.CurrentMetrics {
+		name, value := hpaMetricStatus(status)
+		current[string(status.Type)+"/"+name] = value
+	}
+	for _, metric := range hpa.Spec.Metrics {
+		name, target := hpaMetricSpec(metric)
+		summary.Metrics = append(summary.Metrics, HPAMetric{
+			Type:    string(metric.Type),
+			Name:    name,
+			Target:  target,
+			Current: current[string(metric.Type)+"/"+name],
+		})
+	}
+
+	for _, condition := range hpa.Status.Conditions {
+		if condition.Type == autoscalingv2.ScalingActive && condition.Status == corev1.ConditionFalse {
+			summary.Active = false
+			summary.Message = condition.Message
+		}
+	}
+	return summary
+}
+
+// workloadHPASummary returns the summary of the HPA targeting a workload, or nil. Errors are
+// logged: the HPA is supplementary to the workload detail.
+func workloadHPASummary(namespace string, info WorkloadTypeInfo, name string) *HPASummary {
+	hpa, err := findWorkloadHPA(namespace, info, name)
+	if err != nil {
+		logger.Log.Warn("Failed to look up HPA for workload",
+			zap.String("namespace", namespace),
+			zap.String("kind", info.Kind),
+			zap.String("name", name),
+			zap.Error(err),
+		)
+		return nil
+	}
+	if hpa == nil {
+		return nil
+	}
+	return summarizeHPA(hpa)
+}
+
+// GetWorkloadHPA returns the HPA targeting a workload; data is null if there is none.
+func GetWorkloadHPA(c *gin.Context) {
+	namespace := c.Param("namespace")
+	workloadType := c.Param("type")
+	name := c.Param("name")
+
+	info, err := ResolveWorkloadType(workloadType)
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+	hpa, err := findWorkloadHPA(namespace, info, name)
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+	if hpa == nil {
+		response.Success(c, nil)
+		return
+	}
+	response.Success(c, summarizeHPA(hpa))
+}
+
+// UpdateWorkloadHPA changes the replica bounds of the HPA targeting a workload. This is how the
+// replica count of an autoscaled workload is adjusted.
+func UpdateWorkloadHPA(c *gin.Context) {
+	namespace := c.Param("namespace")
+	workloadType := c.Param("type")
+	name := c.Param("name")
+
+	var req updateHPARequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request payload")
+		return
+	}
+	if req.MinReplicas == nil && req.MaxReplicas == nil {
+		response.BadRequest(c, "minReplicas or maxReplicas is required")
+		return
+	}
+
+	info, err := ResolveWorkloadType(workloadType)
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+	hpa, err := findWorkloadHPA(namespace, info, name)
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+	if hpa == nil {
+		response.Error(c, http.StatusNotFound, fmt.Sprintf("no HorizontalPodAutoscaler targets %s %s", workloadType, name), nil, errorCodeHPANotFound)
+		return
+	}
+
+	minReplicas, maxReplicas := int32(1), hpa.Spec.MaxReplicas
+	if hpa.Spec.MinReplicas != nil {
+		minReplicas = *hpa.Spec.MinReplicas
+	}
+	spec := map[string]interface{}{}
+	if req.MinReplicas != nil {
+		minReplicas = *req.MinReplicas
+		spec["minReplicas"] = minReplicas
+	}
+	if req.MaxReplicas != nil {
+		maxReplicas = *req.MaxReplicas
+		spec["maxReplicas"] = maxReplicas
+	}
+	if minReplicas > maxReplicas {
+		response.BadRequest(c, fmt.Sprintf("minReplicas %d exceeds maxReplicas %d", minReplicas, maxReplicas))
+		return
+	}
+
+	patch, _ := json.Marshal(map[string]interface{}{"spec": spec})
+	updated, err := GetK8sClient().AutoscalingV2().HorizontalPodAutoscalers(namespace).Patch(context.TODO(), hpa.Name, types.MergePatchType, patch, metav1.PatchOptions{})
+	if err != nil {
+		if apierrors.IsInvalid(err) {
+			response.BadRequest(c, err.Error())
+			return
+		}
+		logger.Log.Error("Failed to update HPA",
+			zap.String("namespace", namespace),
+			zap.String("hpa", hpa.Name),
+			zap.Error(err),
+		)
+		response.InternalError(c, err)
+		return
+	}
+
+	logger.Log.Info("Successfully updated HPA",
+		zap.String("namespace", namespace),
+		zap.String("hpa", hpa.Name),
+		zap.Int32("minReplicas", minReplicas),
+		zap.Int32("maxReplicas", maxReplicas),
+	)
+	response.Success(c, summarizeHPA(updated))
+}
@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+func int32Ptr(v int32) *int32 {
+	return &v
+}
+
+func TestHPATargets(t *testing.T) {
+	hpa := &autoscalingv2.HorizontalPodAutoscaler{Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+		ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{APIVersion: "apps.kruise.io/v1beta1", Kind: "CloneSet", Name: "web"},
+	}}
+	cloneSet := workloadTypeRegistry["cloneset"]
+	if !hpaTargets(hpa, cloneSet.GVR, cloneSet.Kind, "web") {
+		t.Error("HPA naming another version of the group should target the CloneSet")
+	}
+	if hpaTargets(hpa, cloneSet.GVR, cloneSet.Kind, "api") {
+		t.Error("HPA should not target another CloneSet")
+	}
+	deployment := workloadTypeRegistry["deployment"]
+	hpa.Spec.ScaleTargetRef.Kind = "Deployment"
+	if hpaTargets(hpa, deployment.GVR, deployment.Kind, "web") {
+		t.Error("HPA in apps.kruise.io should not target an apps Deployment")
+	}
+}
+
+func TestSummarizeHPA(t *testing.T) {
+	utilization := int32(80)
+	currentUtilization := int32(65)
+	hpa := &autoscalingv2.HorizontalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "web"},
+		Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+			MinReplicas: int32Ptr(2),
+			MaxReplicas: 10,
+			Metrics: []autoscalingv2.MetricSpec{
+				{Type: autoscalingv2.ResourceMetricSourceType, Resource: &autoscalingv2.ResourceMetricSource{
+					Name:   corev1.ResourceCPU,
+					Target: autoscalingv2.MetricTarget{Type: autoscalingv2.UtilizationMetricType, AverageUtilization: &utilization},
+				}},
+				{Type: autoscalingv2.PodsMetricSourceType, Pods: &autoscalingv2.PodsMetricSource{
+					Metric: autoscalingv2.MetricIdentifier{Name: "requests_per_second"},
+					Target: autoscalingv2.MetricTarget{Type: autoscalingv2.AverageValueMetricType, AverageValue: resource.NewQuantity(100, resource.DecimalSI)},
+				}},
+			},
+		},
+		Status: autoscalingv2.HorizontalPodAutoscalerStatus{
+			CurrentReplicas: 3,
+			DesiredReplicas: 4,
+			CurrentMetrics: []autoscalingv2.MetricStatus{
+				{Type: autoscalingv2.ResourceMetricSourceType, Resource: &autoscalingv2.ResourceMetricStatus{
+					Name:    corev1.ResourceCPU,
+					Current: autoscalingv2.MetricValueStatus{AverageUtilization: &currentUtilization},
+				}},
+			},
+		},
+	}
+
+	summary := summarizeHPA(hpa)
+	if summary.MinReplicas != 2 || summary.MaxReplicas != 10 || summary.CurrentReplicas != 3 || summary.DesiredReplicas != 4 || !summary.Active {
+		t.Errorf("summary = %+v", summary)
+	}
+	if len(summary.Metrics) != 2 {
+		t.Fatalf("metrics = %+v", summary.Metrics)
+	}
+	if m := summary.Metrics[0]; m.Name != "cpu" || m.Target != "80%" || m.Current != "65%" {
+		t.Errorf("cpu metric = %+v", m)
+	}
+	if m := summary.Metrics[1]; m.Name != "requests_per_second" || m.Target != "100" || m.Current != "" {
+		t.Errorf("pods metric = %+v", m)
+	}
+
+	hpa.Spec.MinReplicas = nil
+	hpa.Status.Conditions = []autoscalingv2.HorizontalPodAutoscalerCondition{
+		{Type: autoscalingv2.ScalingActive, Status: corev1.ConditionFalse, Message: "the HPA was unable to compute the replica count"},
+	}
+	summary = summarizeHPA(hpa)
+	if summary.Active || summary.MinReplicas != 1 || summary.Message == "" {
+		t.Errorf("inactive summary = %+v", summary)
+	}
+}
+
+func TestScaleWorkloadHPALookupFailure(t *testing.T) {
+	// The API server refuses to list HPAs.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(`{"kind":"Status","apiVersion":"v1","status":"Failure","reason":"Forbidden","code":403}`))
+	}))
+	defer server.Close()
+
+	previousClient, previousDynamic := k8sClient, dynamicClient
+	defer func() { k8sClient, dynamicClient = previousClient, previousDynamic }()
+	k8sClient = kubernetes.NewForConfigOrDie(&rest.Config{Host: server.URL})
+	dynamicClient = dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata":   map[string]interface{}{"name": "web", "namespace": "default"},
+		"spec":       map[string]interface{}{"replicas": int64(2)},
+	}})
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/api/v1/workload/:namespace/:type/:name/scale", ScaleWorkload)
+	scale := func(query string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/workload/default/deployment/web/scale?replicas=5"+query, nil))
+		return w
+	}
+	replicas := func() int64 {
+		obj, _ := dynamicClient.Resource(deploymentGVR).Namespace("default").Get(context.Background(), "web", metav1.GetOptions{})
+		value, _, _ := unstructured.NestedInt64(obj.Object, "spec", "replicas")
+		return value
+	}
+
+	w := scale("")
+	if w.Code != http.StatusServiceUnavailable || replicas() != 2 {
+		t.Fatalf("status = %d, replicas = %d; want 503 and the workload untouched", w.Code, replicas())
+	}
+	var failure struct {
+		Code string `json:"code"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &failure); err != nil || failure.Code != errorCodeHPALookupFailed {
+		t.Errorf("code = %q, want %s", failure.Code, errorCodeHPALookupFailed)
+	}
+
+	w = scale("&force=true")
+	var resp struct {
+		Data struct {
+			Warning struct {
+				Code string `json:"code"`
+			} `json:"warning"`
+		} `json:"data"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	if w.Code != http.StatusOK || resp.Data.Warning.Code != errorCodeHPALookupFailed || replicas() != 5 {
+		t.Errorf("forced scale = %d %s, replicas = %d; want 200 with a %s warning", w.Code, w.Body.String(), replicas(), errorCodeHPALookupFailed)
+	}
+}
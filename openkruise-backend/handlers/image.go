@@ -0,0 +1,613 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/openkruise/kruise-dashboard/extensions-backend/pkg/logger"
+	"github.com/openkruise/kruise-dashboard/extensions-backend/pkg/registry"
+	"github.com/openkruise/kruise-dashboard/extensions-backend/pkg/response"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	imagePullPollInterval         = 3 * time.Second
+	defaultPrePullTimeoutSeconds  = 120
+	maxPrePullTimeoutSeconds      = 600
+	defaultImagePullJobTTLSeconds = int64(3600)
+
+	prePullForAnnotation = "kruise-dashboard.io/pre-pull-for"
+
+	// imageDigestTimeout bounds resolving the digest of one image.
+	imageDigestTimeout = 10 * time.Second
+
+	errorCodeImagePrePullFailed    = "IMAGE_PRE_PULL_FAILED"
+	errorCodeImagePrePullTimeout   = "IMAGE_PRE_PULL_TIMEOUT"
+	errorCodeImageDigestUnresolved = "IMAGE_DIGEST_UNRESOLVED"
+)
+
+// errImagePrePullTimedOut is returned when an ImagePullJob does not complete within the timeout.
+var errImagePrePullTimedOut = errors.New("image pre-pull timed out")
+
+// imageRegistryClient is shared by digest lookups; it refuses loopback and link-local addresses.
+var imageRegistryClient = registry.NewClient(imageDigestTimeout)
+
+// registryUsePullSecrets lets digest lookups authenticate with a workload's imagePullSecrets. It
+// needs get on secrets, so it is off unless REGISTRY_USE_PULL_SECRETS is true.
+var registryUsePullSecrets, _ = strconv.ParseBool(os.Getenv("REGISTRY_USE_PULL_SECRETS"))
+
+// newImageResolver returns a digest resolver that also trusts the token realm hosts listed in
+// REGISTRY_TOKEN_REALM_HOSTS, comma-separated.
+func newImageResolver() *registry.Resolver {
+	resolver := &registry.Resolver{Client: imageRegistryClient}
+	for _, host := range strings.Split(os.Getenv("REGISTRY_TOKEN_REALM_HOSTS"), ",") {
+		if host = strings.TrimSpace(host); host != "" {
+			resolver.TokenRealmHosts = append(resolver.TokenRealmHosts, host)
+		}
+	}
+	return resolver
+}
+
+// workloadKeychain reads the registry credentials of the imagePullSecrets in a workload's pod
+// template, or returns nil unless registryUsePullSecrets is set. As with the kubelet, the first
+// secret listing a registry wins. Secrets that cannot be read are skipped, leaving their registries
+// anonymous.
+func workloadKeychain(ctx context.Context, namespace string, workload *unstructured.Unstructured) registry.Keychain {
+	if !registryUsePullSecrets {
+		return nil
+	}
+	keychain := registry.Keychain{}
+	refs, _, _ := unstructured.NestedSlice(workload.Object, "spec", "template", "spec", "imagePullSecrets")
+	for _, raw := range refs {
+		ref, _ := raw.(map[string]interface{})
+		name, _ := ref["name"].(string)
+		if name == "" {
+			continue
+		}
+		secret, err := GetK8sClient().CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			logger.Log.Warn("Failed to read image pull secret",
+				zap.String("namespace", namespace),
+				zap.String("secret", name),
+				zap.Error(err),
+			)
+			continue
+		}
+		data := secret.Data[corev1.DockerConfigJsonKey]
+		if len(data) == 0 {
+			data = secret.Data[corev1.DockerConfigKey]
+		}
+		if len(data) == 0 {
+			continue
+		}
+		credentials, err := registry.ParseDockerConfig(data)
+		if err != nil {
+			logger.Log.Warn("Failed to parse image pull secret",
+				zap.String("namespace", namespace),
+				zap.String("secret", name),
+				zap.Error(err),
+			)
+			continue
+		}
+		for host, creds := range credentials {
+			if _, ok := keychain[host]; !ok {
+				keychain[host] = creds
+			}
+		}
+	}
+	return keychain
+}
+
+var imagePullJobGVR = schema.GroupVersionResource{
+	Group:    "apps.kruise.io",
+	Version:  "v1alpha1",
+	Resource: "imagepulljobs",
+}
+
+var nodeImageGVR = schema.GroupVersionResource{
+	Group:    "apps.kruise.io",
+	Version:  "v1alpha1",
+	Resource: "nodeimages",
+}
+
+type createImagePullJobRequest struct {
+	Name                    string            `json:"name"`
+	Image                   string            `json:"image" openapi:"required"`
+	NodeNames               []string          `json:"nodeNames"`
+	NodeSelector            map[string]string `json:"nodeSelector"`
+	PodSelector             map[string]string `json:"podSelector"`
+	Parallelism             int64             `json:"parallelism" openapi:"min=0"`
+	TimeoutSeconds          int64             `json:"timeoutSeconds" openapi:"min=0"`
+	BackoffLimit            int64             `json:"backoffLimit" openapi:"min=0"`
+	ActiveDeadlineSeconds   int64             `json:"activeDeadlineSeconds" openapi:"min=0"`
+	TTLSecondsAfterFinished int64             `json:"ttlSecondsAfterFinished" openapi:"min=0"`
+	PullSecrets             []string          `json:"pullSecrets"`
+}
+
+// splitImageTag splits an image reference into repository and tag. Registry ports are not
+// mistaken for tags, and a missing tag defaults to "latest".
+func splitImageTag(image string) (string, string) {
+	if at := strings.Index(image, "@"); at >= 0 {
+		image = image[:at]
+	}
+	slash := strings.LastIndex(image, "/")
+	colon := strings.LastIndex(image, ":")
+	if colon > slash {
+		return image[:colon], image[colon+1:]
+	}
+	return image, "latest"
+}
+
+func stringsToInterfaces(values []string) []interface{} {
+	result := make([]interface{}, 0, len(values))
+	for _, v := range values {
+		result = append(result, v)
+	}
+	return result
+}
+
+func stringMapToInterfaces(values map[string]string) map[string]interface{} {
+	result := make(map[string]interface{}, len(values))
+	for k, v := range values {
+		result[k] = v
+	}
+	return result
+}
+
+func buildImagePullJob(namespace string, req createImagePullJobRequest) *unstructured.Unstructured {
+	pullPolicy := map[string]interface{}{}
+	if req.TimeoutSeconds > 0 {
+		pullPolicy["timeoutSeconds"] = req.TimeoutSeconds
+	}
+	if req.BackoffLimit > 0 {
+		pullPolicy["backoffLimit"] = req.BackoffLimit
+	}
+
+	ttl := req.TTLSecondsAfterFinished
+	if ttl <= 0 {
+		ttl = defaultImagePullJobTTLSeconds
+	}
+	completionPolicy := map[string]interface{}{
+		"type":                    "Always",
+		"ttlSecondsAfterFinished": ttl,
+	}
+	if req.ActiveDeadlineSeconds > 0 {
+		completionPolicy["activeDeadlineSeconds"] = req.ActiveDeadlineSeconds
+	}
+
+	spec := map[string]interface{}{
+		"image":            req.Image,
+		"pullPolicy":       pullPolicy,
+		"completionPolicy": completionPolicy,
+	}
+	if req.Parallelism > 0 {
+		spec["parallelism"] = req.Parallelism
+	}
+	if len(req.PullSecrets) > 0 {
+		spec["pullSecrets"] = stringsToInterfaces(req.PullSecrets)
+	}
+	switch {
+	case len(req.NodeNames) > 0:
+		spec["selector"] = map[string]interface{}{"names": stringsToInterfaces(req.NodeNames)}
+	case len(req.NodeSelector) > 0:
+		spec["selector"] = map[string]interface{}{"matchLabels": stringMapToInterfaces(req.NodeSelector)}
+	}
+	if len(req.PodSelector) > 0 {
+		spec["podSelector"] = map[string]interface{}{"matchLabels": stringMapToInterfaces(req.PodSelector)}
+	}
+
+	metadata := map[string]interface{}{"namespace": namespace}
+	if req.Name != "" {
+		metadata["name"] = req.Name
+	} else {
+		repo, _ := splitImageTag(req.Image)
+		base := strings.ReplaceAll(repo[strings.LastIndex(repo, "/")+1:], "_", "-")
+		if len(base) > 40 {
+			base = base[:40]
+		}
+		metadata["generateName"] = "pull-" + base + "-"
+	}
+
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": imagePullJobGVR.GroupVersion().String(),
+		"kind":       "ImagePullJob",
+		"metadata":   metadata,
+		"spec":       spec,
+	}}
+}
+
+// CreateImagePullJob creates an ImagePullJob to pre-pull an image onto nodes.
+func CreateImagePullJob(c *gin.Context) {
+	namespace := c.Param("namespace")
+
+	var req createImagePullJobRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request payload")
+		return
+	}
+	if strings.TrimSpace(req.Image) == "" {
+		response.BadRequest(c, "image is required")
+		return
+	}
+	if len(req.NodeNames) > 0 && len(req.NodeSelector) > 0 {
+		response.BadRequest(c, "nodeNames and nodeSelector are mutually exclusive")
+		return
+	}
+	if req.Parallelism < 0 || req.TimeoutSeconds < 0 || req.BackoffLimit < 0 {
+		response.BadRequest(c, "parallelism, timeoutSeconds and backoffLimit must be non-negative")
+		return
+	}
+
+	job, err := GetDynamicClient().Resource(imagePullJobGVR).Namespace(namespace).Create(context.TODO(), buildImagePullJob(namespace, req), metav1.CreateOptions{})
+	if err != nil {
+		logger.Log.Error("Failed to create image pull job",
+			zap.String("namespace", namespace),
+			zap.String("image", req.Image),
+			zap.Error(err),
+		)
+		response.InternalError(c, err)
+		return
+	}
+
+	logger.Log.Info("Image pull job created",
+		zap.String("namespace", namespace),
+		zap.String("name", job.GetName()),
+		zap.String("image", req.Image),
+	)
+	response.Success(c, summarizeImagePullJob(job.Object))
+}
+
+func summarizeImagePullJob(job map[string]interface{}) map[string]interface{} {
+	name, _, _ := unstructured.NestedString(job, "metadata", "name")
+	namespace, _, _ := unstructured.NestedString(job, "metadata", "namespace")
+	created, _, _ := unstructured.NestedString(job, "metadata", "creationTimestamp")
+	image, _, _ := unstructured.NestedString(job, "spec", "image")
+	desired, _, _ := unstructured.NestedInt64(job, "status", "desired")
+	active, _, _ := unstructured.NestedInt64(job, "status", "active")
+	succeeded, _, _ := unstructured.NestedInt64(job, "status", "succeeded")
+	failedNodes, _, _ := unstructured.NestedStringSlice(job, "status", "failedNodes")
+	startTime, _, _ := unstructured.NestedString(job, "status", "startTime")
+	completionTime, _, _ := unstructured.NestedString(job, "status", "completionTime")
+	message, _, _ := unstructured.NestedString(job, "status", "message")
+	if failedNodes == nil {
+		failedNodes = []string{}
+	}
+
+	return map[string]interface{}{
+		"name":              name,
+		"namespace":         namespace,
+		"creationTimestamp": created,
+		"image":             image,
+		"desired":           desired,
+		"active":            active,
+		"succeeded":         succeeded,
+		"failed":            len(failedNodes),
+		"failedNodes":       failedNodes,
+		"startTime":         startTime,
+		"completionTime":    completionTime,
+		"completed":         completionTime != "",
+		"message":           message,
+	}
+}
+
+// ListImagePullJobs lists ImagePullJobs in a namespace.
+func ListImagePullJobs(c *gin.Context) {
+	namespace := c.Param("namespace")
+
+	list, err := GetDynamicClient().Resource(imagePullJobGVR).Namespace(namespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		logger.Log.Error("Failed to list image pull jobs",
+			zap.String("namespace", namespace),
+			zap.Error(err),
+		)
+		response.InternalError(c, err)
+		return
+	}
+
+	jobs := make([]map[string]interface{}, 0, len(list.Items))
+	for i := range list.Items {
+		jobs = append(jobs, summarizeImagePullJob(list.Items[i].Object))
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i]["creationTimestamp"].(string) > jobs[j]["creationTimestamp"].(string)
+	})
+
+	response.Success(c, gin.H{
+		"imagePullJobs": jobs,
+		"total":         len(jobs),
+		"namespace":     namespace,
+	})
+}
+
+// nodeImagePullProgress finds the tag of a NodeImage that is owned by the given ImagePullJob
+// and returns its pull status on that node.
+func nodeImagePullProgress(nodeImage map[string]interface{}, repo, tag, jobUID string) (map[string]interface{}, bool) {
+	specTags, _, _ := unstructured.NestedSlice(nodeImage, "spec", "images", repo, "tags")
+	owned := false
+	for _, raw := range specTags {
+		specTag, _ := raw.(map[string]interface{})
+		if specTag == nil || specTag["tag"] != tag {
+			continue
+		}
+		owners, _, _ := unstructured.NestedSlice(specTag, "ownerReferences")
+		for _, ownerRaw := range owners {
+			owner, _ := ownerRaw.(map[string]interface{})
+			if owner != nil && owner["uid"] == jobUID {
+				owned = true
+			}
+		}
+	}
+	if !owned {
+		return nil, false
+	}
+
+	nodeName, _, _ := unstructured.NestedString(nodeImage, "metadata", "name")
+	progress := map[string]interface{}{
+		"node":     nodeName,
+		"phase":    "Waiting",
+		"progress": int64(0),
+	}
+	statusTags, _, _ := unstructured.NestedSlice(nodeImage, "status", "imageStatuses", repo, "tags")
+	for _, raw := range statusTags {
+		statusTag, _ := raw.(map[string]interface{})
+		if statusTag == nil || statusTag["tag"] != tag {
+			continue
+		}
+		for _, key := range []string{"phase", "progress", "message", "imageID", "startTime", "completionTime"} {
+			if v, ok := statusTag[key]; ok {
+				progress[key] = v
+			}
+		}
+	}
+	return progress, true
+}
+
+// GetImagePullJob returns an ImagePullJob with its per-node pull progress.
+func GetImagePullJob(c *gin.Context) {
+	namespace := c.Param("namespace")
+	name := c.Param("name")
+
+	job, err := GetDynamicClient().Resource(imagePullJobGVR).Namespace(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			response.NotFound(c, "imagepulljob")
+			return
+		}
+		logger.Log.Error("Failed to get image pull job",
+			zap.String("namespace", namespace),
+			zap.String("name", name),
+			zap.Error(err),
+		)
+		response.InternalError(c, err)
+		return
+	}
+
+	nodeImages, err := GetDynamicClient().Resource(nodeImageGVR).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		logger.Log.Error("Failed to list node images",
+			zap.String("namespace", namespace),
+			zap.String("name", name),
+			zap.Error(err),
+		)
+		response.InternalError(c, err)
+		return
+	}
+
+	image, _, _ := unstructured.NestedString(job.Object, "spec", "image")
+	repo, tag := splitImageTag(image)
+	nodes := make([]map[string]interface{}, 0)
+	for i := range nodeImages.Items {
+		if progress, ok := nodeImagePullProgress(nodeImages.Items[i].Object, repo, tag, string(job.GetUID())); ok {
+			nodes = append(nodes, progress)
+		}
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i]["node"].(string) < nodes[j]["node"].(string)
+	})
+
+	result := summarizeImagePullJob(job.Object)
+	result["nodes"] = nodes
+	response.Success(c, result)
+}
+
+// summarizeNodeImage flattens a NodeImage's image status map into a sorted image list.
+func summarizeNodeImage(nodeImage map[string]interface{}) map[string]interface{} {
+	nodeName, _, _ := unstructured.NestedString(nodeImage, "metadata", "name")
+	imageStatuses, _, _ := unstructured.NestedMap(nodeImage, "status", "imageStatuses")
+
+	images := make([]map[string]interface{}, 0)
+	for repo, raw := range imageStatuses {
+		status, _ := raw.(map[string]interface{})
+		tags, _, _ := unstructured.NestedSlice(status, "tags")
+		for _, tagRaw := range tags {
+			tag, _ := tagRaw.(map[string]interface{})
+			if tag == nil {
+				continue
+			}
+			tagName, _ := tag["tag"].(string)
+			images = append(images, map[string]interface{}{
+				"image":          repo + ":" + tagName,
+				"phase":          tag["phase"],
+				"imageID":        tag["imageID"],
+				"completionTime": tag["completionTime"],
+			})
+		}
+	}
+	sort.Slice(images, func(i, j int) bool {
+		return images[i]["image"].(string) < images[j]["image"].(string)
+	})
+
+	desired, _, _ := unstructured.NestedInt64(nodeImage, "status", "desired")
+	succeeded, _, _ := unstructured.NestedInt64(nodeImage, "status", "succeeded")
+	failed, _, _ := unstructured.NestedInt64(nodeImage, "status", "failed")
+	pulling, _, _ := unstructured.NestedInt64(nodeImage, "status", "pulling")
+
+	return map[string]interface{}{
+		"node":      nodeName,
+		"desired":   desired,
+		"succeeded": succeeded,
+		"failed":    failed,
+		"pulling":   pulling,
+		"images":    images,
+	}
+}
+
+// ListNodeImages lists cached images per node from the cluster-scoped NodeImage objects.
+// The optional image query parameter keeps only images containing the given substring.
+func ListNodeImages(c *gin.Context) {
+	imageFilter := c.Query("image")
+
+	list, err := GetDynamicClient().Resource(nodeImageGVR).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		logger.Log.Error("Failed to list node images", zap.Error(err))
+		response.InternalError(c, err)
+		return
+	}
+
+	nodes := make([]map[string]interface{}, 0, len(list.Items))
+	for i := range list.Items {
+		summary := summarizeNodeImage(list.Items[i].Object)
+		if imageFilter != "" {
+			images := summary["images"].([]map[string]interface{})
+			filtered := make([]map[string]interface{}, 0, len(images))
+			for _, img := range images {
+				if strings.Contains(img["image"].(string), imageFilter) {
+					filtered = append(filtered, img)
+				}
+			}
+			if len(filtered) == 0 {
+				continue
+			}
+			summary["images"] = filtered
+		}
+		nodes = append(nodes, summary)
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i]["node"].(string) < nodes[j]["node"].(string)
+	})
+
+	response.Success(c, gin.H{
+		"nodes": nodes,
+		"total": len(nodes),
+	})
+}
+
+// imagePullJobOutcome tells whether a completed ImagePullJob pulled the image on every node it
+// targeted.
+func imagePullJobOutcome(job map[string]interface{}) error {
+	name, _, _ := unstructured.NestedString(job, "metadata", "name")
+	image, _, _ := unstructured.NestedString(job, "spec", "image")
+	desired, _, _ := unstructured.NestedInt64(job, "status", "desired")
+	succeeded, _, _ := unstructured.NestedInt64(job, "status", "succeeded")
+	failedNodes, _, _ := unstructured.NestedStringSlice(job, "status", "failedNodes")
+	if len(failedNodes) > 0 {
+		return fmt.Errorf("ImagePullJob %s failed to pull %s on nodes %s", name, image, strings.Join(failedNodes, ", "))
+	}
+	if succeeded < desired {
+		return fmt.Errorf("ImagePullJob %s pulled %s on %d of %d nodes", name, image, succeeded, desired)
+	}
+	return nil
+}
+
+// prePullWorkloadImage pre-pulls an image onto the nodes running a workload's pods and waits for
+// the ImagePullJob to finish. It returns the job summary together with an error when the job
+// cannot be created, does not complete before the timeout or fails on any node.
+func prePullWorkloadImage(ctx context.Context, namespace string, workload *unstructured.Unstructured, image string, timeout time.Duration) (map[string]interface{}, error) {
+	job := buildImagePullJob(namespace, createImagePullJobRequest{
+		Image:                 image,
+		ActiveDeadlineSeconds: int64(timeout.Seconds()),
+	})
+	if selector, found, _ := unstructured.NestedMap(workload.Object, "spec", "selector"); found {
+		_ = unstructured.SetNestedMap(job.Object, runtime.DeepCopyJSON(selector), "spec", "podSelector")
+	}
+	_ = unstructured.SetNestedField(job.Object, fmt.Sprintf("%s/%s", workload.GetKind(), workload.GetName()), "metadata", "annotations", prePullForAnnotation)
+
+	created, err := GetDynamicClient().Resource(imagePullJobGVR).Namespace(namespace).Create(ctx, job, metav1.CreateOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	ticker := time.NewTicker(imagePullPollInterval)
+	defer ticker.Stop()
+
+	latest := created
+	for {
+		if completionTime, _, _ := unstructured.NestedString(latest.Object, "status", "completionTime"); completionTime != "" {
+			return summarizeImagePullJob(latest.Object), imagePullJobOutcome(latest.Object)
+		}
+		select {
+		case <-ctx.Done():
+			summary := summarizeImagePullJob(latest.Object)
+			summary["timedOut"] = true
+			return summary, fmt.Errorf("%w: ImagePullJob %s for %s did not complete within %s", errImagePrePullTimedOut, created.GetName(), image, timeout)
+		case <-ticker.C:
+		}
+		current, err := GetDynamicClient().Resource(imagePullJobGVR).Namespace(namespace).Get(ctx, created.GetName(), metav1.GetOptions{})
+		if err != nil {
+			if ctx.Err() != nil {
+				continue
+			}
+			return summarizeImagePullJob(latest.Object), err
+		}
+		latest = current
+	}
+}
+
+// prePullWorkloadImages pre-pulls the distinct images concurrently, one ImagePullJob each, since an
+// ImagePullJob carries a single image. All pulls share the timeout, in seconds; the first failure
+// stops waiting for the others and is returned with the summaries gathered so far.
+func prePullWorkloadImages(namespace string, workload *unstructured.Unstructured, images []string, timeoutSeconds int) ([]map[string]interface{}, error) {
+	if timeoutSeconds <= 0 {
+		timeoutSeconds = defaultPrePullTimeoutSeconds
+	}
+	if timeoutSeconds > maxPrePullTimeoutSeconds {
+		timeoutSeconds = maxPrePullTimeoutSeconds
+	}
+	timeout := time.Duration(timeoutSeconds) * time.Second
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	summaries := make([]map[string]interface{}, len(images))
+	var once sync.Once
+	var firstErr error
+	var wg sync.WaitGroup
+	for i, image := range images {
+		wg.Add(1)
+		go func(i int, image string) {
+			defer wg.Done()
+			summary, err := prePullWorkloadImage(ctx, namespace, workload, image, timeout)
+			summaries[i] = summary
+			if err != nil {
+				once.Do(func() {
+					firstErr = err
+					cancel()
+				})
+			}
+		}(i, image)
+	}
+	wg.Wait()
+
+	results := make([]map[string]interface{}, 0, len(summaries))
+	for _, summary := range summaries {
+		if summary != nil {
+			results = append(results, summary)
+		}
+	}
+	return results, firstErr
+}
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestSplitImageTag(t *testing.T) {
+	tests := []struct {
+		image    string
+		wantRepo string
+		wantTag  string
+	}{
+		{image: "nginx:1.27.0", wantRepo: "nginx", wantTag: "1.27.0"},
+		{image: "nginx", wantRepo: "nginx", wantTag: "latest"},
+		{image: "registry.local:5000/team/app", wantRepo: "registry.local:5000/team/app", wantTag: "latest"},
+		{image: "registry.local:5000/team/app:v2", wantRepo: "registry.local:5000/team/app", wantTag: "v2"},
+		{image: "app:v1@sha256:abc", wantRepo: "app", wantTag: "v1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.image, func(t *testing.T) {
+			repo, tag := splitImageTag(tt.image)
+			if repo != tt.wantRepo || tag != tt.wantTag {
+				t.Errorf("splitImageTag(%q) = (%q, %q), want (%q, %q)", tt.image, repo, tag, tt.wantRepo, tt.wantTag)
+			}
+		})
+	}
+}
+
+func TestNodeImagePullProgress(t *testing.T) {
+	nodeImage := map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "node-1"},
+		"spec": map[string]interface{}{
+			"images": map[string]interface{}{
+				"nginx": map[string]interface{}{
+					"tags": []interface{}{
+						map[string]interface{}{
+							"tag":             "1.27",
+							"ownerReferences": []interface{}{map[string]interface{}{"uid": "job-uid"}},
+						},
+					},
+				},
+			},
+		},
+		"status": map[string]interface{}{
+			"imageStatuses": map[string]interface{}{
+				"nginx": map[string]interface{}{
+					"tags": []interface{}{
+						map[string]interface{}{"tag": "1.27", "phase": "Pulling", "progress": int64(40)},
+					},
+				},
+			},
+		},
+	}
+
+	progress, ok := nodeImagePullProgress(nodeImage, "nginx", "1.27", "job-uid")
+	if !ok {
+		t.Fatal("expected node image to be owned by the job")
+	}
+	if progress["phase"] != "Pulling" || progress["progress"] != int64(40) {
+		t.Errorf("progress = %v, want Pulling at 40", progress)
+	}
+
+	if _, ok := nodeImagePullProgress(nodeImage, "nginx", "1.27", "other-uid"); ok {
+		t.Error("expected no progress for a different job")
+	}
+}
+
+func TestPrePullWorkloadImages(t *testing.T) {
+	previous := dynamicClient
+	defer func() { dynamicClient = previous }()
+
+	// Jobs complete on creation: "missing:1" fails on one node and "broken:1" cannot be created.
+	fake := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())
+	fake.PrependReactor("create", "imagepulljobs", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		job := action.(k8stesting.CreateAction).GetObject().(*unstructured.Unstructured).DeepCopy()
+		image, _, _ := unstructured.NestedString(job.Object, "spec", "image")
+		if image == "broken:1" {
+			return true, nil, errors.New("admission denied")
+		}
+		job.SetName("pull-" + strings.TrimSuffix(image, ":1"))
+		status := map[string]interface{}{"desired": int64(2), "succeeded": int64(2), "completionTime": "2024-01-01T00:00:00Z"}
+		if image == "missing:1" {
+			status["succeeded"] = int64(1)
+			status["failedNodes"] = []interface{}{"node-b"}
+		}
+		job.Object["status"] = status
+		return true, job, nil
+	})
+	dynamicClient = fake
+
+	workload := &unstructured.Unstructured{Object: map[string]interface{}{
+		"kind":     "Deployment",
+		"metadata": map[string]interface{}{"name": "web", "namespace": "default"},
+		"spec":     map[string]interface{}{"selector": map[string]interface{}{"matchLabels": map[string]interface{}{"app": "web"}}},
+	}}
+
+	summaries, err := prePullWorkloadImages("default", workload, []string{"nginx:1", "envoy:1"}, 5)
+	if err != nil || len(summaries) != 2 || summaries[1]["name"] != "pull-envoy" {
+		t.Errorf("prePullWorkloadImages() = %v, %v, want two completed jobs", summaries, err)
+	}
+
+	_, err = prePullWorkloadImages("default", workload, []string{"nginx:1", "missing:1"}, 5)
+	if err == nil || !strings.Contains(err.Error(), "node-b") || errors.Is(err, errImagePrePullTimedOut) {
+		t.Errorf("error = %v, want the failed node reported", err)
+	}
+
+	if _, err := prePullWorkloadImages("default", workload, []string{"broken:1"}, 5); err == nil {
+		t.Error("a job that cannot be created should fail the pre-pull")
+	}
+}
@@ -0,0 +1,538 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/openkruise/kruise-dashboard/extensions-backend/pkg/logger"
+	"github.com/openkruise/kruise-dashboard/extensions-backend/pkg/response"
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	advancedCronJobKind = "AdvancedCronJob"
+	broadcastJobKind    = "BroadcastJob"
+
+	jobPhaseActive    = "Active"
+	jobPhaseSucceeded = "Succeeded"
+	jobPhaseFailed    = "Failed"
+	jobPhaseSuspended = "Suspended"
+
+	rerunOfAnnotation      = "kruise-dashboard.io/rerun-of"
+	instantiateAnnotation  = "cronjob.kubernetes.io/instantiate"
+	errorCodeJobTemplateNA = "JOB_TEMPLATE_NOT_FOUND"
+)
+
+var jobGVR = schema.GroupVersionResource{
+	Group:    "batch",
+	Version:  "v1",
+	Resource: "jobs",
+}
+
+// resolveJobWorkload resolves the workload type and checks it is of the expected kind.
+func resolveJobWorkload(c *gin.Context, expectedKind, operation string) (WorkloadTypeInfo, bool) {
+	workloadType := c.Param("type")
+	info, err := ResolveWorkloadType(workloadType)
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return info, false
+	}
+	if info.Kind != expectedKind {
+		response.BadRequest(c, fmt.Sprintf("%s does not support %s", workloadType, operation))
+		return info, false
+	}
+	return info, true
+}
+
+func setAdvancedCronJobPaused(c *gin.Context, paused bool) {
+	namespace := c.Param("namespace")
+	name := c.Param("name")
+	operation := "resume"
+	if paused {
+		operation = "suspend"
+	}
+
+	info, ok := resolveJobWorkload(c, advancedCronJobKind, operation)
+	if !ok {
+		return
+	}
+
+	patchBytes := []byte(fmt.Sprintf(`{"spec":{"paused":%t}}`, paused))
+	_, err := GetDynamicClient().Resource(info.GVR).Namespace(namespace).Patch(context.TODO(), name, types.MergePatchType, patchBytes, metav1.PatchOptions{})
+	if err != nil {
+		logger.Log.Error("Failed to "+operation+" advanced cron job",
+			zap.String("namespace", namespace),
+			zap.String("name", name),
+			zap.Error(err),
+		)
+		response.InternalError(c, err)
+		return
+	}
+
+	logger.Log.Info("Advanced cron job "+operation+"d",
+		zap.String("namespace", namespace),
+		zap.String("name", name),
+	)
+	response.Success(c, gin.H{
+		"message": fmt.Sprintf("Successfully %sd advancedcronjob %s", operation, name),
+		"paused":  paused,
+	})
+}
+
+// SuspendWorkload suspends an AdvancedCronJob by setting .spec.paused=true.
+func SuspendWorkload(c *gin.Context) {
+	setAdvancedCronJobPaused(c, true)
+}
+
+// ResumeWorkload resumes an AdvancedCronJob by setting .spec.paused=false.
+func ResumeWorkload(c *gin.Context) {
+	setAdvancedCronJobPaused(c, false)
+}
+
+// cronJobTemplate returns the child job GVR, kind and template of an AdvancedCronJob.
+// An AdvancedCronJob templates either a batch/v1 Job or a Kruise BroadcastJob.
+func cronJobTemplate(cronJob map[string]interface{}) (schema.GroupVersionResource, string, map[string]interface{}, bool) {
+	if tpl, found, _ := unstructured.NestedMap(cronJob, "spec", "template", "jobTemplate"); found {
+		return jobGVR, "Job", tpl, true
+	}
+	if tpl, found, _ := unstructured.NestedMap(cronJob, "spec", "template", "broadcastJobTemplate"); found {
+		if _, info, ok := ResolveWorkloadKind(broadcastJobKind); ok {
+			return info.GVR, broadcastJobKind, tpl, true
+		}
+	}
+	return schema.GroupVersionResource{}, "", nil, false
+}
+
+// manualJobName builds a unique child job name, keeping it within the 63 character label limit.
+// A truncated name loses its trailing separators so the suffix does not double them.
+func manualJobName(cronJobName string, now time.Time) string {
+	suffix := "-manual-" + strconv.FormatInt(now.Unix(), 36)
+	if len(cronJobName)+len(suffix) > 63 {
+		cronJobName = strings.TrimRight(cronJobName[:63-len(suffix)], "-.")
+	}
+	return cronJobName + suffix
+}
+
+// cronJobChildSelector returns the label selector of an AdvancedCronJob's child jobs: its own
+// selector if it has one, otherwise the labels its job template gives every child.
+func cronJobChildSelector(info WorkloadTypeInfo, cronJob map[string]interface{}, template map[string]interface{}) string {
+	if selector := workloadLabelSelector(info, cronJob); selector != "" {
+		return selector
+	}
+	templateLabels, _, _ := unstructured.NestedStringMap(template, "metadata", "labels")
+	return labels.SelectorFromSet(templateLabels).String()
+}
+
+func buildJobFromCronTemplate(cronJob *unstructured.Unstructured, gvr schema.GroupVersionResource, kind string, template map[string]interface{}, now time.Time) *unstructured.Unstructured {
+	tpl := runtime.DeepCopyJSON(template)
+	metadata, _ := tpl["metadata"].(map[string]interface{})
+	if metadata == nil {
+		metadata = map[string]interface{}{}
+	}
+	annotations, _ := metadata["annotations"].(map[string]interface{})
+	if annotations == nil {
+		annotations = map[string]interface{}{}
+	}
+	annotations[instantiateAnnotation] = "manual"
+
+	jobMeta := map[string]interface{}{
+		"name":        manualJobName(cronJob.GetName(), now),
+		"namespace":   cronJob.GetNamespace(),
+		"annotations": annotations,
+		"ownerReferences": []interface{}{
+			map[string]interface{}{
+				"apiVersion":         cronJob.GetAPIVersion(),
+				"kind":               advancedCronJobKind,
+				"name":               cronJob.GetName(),
+				"uid":                string(cronJob.GetUID()),
+				"controller":         true,
+				"blockOwnerDeletion": true,
+			},
+		},
+	}
+	if labels, ok := metadata["labels"].(map[string]interface{}); ok {
+		jobMeta["labels"] = labels
+	}
+
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": gvr.GroupVersion().String(),
+		"kind":       kind,
+		"metadata":   jobMeta,
+		"spec":       tpl["spec"],
+	}}
+}
+
+// TriggerWorkload creates a job from an AdvancedCronJob template immediately.
+func TriggerWorkload(c *gin.Context) {
+	namespace := c.Param("namespace")
+	name := c.Param("name")
+
+	info, ok := resolveJobWorkload(c, advancedCronJobKind, "trigger")
+	if !ok {
+		return
+	}
+
+	cronJob, err := GetDynamicClient().Resource(info.GVR).Namespace(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		logger.Log.Error("Failed to get advanced cron job for trigger",
+			zap.String("namespace", namespace),
+			zap.String("name", name),
+			zap.Error(err),
+		)
+		response.InternalError(c, err)
+		return
+	}
+
+	gvr, kind, template, found := cronJobTemplate(cronJob.Object)
+	if !found {
+		response.Error(c, http.StatusConflict, "AdvancedCronJob has no job template", nil, errorCodeJobTemplateNA)
+		return
+	}
+
+	job := buildJobFromCronTemplate(cronJob, gvr, kind, template, time.Now())
+	created, err := GetDynamicClient().Resource(gvr).Namespace(namespace).Create(context.TODO(), job, metav1.CreateOptions{})
+	if err != nil {
+		logger.Log.Error("Failed to create job from advanced cron job",
+			zap.String("namespace", namespace),
+			zap.String("name", name),
+			zap.String("kind", kind),
+			zap.Error(err),
+		)
+		response.InternalError(c, err)
+		return
+	}
+
+	logger.Log.Info("Advanced cron job triggered",
+		zap.String("namespace", namespace),
+		zap.String("name", name),
+		zap.String("job", created.GetName()),
+	)
+	response.Success(c, gin.H{
+		"message": fmt.Sprintf("Triggered advancedcronjob %s", name),
+		"job":     created.GetName(),
+		"kind":    kind,
+	})
+}
+
+// jobOutcome derives a phase for a batch/v1 Job or a BroadcastJob from its status.
+func jobOutcome(job map[string]interface{}) string {
+	conditions, _, _ := unstructured.NestedSlice(job, "status", "conditions")
+	for _, raw := range conditions {
+		cond, _ := raw.(map[string]interface{})
+		if cond == nil || cond["status"] != "True" {
+			continue
+		}
+		switch cond["type"] {
+		case "Complete":
+			return jobPhaseSucceeded
+		case "Failed":
+			return jobPhaseFailed
+		case "Suspended":
+			return jobPhaseSuspended
+		}
+	}
+
+	// BroadcastJob reports its lifecycle in status.phase.
+	phase, _, _ := unstructured.NestedString(job, "status", "phase")
+	switch phase {
+	case "Completed":
+		return jobPhaseSucceeded
+	case "Failed":
+		return jobPhaseFailed
+	case "Paused":
+		return jobPhaseSuspended
+	}
+	return jobPhaseActive
+}
+
+func isOwnedBy(obj map[string]interface{}, kind, name string) bool {
+	owners, _, _ := unstructured.NestedSlice(obj, "metadata", "ownerReferences")
+	for _, raw := range owners {
+		owner, _ := raw.(map[string]interface{})
+		if owner != nil && owner["kind"] == kind && owner["name"] == name {
+			return true
+		}
+	}
+	return false
+}
+
+func summarizeChildJob(job map[string]interface{}, activeNames map[string]bool) map[string]interface{} {
+	name, _, _ := unstructured.NestedString(job, "metadata", "name")
+	created, _, _ := unstructured.NestedString(job, "metadata", "creationTimestamp")
+	annotations, _, _ := unstructured.NestedStringMap(job, "metadata", "annotations")
+	startTime, _, _ := unstructured.NestedString(job, "status", "startTime")
+	completionTime, _, _ := unstructured.NestedString(job, "status", "completionTime")
+	active, _, _ := unstructured.NestedInt64(job, "status", "active")
+	succeeded, _, _ := unstructured.NestedInt64(job, "status", "succeeded")
+	failed, _, _ := unstructured.NestedInt64(job, "status", "failed")
+
+	return map[string]interface{}{
+		"name":              name,
+		"creationTimestamp": created,
+		"startTime":         startTime,
+		"completionTime":    completionTime,
+		"phase":             jobOutcome(job),
+		"active":            active,
+		"succeeded":         succeeded,
+		"failed":            failed,
+		"manual":            annotations[instantiateAnnotation] == "manual",
+		"isActive":          activeNames[name],
+	}
+}
+
+// ListWorkloadJobs lists the active and finished child jobs of an AdvancedCronJob.
+func ListWorkloadJobs(c *gin.Context) {
+	namespace := c.Param("namespace")
+	name := c.Param("name")
+
+	info, ok := resolveJobWorkload(c, advancedCronJobKind, "listing child jobs")
+	if !ok {
+		return
+	}
+
+	cronJob, err := GetDynamicClient().Resource(info.GVR).Namespace(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		logger.Log.Error("Failed to get advanced cron job for jobs",
+			zap.String("namespace", namespace),
+			zap.String("name", name),
+			zap.Error(err),
+		)
+		response.InternalError(c, err)
+		return
+	}
+
+	gvr, kind, template, found := cronJobTemplate(cronJob.Object)
+	if !found {
+		response.Error(c, http.StatusConflict, "AdvancedCronJob has no job template", nil, errorCodeJobTemplateNA)
+		return
+	}
+
+	// The selector narrows the list server-side; the owner reference still decides which jobs are
+	// children, as other jobs may carry the same labels.
+	list, err := GetDynamicClient().Resource(gvr).Namespace(namespace).List(context.TODO(), metav1.ListOptions{
+		LabelSelector: cronJobChildSelector(info, cronJob.Object, template),
+	})
+	if err != nil {
+		logger.Log.Error("Failed to list child jobs",
+			zap.String("namespace", namespace),
+			zap.String("name", name),
+			zap.String("kind", kind),
+			zap.Error(err),
+		)
+		response.InternalError(c, err)
+		return
+	}
+
+	activeNames := map[string]bool{}
+	activeRefs, _, _ := unstructured.NestedSlice(cronJob.Object, "status", "active")
+	for _, raw := range activeRefs {
+		if ref, ok := raw.(map[string]interface{}); ok {
+			if refName, _ := ref["name"].(string); refName != "" {
+				activeNames[refName] = true
+			}
+		}
+	}
+
+	jobs := make([]map[string]interface{}, 0)
+	for i := range list.Items {
+		if !isOwnedBy(list.Items[i].Object, advancedCronJobKind, name) {
+			continue
+		}
+		jobs = append(jobs, summarizeChildJob(list.Items[i].Object, activeNames))
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i]["creationTimestamp"].(string) > jobs[j]["creationTimestamp"].(string)
+	})
+
+	paused, _, _ := unstructured.NestedBool(cronJob.Object, "spec", "paused")
+	schedule, _, _ := unstructured.NestedString(cronJob.Object, "spec", "schedule")
+	lastScheduleTime, _, _ := unstructured.NestedString(cronJob.Object, "status", "lastScheduleTime")
+
+	response.Success(c, gin.H{
+		"name":             name,
+		"namespace":        namespace,
+		"schedule":         schedule,
+		"paused":           paused,
+		"lastScheduleTime": lastScheduleTime,
+		"jobKind":          kind,
+		"jobs":             jobs,
+	})
+}
+
+// groupPodsByNode counts the pods of a job by node and pod phase.
+func groupPodsByNode(pods []interface{}) []map[string]interface{} {
+	type nodeCounts struct {
+		active, succeeded, failed int
+		pods                      []string
+	}
+	byNode := map[string]*nodeCounts{}
+	for _, raw := range pods {
+		pod, ok := raw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		nodeName, _, _ := unstructured.NestedString(pod, "spec", "nodeName")
+		podName, _, _ := unstructured.NestedString(pod, "metadata", "name")
+		phase, _, _ := unstructured.NestedString(pod, "status", "phase")
+
+		counts, ok := byNode[nodeName]
+		if !ok {
+			counts = &nodeCounts{}
+			byNode[nodeName] = counts
+		}
+		counts.pods = append(counts.pods, podName)
+		switch phase {
+		case "Succeeded":
+			counts.succeeded++
+		case "Failed":
+			counts.failed++
+		default:
+			counts.active++
+		}
+	}
+
+	nodes := make([]map[string]interface{}, 0, len(byNode))
+	for nodeName, counts := range byNode {
+		sort.Strings(counts.pods)
+		nodes = append(nodes, map[string]interface{}{
+			"node":      nodeName,
+			"active":    counts.active,
+			"succeeded": counts.succeeded,
+			"failed":    counts.failed,
+			"pods":      counts.pods,
+		})
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i]["node"].(string) < nodes[j]["node"].(string)
+	})
+	return nodes
+}
+
+// GetWorkloadNodeStatus returns per-node pod status of a BroadcastJob.
+func GetWorkloadNodeStatus(c *gin.Context) {
+	namespace := c.Param("namespace")
+	name := c.Param("name")
+
+	info, ok := resolveJobWorkload(c, broadcastJobKind, "node status")
+	if !ok {
+		return
+	}
+
+	job, err := GetDynamicClient().Resource(info.GVR).Namespace(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		logger.Log.Error("Failed to get broadcast job for node status",
+			zap.String("namespace", namespace),
+			zap.String("name", name),
+			zap.Error(err),
+		)
+		response.InternalError(c, err)
+		return
+	}
+
+	pods, err := listWorkloadPods(namespace, name, info, job)
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+
+	desired, _, _ := unstructured.NestedInt64(job.Object, "status", "desired")
+	active, _, _ := unstructured.NestedInt64(job.Object, "status", "active")
+	succeeded, _, _ := unstructured.NestedInt64(job.Object, "status", "succeeded")
+	failed, _, _ := unstructured.NestedInt64(job.Object, "status", "failed")
+
+	response.Success(c, gin.H{
+		"name":      name,
+		"namespace": namespace,
+		"phase":     jobOutcome(job.Object),
+		"desired":   desired,
+		"active":    active,
+		"succeeded": succeeded,
+		"failed":    failed,
+		"nodes":     groupPodsByNode(pods),
+	})
+}
+
+// buildBroadcastJobRerun copies a BroadcastJob's spec into a fresh object so it runs again.
+func buildBroadcastJobRerun(job *unstructured.Unstructured) *unstructured.Unstructured {
+	spec, _, _ := unstructured.NestedMap(job.Object, "spec")
+	// The previous run may have finished because it was paused; the rerun starts active.
+	delete(spec, "paused")
+
+	annotations := map[string]interface{}{}
+	for k, v := range job.GetAnnotations() {
+		annotations[k] = v
+	}
+	annotations[rerunOfAnnotation] = job.GetName()
+
+	labels := map[string]interface{}{}
+	for k, v := range job.GetLabels() {
+		labels[k] = v
+	}
+
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": job.GetAPIVersion(),
+		"kind":       broadcastJobKind,
+		"metadata": map[string]interface{}{
+			"generateName": job.GetName() + "-rerun-",
+			"namespace":    job.GetNamespace(),
+			"labels":       labels,
+			"annotations":  annotations,
+		},
+		"spec": spec,
+	}}
+}
+
+// RerunWorkload runs a BroadcastJob again by creating a copy of it.
+func RerunWorkload(c *gin.Context) {
+	namespace := c.Param("namespace")
+	name := c.Param("name")
+
+	info, ok := resolveJobWorkload(c, broadcastJobKind, "rerun")
+	if !ok {
+		return
+	}
+
+	job, err := GetDynamicClient().Resource(info.GVR).Namespace(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		logger.Log.Error("Failed to get broadcast job for rerun",
+			zap.String("namespace", namespace),
+			zap.String("name", name),
+			zap.Error(err),
+		)
+		response.InternalError(c, err)
+		return
+	}
+
+	created, err := GetDynamicClient().Resource(info.GVR).Namespace(namespace).Create(context.TODO(), buildBroadcastJobRerun(job), metav1.CreateOptions{})
+	if err != nil {
+		logger.Log.Error("Failed to rerun broadcast job",
+			zap.String("namespace", namespace),
+			zap.String("name", name),
+			zap.Error(err),
+		)
+		response.InternalError(c, err)
+		return
+	}
+
+	logger.Log.Info("Broadcast job rerun created",
+		zap.String("namespace", namespace),
+		zap.String("name", name),
+		zap.String("rerun", created.GetName()),
+	)
+	response.Success(c, gin.H{
+		"message": fmt.Sprintf("Rerun of broadcastjob %s created", name),
+		"job":     created.GetName(),
+	})
+}
@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+func TestJobOutcome(t *testing.T) {
+	tests := []struct {
+		name string
+		job  map[string]interface{}
+		want string
+	}{
+		{
+			name: "complete condition",
+			job: map[string]interface{}{"status": map[string]interface{}{
+				"conditions": []interface{}{map[string]interface{}{"type": "Complete", "status": "True"}},
+			}},
+			want: jobPhaseSucceeded,
+		},
+		{
+			name: "failed condition",
+			job: map[string]interface{}{"status": map[string]interface{}{
+				"conditions": []interface{}{map[string]interface{}{"type": "Failed", "status": "True"}},
+			}},
+			want: jobPhaseFailed,
+		},
+		{
+			name: "broadcastjob completed phase",
+			job:  map[string]interface{}{"status": map[string]interface{}{"phase": "Completed"}},
+			want: jobPhaseSucceeded,
+		},
+		{
+			name: "no status",
+			job:  map[string]interface{}{},
+			want: jobPhaseActive,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := jobOutcome(tt.job); got != tt.want {
+				t.Errorf("jobOutcome() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestManualJobName(t *testing.T) {
+	name := manualJobName(strings.Repeat("a", 70), time.Unix(1700000000, 0))
+	if len(name) > 63 {
+		t.Errorf("len(manualJobName()) = %d, want <= 63", len(name))
+	}
+	if !strings.Contains(name, "-manual-") {
+		t.Errorf("manualJobName() = %q, want -manual- suffix", name)
+	}
+
+	// Truncation right after a separator must not leave "--" before the suffix.
+	suffix := "-manual-" + strconv.FormatInt(1700000000, 36)
+	name = manualJobName(strings.Repeat("a", 62-len(suffix))+"-bbbbbbbb", time.Unix(1700000000, 0))
+	if strings.Contains(name, "--") || name != strings.Repeat("a", 62-len(suffix))+suffix {
+		t.Errorf("manualJobName() = %q, want the trailing separator trimmed", name)
+	}
+}
+
+func TestCronJobChildSelector(t *testing.T) {
+	info := WorkloadTypeInfo{SelectorPath: defaultSelectorPath}
+	template := map[string]interface{}{
+		"metadata": map[string]interface{}{"labels": map[string]interface{}{"app": "report"}},
+	}
+	if got := cronJobChildSelector(info, map[string]interface{}{}, template); got != "app=report" {
+		t.Errorf("selector from template labels = %q, want app=report", got)
+	}
+	cronJob := map[string]interface{}{
+		"spec": map[string]interface{}{"selector": map[string]interface{}{"matchLabels": map[string]interface{}{"job": "nightly"}}},
+	}
+	if got := cronJobChildSelector(info, cronJob, template); got != "job=nightly" {
+		t.Errorf("selector = %q, want the cron job's own selector", got)
+	}
+}
+
+func TestGroupPodsByNode(t *testing.T) {
+	pod := func(name, node, phase string) interface{} {
+		return map[string]interface{}{
+			"metadata": map[string]interface{}{"name": name},
+			"spec":     map[string]interface{}{"nodeName": node},
+			"status":   map[string]interface{}{"phase": phase},
+		}
+	}
+	nodes := groupPodsByNode([]interface{}{
+		pod("job-a", "node-2", "Succeeded"),
+		pod("job-b", "node-1", "Failed"),
+		pod("job-c", "node-1", "Running"),
+	})
+
+	if len(nodes) != 2 {
+		t.Fatalf("len(nodes) = %d, want 2", len(nodes))
+	}
+	if nodes[0]["node"] != "node-1" || nodes[0]["failed"] != 1 || nodes[0]["active"] != 1 {
+		t.Errorf("nodes[0] = %v, want node-1 with 1 failed and 1 active", nodes[0])
+	}
+	if nodes[1]["succeeded"] != 1 {
+		t.Errorf("nodes[1] = %v, want 1 succeeded", nodes[1])
+	}
+}
+
+func TestBroadcastJobPodSelector(t *testing.T) {
+	job := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps.kruise.io/v1alpha1",
+		"kind":       "BroadcastJob",
+		"metadata":   map[string]interface{}{"name": "warmup", "namespace": "default", "uid": "9f1c7a2e-1111-4c2b-8d9e-0a1b2c3d4e5f"},
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{"metadata": map[string]interface{}{"labels": map[string]interface{}{"app": "cache"}}},
+		},
+	}}
+	// Labels the kruise BroadcastJob controller puts on its pods.
+	podLabels := labels.Set{
+		"app":                         "cache",
+		"broadcastjob-name":           "warmup",
+		"broadcastjob-controller-uid": "9f1c7a2e-1111-4c2b-8d9e-0a1b2c3d4e5f",
+	}
+	otherJobPod := labels.Set{"broadcastjob-name": "other", "broadcastjob-controller-uid": "0000"}
+
+	selector, err := labels.Parse(workloadPodSelector(workloadTypeRegistry["broadcastjob"], job))
+	if err != nil {
+		t.Fatalf("invalid selector: %v", err)
+	}
+	if !selector.Matches(podLabels) {
+		t.Errorf("selector %q does not match the job's pods", selector)
+	}
+	if selector.Matches(otherJobPod) {
+		t.Errorf("selector %q matches another job's pods", selector)
+	}
+}
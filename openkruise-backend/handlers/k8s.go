@@ -60,16 +60,36 @@ func GetDynamicClient() dynamic.Interface {
 }
 
 // ClusterMetrics represents cluster-wide metrics.
-// CPU and memory come from the metrics-server (Node Metrics API); storage and network are not provided by the standard API.
+// CPU and memory come from the metrics-server (Node Metrics API); storage and network come from the
+// optional CLUSTER_USAGE_PROVIDER. Usage fields that could not be measured are 0 and false in
+// Available. StorageUsage is a percentage of node filesystem capacity. Network throughput has no
+// capacity to be a percentage of, so it is reported in bytes per second and NetworkUsage is never
+// set. StorageNodes and NetworkNodes count the nodes the sums cover; fewer than ReadyNodes means
+// the values are partial.
 type ClusterMetrics struct {
-	CPUUsage     float64 `json:"cpuUsage"`
-	MemoryUsage  float64 `json:"memoryUsage"`
-	StorageUsage float64 `json:"storageUsage"`
-	NetworkUsage float64 `json:"networkUsage"`
-	TotalNodes   int32   `json:"totalNodes"`
-	ReadyNodes   int32   `json:"readyNodes"`
-	TotalPods    int32   `json:"totalPods"`
-	RunningPods  int32   `json:"runningPods"`
+	CPUUsage                      float64                    `json:"cpuUsage"`
+	MemoryUsage                   float64                    `json:"memoryUsage"`
+	StorageUsage                  float64                    `json:"storageUsage"`
+	StorageUsedBytes              int64                      `json:"storageUsedBytes"`
+	StorageCapacityBytes          int64                      `json:"storageCapacityBytes"`
+	StorageNodes                  int32                      `json:"storageNodes"`
+	NetworkUsage                  *float64                   `json:"networkUsage,omitempty" openapi:"deprecated"`
+	NetworkReceiveBytesPerSecond  float64                    `json:"networkReceiveBytesPerSecond"`
+	NetworkTransmitBytesPerSecond float64                    `json:"networkTransmitBytesPerSecond"`
+	NetworkNodes                  int32                      `json:"networkNodes"`
+	Available                     ClusterMetricsAvailability `json:"available"`
+	TotalNodes                    int32                      `json:"totalNodes"`
+	ReadyNodes                    int32                      `json:"readyNodes"`
+	TotalPods                     int32                      `json:"totalPods"`
+	RunningPods                   int32                      `json:"runningPods"`
+}
+
+// ClusterMetricsAvailability tells which usage fields of ClusterMetrics were measured.
+type ClusterMetricsAvailability struct {
+	CPU     bool `json:"cpu"`
+	Memory  bool `json:"memory"`
+	Storage bool `json:"storage"`
+	Network bool `json:"network"`
 }
 
 // ListNamespaces returns all namespaces in the cluster
@@ -106,11 +126,8 @@ func getRestConfig() (*rest.Config, error) {
 func countReadyNodes(nodes *corev1.NodeList) int32 {
 	var count int32
 	for i := range nodes.Items {
-		for _, cond := range nodes.Items[i].Status.Conditions {
-			if cond.Type == corev1.NodeReady && cond.Status == corev1.ConditionTrue {
-				count++
-				break
-			}
+		if nodeIsReady(&nodes.Items[i]) {
+			count++
 		}
 	}
 	return count
@@ -138,16 +155,18 @@ func buildNodeAllocatableMap(nodes *corev1.NodeList) map[string]nodeAllocatable
 	return m
 }
 
-func computeResourceUsagePct(config *rest.Config, nodeAllocatable map[string]nodeAllocatable) (cpuPct, memPct float64) {
+// computeResourceUsagePct returns CPU and memory usage as percentages of allocatable; ok is false
+// when the metrics API is unavailable.
+func computeResourceUsagePct(config *rest.Config, nodeAllocatable map[string]nodeAllocatable) (cpuPct, memPct float64, ok bool) {
 	metricsClient, err := metricsv.NewForConfig(config)
 	if err != nil {
 		logger.Log.Warn("Metrics client not available (metrics-server may be missing)", zap.Error(err))
-		return 0, 0
+		return 0, 0, false
 	}
 	nodeMetricsList, err := metricsClient.MetricsV1beta1().NodeMetricses().List(context.TODO(), metav1.ListOptions{})
 	if err != nil {
 		logger.Log.Warn("Failed to list node metrics (is metrics-server installed?)", zap.Error(err))
-		return 0, 0
+		return 0, 0, false
 	}
 	var totalCPUAlloc, totalMemAlloc, totalCPUUsage, totalMemUsage int64
 	for i := range nodeMetricsList.Items {
@@ -167,7 +186,7 @@ func computeResourceUsagePct(config *rest.Config, nodeAllocatable map[string]nod
 	if totalMemAlloc > 0 {
 		memPct = 100 * float64(totalMemUsage) / float64(totalMemAlloc)
 	}
-	return cpuPct, memPct
+	return cpuPct, memPct, totalCPUAlloc > 0
 }
 
 func GetClusterMetrics(c *gin.Context) {
@@ -200,16 +219,38 @@ func GetClusterMetrics(c *gin.Context) {
 	}
 
 	allocatable := buildNodeAllocatableMap(nodes)
-	cpuPct, memPct := computeResourceUsagePct(config, allocatable)
-
-	response.Success(c, ClusterMetrics{
-		CPUUsage:     cpuPct,
-		MemoryUsage:  memPct,
-		StorageUsage: 0,
-		NetworkUsage: 0,
-		TotalNodes:   int32(len(nodes.Items)),
-		ReadyNodes:   countReadyNodes(nodes),
-		TotalPods:    int32(len(pods.Items)),
-		RunningPods:  countRunningPods(pods),
-	})
+	cpuPct, memPct, resourceMetricsOK := computeResourceUsagePct(config, allocatable)
+
+	response.Success(c, clusterMetrics(cpuPct, memPct, resourceMetricsOK, readClusterUsage(nodes.Items), nodes, pods))
+}
+
+func clusterMetrics(cpuPct, memPct float64, resourceMetricsOK bool, usage clusterUsage, nodes *corev1.NodeList, pods *corev1.PodList) ClusterMetrics {
+	metrics := ClusterMetrics{
+		CPUUsage:    cpuPct,
+		MemoryUsage: memPct,
+		Available: ClusterMetricsAvailability{
+			CPU:     resourceMetricsOK,
+			Memory:  resourceMetricsOK,
+			Storage: usage.HasStorage,
+			Network: usage.HasNetwork,
+		},
+		TotalNodes:  int32(len(nodes.Items)),
+		ReadyNodes:  countReadyNodes(nodes),
+		TotalPods:   int32(len(pods.Items)),
+		RunningPods: countRunningPods(pods),
+	}
+	if usage.HasStorage {
+		metrics.StorageNodes = usage.StorageNodes
+		metrics.StorageUsedBytes = usage.StorageUsedBytes
+		metrics.StorageCapacityBytes = usage.StorageCapacityBytes
+		if usage.StorageCapacityBytes > 0 {
+			metrics.StorageUsage = 100 * float64(usage.StorageUsedBytes) / float64(usage.StorageCapacityBytes)
+		}
+	}
+	if usage.HasNetwork {
+		metrics.NetworkNodes = usage.NetworkNodes
+		metrics.NetworkReceiveBytesPerSecond = usage.NetworkReceiveBytesPerSecond
+		metrics.NetworkTransmitBytesPerSecond = usage.NetworkTransmitBytesPerSecond
+	}
+	return metrics
 }
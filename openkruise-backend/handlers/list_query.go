@@ -0,0 +1,254 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/openkruise/kruise-dashboard/extensions-backend/pkg/response"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	maxListLimit = 500
+
+	listViewFull    = "full"
+	listViewSummary = "summary"
+
+	sortByName              = "name"
+	sortByCreationTimestamp = "creationTimestamp"
+	sortByReadiness         = "readiness"
+
+	// offsetContinuePrefix marks continue tokens issued by the dashboard for lists it sorts itself,
+	// as opposed to API server continue tokens which are passed through unchanged.
+	offsetContinuePrefix = "offset:"
+
+	errorCodeContinueExpired = "CONTINUE_EXPIRED"
+)
+
+var errInvalidContinue = errors.New("invalid continue token")
+
+// listQuery holds the pagination, sorting and projection parameters of a list request.
+type listQuery struct {
+	Limit    int64
+	Continue string
+	SortBy   string
+	Desc     bool
+	Summary  bool
+}
+
+// bindListQuery parses limit, continue, sortBy, order and view. sortKeys are the sort keys the
+// endpoint supports.
+func bindListQuery(c *gin.Context, sortKeys ...string) (listQuery, bool) {
+	q := listQuery{
+		Continue: c.Query("continue"),
+		SortBy:   c.Query("sortBy"),
+	}
+
+	if raw := c.Query("limit"); raw != "" {
+		limit, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || limit < 1 || limit > maxListLimit {
+			response.BadRequest(c, "limit must be an integer between 1 and "+strconv.Itoa(maxListLimit))
+			return q, false
+		}
+		q.Limit = limit
+	}
+
+	if q.SortBy != "" {
+		supported := false
+		for _, key := range sortKeys {
+			if key == q.SortBy {
+				supported = true
+				break
+			}
+		}
+		if !supported {
+			response.BadRequest(c, "sortBy must be one of: "+strings.Join(sortKeys, ", "))
+			return q, false
+		}
+	}
+
+	switch order := c.DefaultQuery("order", "asc"); order {
+	case "asc":
+	case "desc":
+		q.Desc = true
+	default:
+		response.BadRequest(c, "order must be asc or desc")
+		return q, false
+	}
+
+	switch view := c.DefaultQuery("view", listViewFull); view {
+	case listViewFull:
+	case listViewSummary:
+		q.Summary = true
+	default:
+		response.BadRequest(c, "view must be full or summary")
+		return q, false
+	}
+	return q, true
+}
+
+// serverPaged reports whether the API server's continue token can page the result. The API server
+// returns objects in key order, i.e. ascending by name within a namespace.
+func (q listQuery) serverPaged() bool {
+	return (q.SortBy == "" || q.SortBy == sortByName) && !q.Desc
+}
+
+// pageByOffset returns the [start, end) window of a sorted list of total items and the continue
+// token of the next page.
+func pageByOffset(total int, q listQuery) (int, int, string, error) {
+	start := 0
+	if q.Continue != "" {
+		if !strings.HasPrefix(q.Continue, offsetContinuePrefix) {
+			return 0, 0, "", errInvalidContinue
+		}
+		offset, err := strconv.Atoi(strings.TrimPrefix(q.Continue, offsetContinuePrefix))
+		if err != nil || offset < 0 || offset > total {
+			return 0, 0, "", errInvalidContinue
+		}
+		start = offset
+	}
+
+	end := total
+	next := ""
+	if q.Limit > 0 && start+int(q.Limit) < total {
+		end = start + int(q.Limit)
+		next = offsetContinuePrefix + strconv.Itoa(end)
+	}
+	return start, end, next, nil
+}
+
+// workloadReadiness is the fraction of desired replicas that are ready; workloads without desired
+// replicas count as fully ready.
+func workloadReadiness(obj map[string]interface{}) float64 {
+	status := readWorkloadReplicaStatus(obj)
+	if status.Replicas <= 0 {
+		return 1
+	}
+	return float64(status.Ready) / float64(status.Replicas)
+}
+
+// compareObjects orders two objects by sortBy, breaking ties by namespace and name.
+func compareObjects(a, b map[string]interface{}, sortBy string) int {
+	switch sortBy {
+	case sortByCreationTimestamp:
+		at, _, _ := unstructured.NestedString(a, "metadata", "creationTimestamp")
+		bt, _, _ := unstructured.NestedString(b, "metadata", "creationTimestamp")
+		// RFC 3339 timestamps in UTC sort lexically.
+		if cmp := strings.Compare(at, bt); cmp != 0 {
+			return cmp
+		}
+	case sortByReadiness:
+		ar, br := workloadReadiness(a), workloadReadiness(b)
+		if ar < br {
+			return -1
+		}
+		if ar > br {
+			return 1
+		}
+	}
+
+	an, _, _ := unstructured.NestedString(a, "metadata", "name")
+	bn, _, _ := unstructured.NestedString(b, "metadata", "name")
+	if sortBy == sortByName {
+		if cmp := strings.Compare(an, bn); cmp != 0 {
+			return cmp
+		}
+	}
+	ans, _, _ := unstructured.NestedString(a, "metadata", "namespace")
+	bns, _, _ := unstructured.NestedString(b, "metadata", "namespace")
+	if cmp := strings.Compare(ans, bns); cmp != 0 {
+		return cmp
+	}
+	return strings.Compare(an, bn)
+}
+
+// sortUnstructured sorts items by q.SortBy; without a sort key the order is left unchanged.
+func sortUnstructured(items []unstructured.Unstructured, q listQuery) {
+	if q.SortBy == "" {
+		if q.Desc {
+			for i, j := 0, len(items)-1; i < j; i, j = i+1, j-1 {
+				items[i], items[j] = items[j], items[i]
+			}
+		}
+		return
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		cmp := compareObjects(items[i].Object, items[j].Object, q.SortBy)
+		if q.Desc {
+			return cmp > 0
+		}
+		return cmp < 0
+	})
+}
+
+// listResourcePage lists one resource according to q. When the API server can page the result
+// its limit and continue token are used directly; otherwise every object is listed, sorted and
+// paged by offset. The returned list's continue field holds the next page token.
+func listResourcePage(ctx context.Context, gvr schema.GroupVersionResource, namespace string, opts metav1.ListOptions, q listQuery) (*unstructured.UnstructuredList, error) {
+	if q.serverPaged() {
+		if strings.HasPrefix(q.Continue, offsetContinuePrefix) {
+			return nil, errInvalidContinue
+		}
+		opts.Limit = q.Limit
+		opts.Continue = q.Continue
+		return GetDynamicClient().Resource(gvr).Namespace(namespace).List(ctx, opts)
+	}
+	return listFilteredPage(ctx, gvr, namespace, opts, q, nil)
+}
+
+// listFilteredPage lists every object, keeps those matching keep (all when nil), then sorts and
+// pages them by offset. Filtering before paging keeps every page but the last full.
+func listFilteredPage(ctx context.Context, gvr schema.GroupVersionResource, namespace string, opts metav1.ListOptions, q listQuery, keep func(obj map[string]interface{}) bool) (*unstructured.UnstructuredList, error) {
+	list, err := GetDynamicClient().Resource(gvr).Namespace(namespace).List(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	if keep != nil {
+		kept := list.Items[:0]
+		for i := range list.Items {
+			if keep(list.Items[i].Object) {
+				kept = append(kept, list.Items[i])
+			}
+		}
+		list.Items = kept
+	}
+	sortUnstructured(list.Items, q)
+	start, end, next, err := pageByOffset(len(list.Items), q)
+	if err != nil {
+		return nil, err
+	}
+	list.Items = list.Items[start:end]
+	list.SetContinue(next)
+	list.SetRemainingItemCount(nil)
+	return list, nil
+}
+
+// handleListError responds to pagination errors and reports whether it did so. Other errors are
+// left to the caller.
+func handleListError(c *gin.Context, err error) bool {
+	if errors.Is(err, errInvalidContinue) {
+		response.BadRequest(c, err.Error())
+		return true
+	}
+	if apierrors.IsResourceExpired(err) || apierrors.IsGone(err) {
+		response.Error(c, http.StatusGone, "continue token expired, restart the list from the first page", nil, errorCodeContinueExpired)
+		return true
+	}
+	return false
+}
+
+// stripManagedFields drops metadata.managedFields, which clients never need and which dominates
+// the size of list payloads.
+func stripManagedFields(items []unstructured.Unstructured) {
+	for i := range items {
+		items[i].SetManagedFields(nil)
+	}
+}
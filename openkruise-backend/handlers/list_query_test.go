@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+func TestPageByOffset(t *testing.T) {
+	tests := []struct {
+		name      string
+		total     int
+		q         listQuery
+		wantStart int
+		wantEnd   int
+		wantNext  string
+		wantErr   bool
+	}{
+		{name: "no limit", total: 5, q: listQuery{}, wantStart: 0, wantEnd: 5},
+		{name: "first page", total: 5, q: listQuery{Limit: 2}, wantStart: 0, wantEnd: 2, wantNext: "offset:2"},
+		{name: "middle page", total: 5, q: listQuery{Limit: 2, Continue: "offset:2"}, wantStart: 2, wantEnd: 4, wantNext: "offset:4"},
+		{name: "last page", total: 5, q: listQuery{Limit: 2, Continue: "offset:4"}, wantStart: 4, wantEnd: 5},
+		{name: "api server token", total: 5, q: listQuery{Limit: 2, Continue: "eyJ2IjoibWV0YS5rOHMuaW8vdjEifQ"}, wantErr: true},
+		{name: "offset past end", total: 5, q: listQuery{Limit: 2, Continue: "offset:9"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end, next, err := pageByOffset(tt.total, tt.q)
+			if tt.wantErr {
+				if !errors.Is(err, errInvalidContinue) {
+					t.Fatalf("pageByOffset() error = %v, want errInvalidContinue", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("pageByOffset() unexpected error: %v", err)
+			}
+			if start != tt.wantStart || end != tt.wantEnd || next != tt.wantNext {
+				t.Errorf("pageByOffset() = (%d, %d, %q), want (%d, %d, %q)", start, end, next, tt.wantStart, tt.wantEnd, tt.wantNext)
+			}
+		})
+	}
+}
+
+func workloadForSort(name, created string, replicas, ready int64) unstructured.Unstructured {
+	return unstructured.Unstructured{Object: map[string]interface{}{
+		"metadata": map[string]interface{}{"name": name, "namespace": "default", "creationTimestamp": created},
+		"status":   map[string]interface{}{"replicas": replicas, "readyReplicas": ready},
+	}}
+}
+
+func TestSortUnstructured(t *testing.T) {
+	items := []unstructured.Unstructured{
+		workloadForSort("b", "2024-01-02T00:00:00Z", 4, 1),
+		workloadForSort("c", "2024-01-01T00:00:00Z", 2, 2),
+		workloadForSort("a", "2024-01-03T00:00:00Z", 0, 0),
+	}
+
+	tests := []struct {
+		name string
+		q    listQuery
+		want []string
+	}{
+		{name: "name", q: listQuery{SortBy: sortByName}, want: []string{"a", "b", "c"}},
+		{name: "name desc", q: listQuery{SortBy: sortByName, Desc: true}, want: []string{"c", "b", "a"}},
+		{name: "creationTimestamp", q: listQuery{SortBy: sortByCreationTimestamp}, want: []string{"c", "b", "a"}},
+		{name: "readiness", q: listQuery{SortBy: sortByReadiness}, want: []string{"b", "a", "c"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sorted := append([]unstructured.Unstructured{}, items...)
+			sortUnstructured(sorted, tt.q)
+			for i, name := range tt.want {
+				if sorted[i].GetName() != name {
+					t.Fatalf("order = %v, want %v", []string{sorted[0].GetName(), sorted[1].GetName(), sorted[2].GetName()}, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestListAllWorkloadsPage(t *testing.T) {
+	previous := dynamicClient
+	defer func() { dynamicClient = previous }()
+
+	workload := func(apiVersion, kind, name, created string) runtime.Object {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": apiVersion,
+			"kind":       kind,
+			"metadata":   map[string]interface{}{"name": name, "namespace": "default", "creationTimestamp": created},
+		}}
+	}
+	listKinds := map[schema.GroupVersionResource]string{}
+	for _, info := range kruiseWorkloadTypes() {
+		listKinds[info.GVR] = info.Kind + "List"
+	}
+	dynamicClient = dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), listKinds,
+		workload("apps.kruise.io/v1alpha1", "CloneSet", "cs-a", "2024-01-01T00:00:00Z"),
+		workload("apps.kruise.io/v1alpha1", "CloneSet", "cs-b", "2024-01-02T00:00:00Z"),
+		workload("apps.kruise.io/v1alpha1", "CloneSet", "cs-c", "2024-01-03T00:00:00Z"),
+		workload("apps.kruise.io/v1alpha1", "DaemonSet", "ds-a", "2024-01-01T00:00:00Z"),
+	)
+
+	// Sorting by creation time pages by offset, which the fake client supports.
+	q := listQuery{Limit: 2, SortBy: sortByCreationTimestamp}
+	var names []string
+	for pages := 0; pages < 10; pages++ {
+		page, err := listAllWorkloadsPage(context.Background(), "default", q)
+		if err != nil {
+			t.Fatalf("listAllWorkloadsPage() error = %v", err)
+		}
+		// Types are paged in resource order; the page map itself is unordered.
+		keys := make([]string, 0, len(page))
+		for key := range page {
+			if key != "continue" {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		count := 0
+		for _, key := range keys {
+			for _, item := range page[key].([]interface{}) {
+				name, _, _ := unstructured.NestedString(item.(map[string]interface{}), "metadata", "name")
+				names = append(names, name)
+				count++
+			}
+		}
+		if count > 2 {
+			t.Errorf("page has %d items, want at most 2", count)
+		}
+		q.Continue = page["continue"].(string)
+		if q.Continue == "" {
+			break
+		}
+	}
+	if want := []string{"cs-a", "cs-b", "cs-c", "ds-a"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("paged names = %v, want %v", names, want)
+	}
+
+	if _, err := listAllWorkloadsPage(context.Background(), "default", listQuery{Limit: 2, Continue: "not-a-token"}); !errors.Is(err, errInvalidContinue) {
+		t.Errorf("invalid token error = %v, want errInvalidContinue", err)
+	}
+}
@@ -0,0 +1,426 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/openkruise/kruise-dashboard/extensions-backend/pkg/logger"
+	"github.com/openkruise/kruise-dashboard/extensions-backend/pkg/response"
+	"github.com/openkruise/kruise-dashboard/extensions-backend/pkg/timeseries"
+	"go.uber.org/zap"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	metricsv1beta1 "k8s.io/metrics/pkg/apis/metrics/v1beta1"
+)
+
+const (
+	// The sampler lists every pod in the cluster, so it is off unless an interval is configured.
+	defaultMetricsHistoryInterval  = 0
+	defaultMetricsHistoryRetention = 24 * time.Hour
+	defaultMetricsHistoryMaxSeries = 2000
+	metricsHistoryPersistInterval  = 5 * time.Minute
+	metricsHistorySampleTimeout    = 20 * time.Second
+
+	errorCodeMetricsHistoryDisabled = "METRICS_HISTORY_DISABLED"
+
+	clusterSeriesKey = "cluster"
+)
+
+// Values of a history point. Capacity is the allocatable amount for the cluster and nodes and the
+// sum of container requests for workloads.
+const (
+	historyCPUMillis = iota
+	historyMemoryBytes
+	historyCPUCapacity
+	historyMemoryCapacity
+	historyValueCount
+)
+
+// metricsHistory is nil when sampling is disabled.
+var metricsHistory *metricsHistoryRecorder
+
+// metricsHistoryRecorder periodically samples cluster, node and workload usage into a store.
+type metricsHistoryRecorder struct {
+	store     *timeseries.Store
+	interval  time.Duration
+	retention time.Duration
+	file      string
+}
+
+// MetricsSample is one point of a usage history. The percentages are of allocatable for the
+// cluster and nodes and of requests for workloads, and are omitted when that is 0.
+type MetricsSample struct {
+	Timestamp     string   `json:"timestamp"`
+	CPUMillis     float64  `json:"cpuMillis"`
+	MemoryBytes   float64  `json:"memoryBytes"`
+	CPUPercent    *float64 `json:"cpuPercent,omitempty"`
+	MemoryPercent *float64 `json:"memoryPercent,omitempty"`
+}
+
+// MetricsHistory is the usage history of one series, averaged over resolution-sized buckets.
+type MetricsHistory struct {
+	Series            string          `json:"series"`
+	IntervalSeconds   int64           `json:"intervalSeconds"`
+	ResolutionSeconds int64           `json:"resolutionSeconds"`
+	RetentionSeconds  int64           `json:"retentionSeconds"`
+	Samples           []MetricsSample `json:"samples"`
+}
+
+func nodeSeriesKey(name string) string {
+	return "node/" + name
+}
+
+// workloadSeriesKey includes the API group, so that for example native and Advanced StatefulSets
+// of the same name are kept apart.
+func workloadSeriesKey(namespace string, groupKind schema.GroupKind, name string) string {
+	return "workload/" + namespace + "/" + groupKind.String() + "/" + name
+}
+
+func durationFromEnv(name string, fallback time.Duration) (time.Duration, error) {
+	raw := os.Getenv(name)
+	if raw == "" {
+		return fallback, nil
+	}
+	value, err := time.ParseDuration(raw)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("%s must be a non-negative duration such as 30s, got %q", name, raw)
+	}
+	return value, nil
+}
+
+func positiveIntFromEnv(name string, fallback int) (int, error) {
+	raw := os.Getenv(name)
+	if raw == "" {
+		return fallback, nil
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil || value <= 0 {
+		return 0, fmt.Errorf("%s must be a positive integer, got %q", name, raw)
+	}
+	return value, nil
+}
+
+// InitMetricsHistory starts the background sampler configured by METRICS_HISTORY_INTERVAL (unset
+// or 0 disables it), METRICS_HISTORY_RETENTION, METRICS_HISTORY_MAX_SERIES and
+// METRICS_HISTORY_FILE. With a file, the history is restored on start and saved every few minutes.
+// It must be called after InitWorkloadTypes.
+func InitMetricsHistory() error {
+	interval, err := durationFromEnv("METRICS_HISTORY_INTERVAL", defaultMetricsHistoryInterval)
+	if err != nil {
+		return err
+	}
+	retention, err := durationFromEnv("METRICS_HISTORY_RETENTION", defaultMetricsHistoryRetention)
+	if err != nil {
+		return err
+	}
+	maxSeries, err := positiveIntFromEnv("METRICS_HISTORY_MAX_SERIES", defaultMetricsHistoryMaxSeries)
+	if err != nil {
+		return err
+	}
+	if interval == 0 {
+		logger.Log.Info("Metrics history disabled")
+		return nil
+	}
+	if retention < interval {
+		return fmt.Errorf("METRICS_HISTORY_RETENTION (%s) must not be shorter than METRICS_HISTORY_INTERVAL (%s)", retention, interval)
+	}
+
+	recorder := &metricsHistoryRecorder{
+		store:     timeseries.NewStore(int(retention/interval), maxSeries),
+		interval:  interval,
+		retention: retention,
+		file:      os.Getenv("METRICS_HISTORY_FILE"),
+	}
+	if recorder.file != "" {
+		if err := recorder.store.Load(recorder.file, time.Now().Add(-retention)); err != nil {
+			// A corrupt snapshot only costs the history; it is replaced by the next save.
+			logger.Log.Warn("Failed to restore metrics history", zap.String("file", recorder.file), zap.Error(err))
+		}
+	}
+	metricsHistory = recorder
+
+	logger.Log.Info("Metrics history enabled",
+		zap.Duration("interval", interval),
+		zap.Duration("retention", retention),
+		zap.Int("maxSeries", maxSeries),
+		zap.String("file", recorder.file),
+	)
+	go recorder.run()
+	return nil
+}
+
+func (r *metricsHistoryRecorder) run() {
+	sampleTicker := time.NewTicker(r.interval)
+	defer sampleTicker.Stop()
+	persistTicker := time.NewTicker(metricsHistoryPersistInterval)
+	defer persistTicker.Stop()
+
+	r.sample()
+	for {
+		select {
+		case <-sampleTicker.C:
+			r.sample()
+		case <-persistTicker.C:
+			if r.file == "" {
+				continue
+			}
+			if err := r.store.Save(r.file); err != nil {
+				logger.Log.Warn("Failed to save metrics history", zap.String("file", r.file), zap.Error(err))
+			}
+		}
+	}
+}
+
+// sample records one point for the cluster, every node and every workload with running pods.
+// Without metrics-server nothing is recorded. The cluster and nodes are recorded first, so only
+// workloads are dropped once the store reaches its series limit.
+func (r *metricsHistoryRecorder) sample() {
+	ctx, cancel := context.WithTimeout(context.Background(), metricsHistorySampleTimeout)
+	defer cancel()
+	now := time.Now()
+	defer r.store.Prune(now.Add(-r.retention))
+	dropped := 0
+	defer func() {
+		if dropped > 0 {
+			logger.Log.Warn("Metrics history series limit reached, new series dropped", zap.Int("dropped", dropped))
+		}
+	}()
+	add := func(key string, values []float64) {
+		if !r.store.Add(key, timeseries.Point{Time: now, Values: values}) {
+			dropped++
+		}
+	}
+
+	metricsClient, err := newMetricsClient()
+	if err != nil {
+		logger.Log.Warn("Metrics client not available for history sampling", zap.Error(err))
+		return
+	}
+	nodes, err := GetK8sClient().CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		logger.Log.Warn("Failed to list nodes for metrics history", zap.Error(err))
+		return
+	}
+	nodeMetrics, err := metricsClient.MetricsV1beta1().NodeMetricses().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		logger.Log.Warn("Failed to list node metrics for history (is metrics-server installed?)", zap.Error(err))
+		return
+	}
+	for key, values := range nodeHistoryValues(nodes.Items, nodeMetrics.Items) {
+		add(key, values)
+	}
+
+	pods, err := GetK8sClient().CoreV1().Pods("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		logger.Log.Warn("Failed to list pods for metrics history", zap.Error(err))
+		return
+	}
+	replicaSets, err := GetK8sClient().AppsV1().ReplicaSets("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		logger.Log.Warn("Failed to list replicasets for metrics history", zap.Error(err))
+		return
+	}
+	podMetrics, err := metricsClient.MetricsV1beta1().PodMetricses("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		logger.Log.Warn("Failed to list pod metrics for history", zap.Error(err))
+		return
+	}
+	for key, values := range workloadHistoryValues(pods.Items, replicaSets.Items, podMetrics.Items) {
+		add(key, values)
+	}
+}
+
+// nodeHistoryValues returns the point values of every node with metrics and of the cluster, which
+// sums those nodes.
+func nodeHistoryValues(nodes []corev1.Node, nodeMetrics []metricsv1beta1.NodeMetrics) map[string][]float64 {
+	allocatable := buildNodeAllocatableMap(&corev1.NodeList{Items: nodes})
+	values := map[string][]float64{}
+	cluster := make([]float64, historyValueCount)
+	for i := range nodeMetrics {
+		metrics := &nodeMetrics[i]
+		alloc, ok := allocatable[metrics.Name]
+		if !ok {
+			continue
+		}
+		node := make([]float64, historyValueCount)
+		node[historyCPUMillis] = float64(metrics.Usage.Cpu().MilliValue())
+		node[historyMemoryBytes] = float64(metrics.Usage.Memory().Value())
+		node[historyCPUCapacity] = float64(alloc.cpuMilli)
+		node[historyMemoryCapacity] = float64(alloc.memoryBytes)
+		values[nodeSeriesKey(metrics.Name)] = node
+		for j := range cluster {
+			cluster[j] += node[j]
+		}
+	}
+	values[clusterSeriesKey] = cluster
+	return values
+}
+
+// podWorkload returns the registered workload controlling a pod, looking through the ReplicaSets
+// of Deployments.
+func podWorkload(pod *corev1.Pod, replicaSetOwners map[string]*metav1.OwnerReference, kinds map[schema.GroupKind]bool) (schema.GroupKind, string, bool) {
+	owner := metav1.GetControllerOf(pod)
+	if owner == nil {
+		return schema.GroupKind{}, "", false
+	}
+	if owner.Kind == "ReplicaSet" {
+		rsOwner, ok := replicaSetOwners[pod.Namespace+"/"+owner.Name]
+		if !ok || rsOwner == nil {
+			return schema.GroupKind{}, "", false
+		}
+		owner = rsOwner
+	}
+	gv, err := schema.ParseGroupVersion(owner.APIVersion)
+	groupKind := schema.GroupKind{Group: gv.Group, Kind: owner.Kind}
+	if err != nil || !kinds[groupKind] {
+		return schema.GroupKind{}, "", false
+	}
+	return groupKind, owner.Name, true
+}
+
+// workloadHistoryValues sums pod usage and the requests of the measured containers by workload.
+func workloadHistoryValues(pods []corev1.Pod, replicaSets []appsv1.ReplicaSet, podMetrics []metricsv1beta1.PodMetrics) map[string][]float64 {
+	kinds := map[schema.GroupKind]bool{}
+	for _, info := range workloadTypeRegistry {
+		kinds[schema.GroupKind{Group: info.GVR.Group, Kind: info.Kind}] = true
+	}
+	replicaSetOwners := make(map[string]*metav1.OwnerReference, len(replicaSets))
+	for i := range replicaSets {
+		replicaSetOwners[replicaSets[i].Namespace+"/"+replicaSets[i].Name] = metav1.GetControllerOf(&replicaSets[i])
+	}
+	metricsByPod := make(map[string]*metricsv1beta1.PodMetrics, len(podMetrics))
+	for i := range podMetrics {
+		metricsByPod[podMetrics[i].Namespace+"/"+podMetrics[i].Name] = &podMetrics[i]
+	}
+
+	values := map[string][]float64{}
+	for i := range pods {
+		pod := &pods[i]
+		metrics, ok := metricsByPod[pod.Namespace+"/"+pod.Name]
+		if !ok {
+			continue
+		}
+		groupKind, name, ok := podWorkload(pod, replicaSetOwners, kinds)
+		if !ok {
+			continue
+		}
+		key := workloadSeriesKey(pod.Namespace, groupKind, name)
+		if values[key] == nil {
+			values[key] = make([]float64, historyValueCount)
+		}
+		requests := map[string]corev1.ResourceList{}
+		for _, container := range pod.Spec.Containers {
+			requests[container.Name] = container.Resources.Requests
+		}
+		for _, container := range metrics.Containers {
+			containerRequests := requests[container.Name]
+			values[key][historyCPUMillis] += float64(container.Usage.Cpu().MilliValue())
+			values[key][historyMemoryBytes] += float64(container.Usage.Memory().Value())
+			values[key][historyCPUCapacity] += float64(containerRequests.Cpu().MilliValue())
+			values[key][historyMemoryCapacity] += float64(containerRequests.Memory().Value())
+		}
+	}
+	return values
+}
+
+func historySample(point timeseries.Point) MetricsSample {
+	value := func(i int) float64 {
+		if i < len(point.Values) {
+			return point.Values[i]
+		}
+		return 0
+	}
+	ratio := func(used, capacity float64) *float64 {
+		if capacity == 0 {
+			return nil
+		}
+		pct := 100 * used / capacity
+		return &pct
+	}
+	return MetricsSample{
+		Timestamp:     point.Time.UTC().Format(time.RFC3339),
+		CPUMillis:     value(historyCPUMillis),
+		MemoryBytes:   value(historyMemoryBytes),
+		CPUPercent:    ratio(value(historyCPUMillis), value(historyCPUCapacity)),
+		MemoryPercent: ratio(value(historyMemoryBytes), value(historyMemoryCapacity)),
+	}
+}
+
+// bindHistoryQuery parses window (how far back, default and maximum the retention) and
+// resolution (bucket size, default the sampling interval).
+func bindHistoryQuery(c *gin.Context, recorder *metricsHistoryRecorder) (time.Duration, time.Duration, bool) {
+	window, resolution := recorder.retention, recorder.interval
+	if raw := c.Query("window"); raw != "" {
+		value, err := time.ParseDuration(raw)
+		if err != nil || value <= 0 || value > recorder.retention {
+			response.BadRequest(c, fmt.Sprintf("window must be a duration between 0 and the retention of %s", recorder.retention))
+			return 0, 0, false
+		}
+		window = value
+	}
+	if raw := c.Query("resolution"); raw != "" {
+		value, err := time.ParseDuration(raw)
+		if err != nil || value < recorder.interval || value > window {
+			response.BadRequest(c, fmt.Sprintf("resolution must be a duration between the sampling interval of %s and the window", recorder.interval))
+			return 0, 0, false
+		}
+		resolution = value
+	}
+	return window, resolution, true
+}
+
+// respondMetricsHistory responds with the history of a series. A series that has not been sampled
+// yet has no samples.
+func respondMetricsHistory(c *gin.Context, key string) {
+	recorder := metricsHistory
+	if recorder == nil {
+		response.Error(c, http.StatusServiceUnavailable, "Metrics history is disabled, set METRICS_HISTORY_INTERVAL to enable it", nil, errorCodeMetricsHistoryDisabled)
+		return
+	}
+	window, resolution, ok := bindHistoryQuery(c, recorder)
+	if !ok {
+		return
+	}
+
+	points := recorder.store.Query(key, time.Now().Add(-window), resolution)
+	samples := make([]MetricsSample, 0, len(points))
+	for _, point := range points {
+		samples = append(samples, historySample(point))
+	}
+	response.Success(c, MetricsHistory{
+		Series:            key,
+		IntervalSeconds:   int64(recorder.interval / time.Second),
+		ResolutionSeconds: int64(resolution / time.Second),
+		RetentionSeconds:  int64(recorder.retention / time.Second),
+		Samples:           samples,
+	})
+}
+
+// GetClusterMetricsHistory returns the CPU and memory usage history of the cluster.
+func GetClusterMetricsHistory(c *gin.Context) {
+	respondMetricsHistory(c, clusterSeriesKey)
+}
+
+// GetNodeMetricsHistory returns the CPU and memory usage history of a node.
+func GetNodeMetricsHistory(c *gin.Context) {
+	respondMetricsHistory(c, nodeSeriesKey(c.Param("name")))
+}
+
+// GetWorkloadMetricsHistory returns the CPU and memory usage history of a workload's pods. The
+// history outlives the workload until it expires, so the workload itself is not looked up.
+func GetWorkloadMetricsHistory(c *gin.Context) {
+	info, err := ResolveWorkloadType(c.Param("type"))
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+	groupKind := schema.GroupKind{Group: info.GVR.Group, Kind: info.Kind}
+	respondMetricsHistory(c, workloadSeriesKey(c.Param("namespace"), groupKind, c.Param("name")))
+}
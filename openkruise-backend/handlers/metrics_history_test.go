@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/openkruise/kruise-dashboard/extensions-backend/pkg/timeseries"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	metricsv1beta1 "k8s.io/metrics/pkg/apis/metrics/v1beta1"
+)
+
+func usageList(cpu, memory string) corev1.ResourceList {
+	return corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(cpu), corev1.ResourceMemory: resource.MustParse(memory)}
+}
+
+func controllerRef(apiVersion, kind, name string) []metav1.OwnerReference {
+	controller := true
+	return []metav1.OwnerReference{{APIVersion: apiVersion, Kind: kind, Name: name, Controller: &controller}}
+}
+
+func TestNodeHistoryValues(t *testing.T) {
+	nodes := []corev1.Node{
+		{ObjectMeta: metav1.ObjectMeta{Name: "a"}, Status: corev1.NodeStatus{Allocatable: usageList("4", "8Gi")}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "b"}, Status: corev1.NodeStatus{Allocatable: usageList("2", "4Gi")}},
+	}
+	metrics := []metricsv1beta1.NodeMetrics{
+		{ObjectMeta: metav1.ObjectMeta{Name: "a"}, Usage: usageList("1", "2Gi")},
+		{ObjectMeta: metav1.ObjectMeta{Name: "b"}, Usage: usageList("500m", "1Gi")},
+		{ObjectMeta: metav1.ObjectMeta{Name: "gone"}, Usage: usageList("1", "1Gi")},
+	}
+	values := nodeHistoryValues(nodes, metrics)
+	gi := float64(1 << 30)
+	want := map[string][]float64{
+		"node/a":         {1000, 2 * gi, 4000, 8 * gi},
+		"node/b":         {500, gi, 2000, 4 * gi},
+		clusterSeriesKey: {1500, 3 * gi, 6000, 12 * gi},
+	}
+	if !reflect.DeepEqual(values, want) {
+		t.Errorf("nodeHistoryValues() = %v, want %v", values, want)
+	}
+}
+
+func TestWorkloadHistoryValues(t *testing.T) {
+	// Register native StatefulSets next to the built-in Advanced StatefulSet.
+	workloadTypeRegistry["native-statefulset"] = WorkloadTypeInfo{
+		GVR:  schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "statefulsets"},
+		Kind: "StatefulSet",
+	}
+	defer delete(workloadTypeRegistry, "native-statefulset")
+
+	pod := func(name string, owners []metav1.OwnerReference) corev1.Pod {
+		return corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name, OwnerReferences: owners},
+			Spec: corev1.PodSpec{Containers: []corev1.Container{
+				{Name: "app", Resources: corev1.ResourceRequirements{Requests: usageList("100m", "128Mi")}},
+			}},
+		}
+	}
+	pods := []corev1.Pod{
+		pod("web-abc-1", controllerRef("apps/v1", "ReplicaSet", "web-abc")),
+		pod("web-abc-2", controllerRef("apps/v1", "ReplicaSet", "web-abc")),
+		pod("cs-1", controllerRef("apps.kruise.io/v1alpha1", "CloneSet", "cs")),
+		pod("bare", nil),
+		pod("unmeasured", controllerRef("apps.kruise.io/v1alpha1", "CloneSet", "cs")),
+		pod("db-0", controllerRef("apps/v1", "StatefulSet", "db")),
+		pod("db-1", controllerRef("apps.kruise.io/v1beta1", "StatefulSet", "db")),
+	}
+	replicaSets := []appsv1.ReplicaSet{
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "web-abc", OwnerReferences: controllerRef("apps/v1", "Deployment", "web")}},
+	}
+	podMetrics := []metricsv1beta1.PodMetrics{}
+	for _, name := range []string{"web-abc-1", "web-abc-2", "cs-1", "bare", "db-0", "db-1"} {
+		podMetrics = append(podMetrics, metricsv1beta1.PodMetrics{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name},
+			Containers: []metricsv1beta1.ContainerMetrics{{Name: "app", Usage: usageList("50m", "64Mi")}},
+		})
+	}
+
+	values := workloadHistoryValues(pods, replicaSets, podMetrics)
+	mi := float64(1 << 20)
+	want := map[string][]float64{
+		"workload/default/Deployment.apps/web":           {100, 128 * mi, 200, 256 * mi},
+		"workload/default/CloneSet.apps.kruise.io/cs":    {50, 64 * mi, 100, 128 * mi},
+		"workload/default/StatefulSet.apps/db":           {50, 64 * mi, 100, 128 * mi},
+		"workload/default/StatefulSet.apps.kruise.io/db": {50, 64 * mi, 100, 128 * mi},
+	}
+	if !reflect.DeepEqual(values, want) {
+		t.Errorf("workloadHistoryValues() = %v, want %v", values, want)
+	}
+}
+
+func TestHistorySample(t *testing.T) {
+	at := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	sample := historySample(timeseries.Point{Time: at, Values: []float64{500, 1024, 2000, 0}})
+	if sample.Timestamp != "2024-01-01T00:00:00Z" || sample.CPUMillis != 500 || sample.MemoryBytes != 1024 {
+		t.Errorf("sample = %+v", sample)
+	}
+	if sample.CPUPercent == nil || *sample.CPUPercent != 25 || sample.MemoryPercent != nil {
+		t.Errorf("percentages = %v / %v, want 25 / omitted", sample.CPUPercent, sample.MemoryPercent)
+	}
+}
+
+func TestRespondMetricsHistory(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	previous := metricsHistory
+	defer func() { metricsHistory = previous }()
+
+	serve := func(query string) *httptest.ResponseRecorder {
+		r := gin.New()
+		r.GET("/cluster/metrics/history", GetClusterMetricsHistory)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/cluster/metrics/history"+query, nil))
+		return w
+	}
+
+	metricsHistory = nil
+	if w := serve(""); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("disabled: status = %d, want 503", w.Code)
+	}
+
+	metricsHistory = &metricsHistoryRecorder{store: timeseries.NewStore(120, 0), interval: 30 * time.Second, retention: time.Hour}
+	now := time.Now()
+	for i := 0; i < 4; i++ {
+		metricsHistory.store.Add(clusterSeriesKey, timeseries.Point{Time: now.Add(-time.Duration(i) * 30 * time.Second), Values: []float64{100, 0, 1000, 0}})
+	}
+
+	for _, query := range []string{"?window=2h", "?window=bogus", "?resolution=10s", "?window=5m&resolution=10m"} {
+		if w := serve(query); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", query, w.Code)
+		}
+	}
+
+	w := serve("?window=10m")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", w.Code, w.Body)
+	}
+	var body struct {
+		Data MetricsHistory `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Data.Series != clusterSeriesKey || body.Data.ResolutionSeconds != 30 || body.Data.RetentionSeconds != 3600 {
+		t.Errorf("history = %+v", body.Data)
+	}
+	if len(body.Data.Samples) == 0 || body.Data.Samples[0].CPUPercent == nil || *body.Data.Samples[0].CPUPercent != 10 {
+		t.Errorf("samples = %+v", body.Data.Samples)
+	}
+}
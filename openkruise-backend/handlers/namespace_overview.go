@@ -0,0 +1,344 @@
+package handlers
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/openkruise/kruise-dashboard/extensions-backend/pkg/logger"
+	"github.com/openkruise/kruise-dashboard/extensions-backend/pkg/response"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+)
+
+const (
+	overviewTimeout           = 10 * time.Second
+	overviewWarningEventLimit = 10
+
+	overviewSectionRollouts       = "rollouts"
+	overviewSectionPods           = "pods"
+	overviewSectionEvents         = "events"
+	overviewSectionResourceQuotas = "resourceQuotas"
+	overviewSectionLimitRanges    = "limitRanges"
+)
+
+// WorkloadTypeCount counts the workloads of one type by health status.
+type WorkloadTypeCount struct {
+	Type     string         `json:"type"`
+	Kind     string         `json:"kind"`
+	Total    int            `json:"total"`
+	ByHealth map[string]int `json:"byHealth"`
+}
+
+// RolloutCounts counts rollouts by status.phase; paused rollouts are also counted in Paused.
+type RolloutCounts struct {
+	Total   int            `json:"total"`
+	ByPhase map[string]int `json:"byPhase"`
+	Paused  int            `json:"paused"`
+}
+
+// PodCounts counts pods by phase and readiness.
+type PodCounts struct {
+	Total    int            `json:"total"`
+	ByPhase  map[string]int `json:"byPhase"`
+	Ready    int            `json:"ready"`
+	NotReady int            `json:"notReady"`
+	Restarts int64          `json:"restarts"`
+}
+
+// QuotaResourceUsage is the usage of one resource limited by a ResourceQuota. Ratio is used/hard.
+type QuotaResourceUsage struct {
+	Resource string  `json:"resource"`
+	Hard     string  `json:"hard"`
+	Used     string  `json:"used"`
+	Ratio    float64 `json:"ratio"`
+}
+
+type ResourceQuotaUsage struct {
+	Name      string               `json:"name"`
+	Resources []QuotaResourceUsage `json:"resources"`
+}
+
+// LimitRangeLimit is the constraint of a LimitRange on one resource of one object type.
+type LimitRangeLimit struct {
+	Type                 string `json:"type" openapi:"enum=Container|Pod|PersistentVolumeClaim"`
+	Resource             string `json:"resource"`
+	Min                  string `json:"min,omitempty"`
+	Max                  string `json:"max,omitempty"`
+	Default              string `json:"default,omitempty"`
+	DefaultRequest       string `json:"defaultRequest,omitempty"`
+	MaxLimitRequestRatio string `json:"maxLimitRequestRatio,omitempty"`
+}
+
+type LimitRangeSummary struct {
+	Name   string            `json:"name"`
+	Limits []LimitRangeLimit `json:"limits"`
+}
+
+// NamespaceOverview aggregates the state of a namespace for its landing page. FailedSections lists
+// the sections and workload types that could not be read, for example for lack of permission.
+type NamespaceOverview struct {
+	Namespace      string               `json:"namespace"`
+	Workloads      []WorkloadTypeCount  `json:"workloads"`
+	Rollouts       RolloutCounts        `json:"rollouts"`
+	Pods           PodCounts            `json:"pods"`
+	WarningEvents  []Event              `json:"warningEvents"`
+	ResourceQuotas []ResourceQuotaUsage `json:"resourceQuotas"`
+	LimitRanges    []LimitRangeSummary  `json:"limitRanges"`
+	FailedSections []string             `json:"failedSections"`
+}
+
+func countWorkloads(workloadType string, info WorkloadTypeInfo, items []unstructured.Unstructured) WorkloadTypeCount {
+	count := WorkloadTypeCount{Type: workloadType, Kind: info.Kind, Total: len(items), ByHealth: map[string]int{}}
+	for i := range items {
+		count.ByHealth[evaluateWorkloadHealth(info.Kind, items[i].Object).Status]++
+	}
+	return count
+}
+
+func countRollouts(items []unstructured.Unstructured) RolloutCounts {
+	counts := RolloutCounts{Total: len(items), ByPhase: map[string]int{}}
+	for i := range items {
+		phase, _, _ := unstructured.NestedString(items[i].Object, "status", "phase")
+		if phase == "" {
+			phase = healthUnknown
+		}
+		counts.ByPhase[phase]++
+		if rolloutPaused(items[i].Object) {
+			counts.Paused++
+		}
+	}
+	return counts
+}
+
+func podReady(pod *corev1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+func countPods(pods []corev1.Pod) PodCounts {
+	counts := PodCounts{Total: len(pods), ByPhase: map[string]int{}}
+	for i := range pods {
+		pod := &pods[i]
+		counts.ByPhase[string(pod.Status.Phase)]++
+		// Completed pods are not expected to be ready.
+		if pod.Status.Phase != corev1.PodSucceeded {
+			if podReady(pod) {
+				counts.Ready++
+			} else {
+				counts.NotReady++
+			}
+		}
+		for _, status := range pod.Status.ContainerStatuses {
+			counts.Restarts += int64(status.RestartCount)
+		}
+	}
+	return counts
+}
+
+func quotaRatio(used, hard resource.Quantity) float64 {
+	if hard.IsZero() {
+		return 0
+	}
+	return float64(used.MilliValue()) / float64(hard.MilliValue())
+}
+
+func summarizeResourceQuotas(quotas []corev1.ResourceQuota) []ResourceQuotaUsage {
+	usages := make([]ResourceQuotaUsage, 0, len(quotas))
+	for _, quota := range quotas {
+		usage := ResourceQuotaUsage{Name: quota.Name, Resources: []QuotaResourceUsage{}}
+		for name, hard := range quota.Status.Hard {
+			used := quota.Status.Used[name]
+			usage.Resources = append(usage.Resources, QuotaResourceUsage{
+				Resource: string(name),
+				Hard:     hard.String(),
+				Used:     used.String(),
+				Ratio:    quotaRatio(used, hard),
+			})
+		}
+		sort.Slice(usage.Resources, func(i, j int) bool { return usage.Resources[i].Resource < usage.Resources[j].Resource })
+		usages = append(usages, usage)
+	}
+	return usages
+}
+
+func summarizeLimitRanges(limitRanges []corev1.LimitRange) []LimitRangeSummary {
+	summaries := make([]LimitRangeSummary, 0, len(limitRanges))
+	quantity := func(list corev1.ResourceList, name corev1.ResourceName) string {
+		if value, ok := list[name]; ok {
+			return value.String()
+		}
+		return ""
+	}
+	for _, limitRange := range limitRanges {
+		summary := LimitRangeSummary{Name: limitRange.Name, Limits: []LimitRangeLimit{}}
+		for _, item := range limitRange.Spec.Limits {
+			names := map[corev1.ResourceName]bool{}
+			for _, list := range []corev1.ResourceList{item.Min, item.Max, item.Default, item.DefaultRequest, item.MaxLimitRequestRatio} {
+				for name := range list {
+					names[name] = true
+				}
+			}
+			sorted := make([]string, 0, len(names))
+			for name := range names {
+				sorted = append(sorted, string(name))
+			}
+			sort.Strings(sorted)
+			for _, name := range sorted {
+				resourceName := corev1.ResourceName(name)
+				summary.Limits = append(summary.Limits, LimitRangeLimit{
+					Type:                 string(item.Type),
+					Resource:             name,
+					Min:                  quantity(item.Min, resourceName),
+					Max:                  quantity(item.Max, resourceName),
+					Default:              quantity(item.Default, resourceName),
+					DefaultRequest:       quantity(item.DefaultRequest, resourceName),
+					MaxLimitRequestRatio: quantity(item.MaxLimitRequestRatio, resourceName),
+				})
+			}
+		}
+		summaries = append(summaries, summary)
+	}
+	return summaries
+}
+
+// GetNamespaceOverview aggregates workloads, rollouts, pods, warning events, quotas and limit
+// ranges of a namespace in one request. Sections are read concurrently; a section that fails is
+// left empty and listed in failedSections.
+func GetNamespaceOverview(c *gin.Context) {
+	namespace := c.Param("namespace")
+
+	if _, err := GetK8sClient().CoreV1().Namespaces().Get(context.TODO(), namespace, metav1.GetOptions{}); err != nil {
+		if apierrors.IsNotFound(err) {
+			response.NotFound(c, "namespace")
+			return
+		}
+		response.InternalError(c, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), overviewTimeout)
+	defer cancel()
+
+	overview := NamespaceOverview{
+		Namespace:      namespace,
+		Workloads:      []WorkloadTypeCount{},
+		Rollouts:       RolloutCounts{ByPhase: map[string]int{}},
+		Pods:           PodCounts{ByPhase: map[string]int{}},
+		WarningEvents:  []Event{},
+		ResourceQuotas: []ResourceQuotaUsage{},
+		LimitRanges:    []LimitRangeSummary{},
+		FailedSections: []string{},
+	}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	run := func(section string, read func() error) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := read(); err != nil {
+				logger.Log.Warn("Failed to read namespace overview section",
+					zap.String("namespace", namespace),
+					zap.String("section", section),
+					zap.Error(err),
+				)
+				mu.Lock()
+				overview.FailedSections = append(overview.FailedSections, section)
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for workloadType, info := range workloadTypeRegistry {
+		run(workloadType, func() error {
+			list, err := GetDynamicClient().Resource(info.GVR).Namespace(namespace).List(ctx, metav1.ListOptions{})
+			if err != nil {
+				return err
+			}
+			count := countWorkloads(workloadType, info, list.Items)
+			mu.Lock()
+			overview.Workloads = append(overview.Workloads, count)
+			mu.Unlock()
+			return nil
+		})
+	}
+	run(overviewSectionRollouts, func() error {
+		list, err := GetDynamicClient().Resource(rolloutGVR).Namespace(namespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return err
+		}
+		counts := countRollouts(list.Items)
+		mu.Lock()
+		overview.Rollouts = counts
+		mu.Unlock()
+		return nil
+	})
+	run(overviewSectionPods, func() error {
+		list, err := GetK8sClient().CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return err
+		}
+		counts := countPods(list.Items)
+		mu.Lock()
+		overview.Pods = counts
+		mu.Unlock()
+		return nil
+	})
+	run(overviewSectionEvents, func() error {
+		selector := fields.OneTermEqualSelector("type", corev1.EventTypeWarning).String()
+		list, err := GetK8sClient().CoreV1().Events(namespace).List(ctx, metav1.ListOptions{FieldSelector: selector})
+		if err != nil {
+			return err
+		}
+		events := make([]Event, 0, len(list.Items))
+		for _, event := range list.Items {
+			events = append(events, toEvent(event))
+		}
+		sortEventsNewestFirst(events)
+		if len(events) > overviewWarningEventLimit {
+			events = events[:overviewWarningEventLimit]
+		}
+		mu.Lock()
+		overview.WarningEvents = events
+		mu.Unlock()
+		return nil
+	})
+	run(overviewSectionResourceQuotas, func() error {
+		list, err := GetK8sClient().CoreV1().ResourceQuotas(namespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return err
+		}
+		quotas := summarizeResourceQuotas(list.Items)
+		mu.Lock()
+		overview.ResourceQuotas = quotas
+		mu.Unlock()
+		return nil
+	})
+	run(overviewSectionLimitRanges, func() error {
+		list, err := GetK8sClient().CoreV1().LimitRanges(namespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return err
+		}
+		limitRanges := summarizeLimitRanges(list.Items)
+		mu.Lock()
+		overview.LimitRanges = limitRanges
+		mu.Unlock()
+		return nil
+	})
+	wg.Wait()
+
+	sort.Slice(overview.Workloads, func(i, j int) bool { return overview.Workloads[i].Type < overview.Workloads[j].Type })
+	sort.Strings(overview.FailedSections)
+	response.Success(c, overview)
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestCountPods(t *testing.T) {
+	ready := []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}}
+	pods := []corev1.Pod{
+		{Status: corev1.PodStatus{Phase: corev1.PodRunning, Conditions: ready, ContainerStatuses: []corev1.ContainerStatus{{RestartCount: 2}}}},
+		{Status: corev1.PodStatus{Phase: corev1.PodRunning}},
+		{Status: corev1.PodStatus{Phase: corev1.PodPending}},
+		{Status: corev1.PodStatus{Phase: corev1.PodSucceeded}},
+	}
+	counts := countPods(pods)
+	if counts.Total != 4 || counts.Ready != 1 || counts.NotReady != 2 || counts.Restarts != 2 {
+		t.Errorf("counts = %+v", counts)
+	}
+	if counts.ByPhase["Running"] != 2 || counts.ByPhase["Pending"] != 1 || counts.ByPhase["Succeeded"] != 1 {
+		t.Errorf("byPhase = %v", counts.ByPhase)
+	}
+}
+
+func TestCountRollouts(t *testing.T) {
+	items := []unstructured.Unstructured{
+		{Object: map[string]interface{}{"status": map[string]interface{}{"phase": "Healthy"}}},
+		{Object: map[string]interface{}{"spec": map[string]interface{}{"paused": true}, "status": map[string]interface{}{"phase": "Progressing"}}},
+		{Object: map[string]interface{}{}},
+	}
+	counts := countRollouts(items)
+	if counts.Total != 3 || counts.Paused != 1 || counts.ByPhase["Healthy"] != 1 || counts.ByPhase["Progressing"] != 1 || counts.ByPhase[healthUnknown] != 1 {
+		t.Errorf("counts = %+v", counts)
+	}
+}
+
+func TestSummarizeResourceQuotas(t *testing.T) {
+	quotas := []corev1.ResourceQuota{{
+		ObjectMeta: metav1.ObjectMeta{Name: "compute"},
+		Status: corev1.ResourceQuotaStatus{
+			Hard: corev1.ResourceList{corev1.ResourceRequestsCPU: resource.MustParse("4"), corev1.ResourcePods: resource.MustParse("10")},
+			Used: corev1.ResourceList{corev1.ResourceRequestsCPU: resource.MustParse("1500m")},
+		},
+	}}
+	usages := summarizeResourceQuotas(quotas)
+	if len(usages) != 1 || len(usages[0].Resources) != 2 {
+		t.Fatalf("usages = %+v", usages)
+	}
+	pods, cpu := usages[0].Resources[0], usages[0].Resources[1]
+	if pods.Resource != "pods" || pods.Used != "0" || pods.Ratio != 0 {
+		t.Errorf("pods = %+v", pods)
+	}
+	if cpu.Resource != "requests.cpu" || cpu.Used != "1500m" || cpu.Ratio != 0.375 {
+		t.Errorf("cpu = %+v", cpu)
+	}
+}
+
+func TestSummarizeLimitRanges(t *testing.T) {
+	limitRanges := []corev1.LimitRange{{
+		ObjectMeta: metav1.ObjectMeta{Name: "defaults"},
+		Spec: corev1.LimitRangeSpec{Limits: []corev1.LimitRangeItem{{
+			Type:           corev1.LimitTypeContainer,
+			Max:            corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2")},
+			Default:        corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("500m"), corev1.ResourceMemory: resource.MustParse("256Mi")},
+			DefaultRequest: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("128Mi")},
+		}}},
+	}}
+	summaries := summarizeLimitRanges(limitRanges)
+	if len(summaries) != 1 || len(summaries[0].Limits) != 2 {
+		t.Fatalf("summaries = %+v", summaries)
+	}
+	cpu, memory := summaries[0].Limits[0], summaries[0].Limits[1]
+	if cpu.Resource != "cpu" || cpu.Max != "2" || cpu.Default != "500m" || cpu.DefaultRequest != "" {
+		t.Errorf("cpu = %+v", cpu)
+	}
+	if memory.Resource != "memory" || memory.Type != "Container" || memory.DefaultRequest != "128Mi" {
+		t.Errorf("memory = %+v", memory)
+	}
+}
@@ -0,0 +1,292 @@
+package handlers
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/openkruise/kruise-dashboard/extensions-backend/pkg/openapi"
+	"github.com/openkruise/kruise-dashboard/extensions-backend/pkg/response"
+)
+
+var (
+	limitParam    = openapi.Parameter{Name: "limit", In: "query", Description: "Page size, 1-500", Schema: &openapi.Schema{Type: "integer", Minimum: floatPtr(1), Maximum: floatPtr(maxListLimit)}}
+	continueParam = openapi.Parameter{Name: "continue", In: "query", Description: "Token of the next page", Schema: &openapi.Schema{Type: "string"}}
+	orderParam    = openapi.Parameter{Name: "order", In: "query", Schema: &openapi.Schema{Type: "string", Enum: []string{"asc", "desc"}}}
+	viewParam     = openapi.Parameter{Name: "view", In: "query", Schema: &openapi.Schema{Type: "string", Enum: []string{listViewFull, listViewSummary}}}
+
+	listFilterParams = []openapi.Parameter{
+		{Name: "labelSelector", In: "query", Schema: &openapi.Schema{Type: "string"}},
+		{Name: "phase", In: "query", Schema: &openapi.Schema{Type: "string"}},
+		{Name: "image", In: "query", Description: "Image substring", Schema: &openapi.Schema{Type: "string"}},
+		{Name: "team", In: "query", Description: "Owner team annotation value", Schema: &openapi.Schema{Type: "string"}},
+	}
+	workloadTypeParam = openapi.Parameter{Name: "type", In: "query", Description: "Comma-separated workload types", Schema: &openapi.Schema{Type: "string"}}
+
+	workloadListParams = []openapi.Parameter{limitParam, continueParam, sortByParam(sortByName, sortByCreationTimestamp, sortByReadiness), orderParam, viewParam}
+	rolloutListParams  = []openapi.Parameter{limitParam, continueParam, sortByParam(sortByName, sortByCreationTimestamp), orderParam, viewParam}
+
+	metricsHistoryParams = []openapi.Parameter{
+		{Name: "window", In: "query", Description: "How far back to return, e.g. 6h; defaults to the retention", Schema: &openapi.Schema{Type: "string"}},
+		{Name: "resolution", In: "query", Description: "Bucket size samples are averaged over, e.g. 5m; defaults to the sampling interval", Schema: &openapi.Schema{Type: "string"}},
+	}
+
+	revisionDiffParams = []openapi.Parameter{
+		{Name: "from", In: "query", Description: "Revision name, pod template hash or number; defaults to the stable revision", Schema: &openapi.Schema{Type: "string"}},
+		{Name: "to", In: "query", Description: "Revision name, pod template hash or number; defaults to the updated revision", Schema: &openapi.Schema{Type: "string"}},
+	}
+)
+
+func sortByParam(keys ...string) openapi.Parameter {
+	return openapi.Parameter{Name: "sortBy", In: "query", Schema: &openapi.Schema{Type: "string", Enum: keys}}
+}
+
+func floatPtr(v float64) *float64 {
+	return &v
+}
+
+func queryParams(groups ...[]openapi.Parameter) []openapi.Parameter {
+	params := []openapi.Parameter{}
+	for _, group := range groups {
+		params = append(params, group...)
+	}
+	return params
+}
+
+// apiOperations describes routes beyond the route table: summaries, query parameters and body
+// types. Request types listed here are enforced by ValidateRequestBodies.
+var apiOperations = map[string]openapi.Operation{
+	openapi.Key(http.MethodGet, "/api/v1/cluster/metrics"): {
+		Summary:  "Get cluster resource usage and node and pod counts",
+		Response: ClusterMetrics{},
+	},
+	openapi.Key(http.MethodGet, "/api/v1/cluster/metrics/history"): {
+		Summary:  "Get the CPU and memory usage history of the cluster",
+		Query:    metricsHistoryParams,
+		Response: MetricsHistory{},
+	},
+	openapi.Key(http.MethodGet, "/api/v1/nodes/:name/metrics/history"): {
+		Summary:  "Get the CPU and memory usage history of a node",
+		Query:    metricsHistoryParams,
+		Response: MetricsHistory{},
+	},
+	openapi.Key(http.MethodGet, "/api/v1/workload/:namespace/:type/:name/metrics/history"): {
+		Summary:  "Get the CPU and memory usage history of a workload",
+		Query:    metricsHistoryParams,
+		Response: MetricsHistory{},
+	},
+	openapi.Key(http.MethodGet, "/api/v1/namespaces/:namespace/overview"): {
+		Summary:  "Aggregate workloads, rollouts, pods, warning events, quotas and limit ranges of a namespace",
+		Response: NamespaceOverview{},
+	},
+	openapi.Key(http.MethodGet, "/api/v1/workloads"): {
+		Summary: "List workloads across all namespaces",
+		Query:   queryParams([]openapi.Parameter{workloadTypeParam}, listFilterParams, workloadListParams),
+	},
+	openapi.Key(http.MethodGet, "/api/v1/rollouts"): {
+		Summary: "List rollouts across all namespaces",
+		Query:   queryParams(listFilterParams, rolloutListParams),
+	},
+	openapi.Key(http.MethodGet, "/api/v1/workload-types"): {Summary: "List the workload types served by the cluster"},
+	openapi.Key(http.MethodGet, "/api/v1/openapi.json"):   {Summary: "This document"},
+
+	openapi.Key(http.MethodGet, "/api/v1/rollout/watch/:namespace"):       {Summary: "Watch rollouts in a namespace", Stream: true},
+	openapi.Key(http.MethodGet, "/api/v1/rollout/watch/:namespace/:name"): {Summary: "Watch a rollout", Stream: true},
+	openapi.Key(http.MethodGet, "/api/v1/rollout/list/:namespace"): {
+		Summary: "List rollouts in a namespace",
+		Query:   rolloutListParams,
+	},
+	openapi.Key(http.MethodPost, "/api/v1/rollout/create/:namespace"): {
+		Summary:  "Generate a Rollout for a workload and create it after pre-flight checks",
+		Request:  createRolloutRequest{},
+		Response: RolloutManifest{},
+	},
+	openapi.Key(http.MethodPost, "/api/v1/rollout/set-image/:namespace/:name"): {
+		Summary: "Update a container image of the rollout's workload",
+		Request: setRolloutImageRequest{},
+	},
+
+	openapi.Key(http.MethodPost, "/api/v1/rollout/promote-full/:namespace/:name"): {
+		Summary: "Skip all remaining canary steps",
+		Request: promoteFullRolloutRequest{},
+	},
+	openapi.Key(http.MethodGet, "/api/v1/rollout/:namespace/:name/traffic"): {
+		Summary:  "Inspect the traffic routing of a rollout",
+		Response: TrafficRouting{},
+	},
+	openapi.Key(http.MethodGet, "/api/v1/rollout/:namespace/:name/batchrelease"): {
+		Summary:  "Get the BatchRelease driving a rollout, with events",
+		Response: BatchReleaseDetail{},
+	},
+	openapi.Key(http.MethodGet, "/api/v1/rollout/:namespace/:name/diff"): {
+		Summary:  "Diff the pod templates of two workload revisions",
+		Query:    revisionDiffParams,
+		Response: RevisionDiff{},
+	},
+	openapi.Key(http.MethodGet, "/api/v1/rollout/:namespace/:name/steps"): {Summary: "Get the canary steps of a rollout"},
+	openapi.Key(http.MethodPut, "/api/v1/rollout/:namespace/:name/steps"): {
+		Summary: "Replace the steps after the current one",
+		Request: updateRolloutStepsRequest{},
+	},
+	openapi.Key(http.MethodPost, "/api/v1/rollout/jump/:namespace/:name"): {
+		Summary: "Jump an in-progress rollout to a step",
+		Request: jumpRolloutStepRequest{},
+	},
+
+	openapi.Key(http.MethodPost, "/api/v1/imagepulljob/:namespace"): {
+		Summary: "Create an ImagePullJob",
+		Request: createImagePullJobRequest{},
+	},
+
+	openapi.Key(http.MethodGet, "/api/v1/workload/:namespace"): {
+		Summary: "List all Kruise workloads in a namespace, grouped by resource",
+		Query:   workloadListParams,
+	},
+	openapi.Key(http.MethodGet, "/api/v1/workload/:namespace/:type"): {
+		Summary: "List workloads of a type",
+		Query:   workloadListParams,
+	},
+	openapi.Key(http.MethodGet, "/api/v1/workload/:namespace/:type/:name"): {
+		Summary: "Get a workload, or its summary with health when view=summary",
+		Query:   []openapi.Parameter{viewParam},
+	},
+	openapi.Key(http.MethodPost, "/api/v1/workload/:namespace/:type/:name/scale"): {
+		Summary: "Scale a workload; refused with HPA_CONTROLS_REPLICAS while an HPA is active unless force=true",
+		Query: []openapi.Parameter{
+			{Name: "replicas", In: "query", Required: true, Schema: &openapi.Schema{Type: "integer", Minimum: floatPtr(0)}},
+			{Name: "force", In: "query", Description: "Scale even though an active HPA targets the workload", Schema: &openapi.Schema{Type: "boolean"}},
+		},
+	},
+	openapi.Key(http.MethodGet, "/api/v1/workload/:namespace/:type/:name/hpa"): {
+		Summary:  "Get the HorizontalPodAutoscaler targeting a workload",
+		Response: HPASummary{},
+	},
+	openapi.Key(http.MethodPost, "/api/v1/workload/:namespace/:type/:name/hpa"): {
+		Summary:  "Change the replica bounds of the HorizontalPodAutoscaler targeting a workload",
+		Request:  updateHPARequest{},
+		Response: HPASummary{},
+	},
+	openapi.Key(http.MethodPost, "/api/v1/workload/:namespace/:type/:name/containers"): {
+		Summary: "Edit images, env vars, resources and probes of a workload's containers",
+		Request: updateWorkloadContainersRequest{},
+	},
+	openapi.Key(http.MethodPost, "/api/v1/workload/:namespace/:type/:name/recreate-containers"): {
+		Summary: "Recreate containers of a workload's pods in batches",
+		Request: recreateContainersRequest{},
+	},
+
+	openapi.Key(http.MethodGet, "/api/v2/rollouts/:namespace"): {
+		Summary: "List rollout summaries",
+		Query: []openapi.Parameter{
+			limitParam, continueParam, sortByParam(sortByName, sortByCreationTimestamp), orderParam,
+			{Name: "active", In: "query", Description: "Only rollouts releasing a new revision (phase Progressing or Paused)", Schema: &openapi.Schema{Type: "boolean"}},
+		},
+		Response: ListData[RolloutSummary]{},
+	},
+	openapi.Key(http.MethodGet, "/api/v2/rollouts/:namespace/:name"): {
+		Summary:  "Get rollout detail",
+		Response: RolloutDetail{},
+	},
+	openapi.Key(http.MethodGet, "/api/v2/rollouts/:namespace/:name/history"): {
+		Summary:  "Get rollout history",
+		Response: ListData[RolloutHistoryEntry]{},
+	},
+	openapi.Key(http.MethodGet, "/api/v2/rollouts/:namespace/:name/pods"): {
+		Summary:  "List pods of the rollout's workload",
+		Response: ListData[PodSummary]{},
+	},
+	openapi.Key(http.MethodGet, "/api/v2/rollouts/:namespace/:name/revisions"): {
+		Summary:  "List revisions of the rollout's workload",
+		Response: ListData[Revision]{},
+	},
+	openapi.Key(http.MethodGet, "/api/v2/rollouts/:namespace/:name/diff"): {
+		Summary:  "Diff the pod templates of two workload revisions",
+		Query:    revisionDiffParams,
+		Response: RevisionDiff{},
+	},
+	openapi.Key(http.MethodGet, "/api/v2/rollouts/:namespace/:name/batchrelease"): {
+		Summary:  "Get the BatchRelease driving a rollout, with events",
+		Response: BatchReleaseDetail{},
+	},
+	openapi.Key(http.MethodGet, "/api/v2/rollouts/:namespace/:name/events"): {
+		Summary:  "List events of a rollout and its BatchRelease",
+		Response: ListData[Event]{},
+	},
+	openapi.Key(http.MethodGet, "/api/v2/rollouts/:namespace/:name/metrics"): {
+		Summary:  "Get CPU and memory usage of the rollout's workload by revision and container",
+		Response: WorkloadMetrics{},
+	},
+	openapi.Key(http.MethodGet, "/api/v2/workloads/:namespace/:type"): {
+		Summary:  "List workload summaries of a type",
+		Query:    []openapi.Parameter{limitParam, continueParam, sortByParam(sortByName, sortByCreationTimestamp, sortByReadiness), orderParam},
+		Response: ListData[WorkloadSummary]{},
+	},
+	openapi.Key(http.MethodGet, "/api/v2/workloads/:namespace/:type/:name"): {
+		Summary:  "Get a workload summary",
+		Response: WorkloadSummary{},
+	},
+	openapi.Key(http.MethodGet, "/api/v2/workloads/:namespace/:type/:name/pods"): {
+		Summary:  "List pods of a workload",
+		Response: ListData[PodSummary]{},
+	},
+	openapi.Key(http.MethodGet, "/api/v2/workloads/:namespace/:type/:name/metrics"): {
+		Summary:  "Get CPU and memory usage of a workload by container",
+		Response: WorkloadMetrics{},
+	},
+}
+
+// ServeOpenAPI serves the OpenAPI document generated from engine's route table.
+func ServeOpenAPI(engine *gin.Engine) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		routes := engine.Routes()
+		table := make([]openapi.Route, 0, len(routes))
+		for _, route := range routes {
+			table = append(table, openapi.Route{Method: route.Method, Path: route.Path, Handler: route.Handler})
+		}
+		doc := openapi.Build(openapi.Config{
+			Title:         "Kruise Dashboard API",
+			Version:       "v1",
+			ErrorResponse: response.ErrorResponse{},
+		}, table, apiOperations)
+		c.JSON(http.StatusOK, doc)
+	}
+}
+
+// ValidateRequestBodies rejects request bodies that do not match the schema of the route's
+// request type, listing every offending field.
+func ValidateRequestBodies() gin.HandlerFunc {
+	schemas := map[string]*openapi.Schema{}
+	for key, op := range apiOperations {
+		if schema := openapi.SchemaOf(op.Request); schema != nil {
+			schemas[key] = schema
+		}
+	}
+
+	return func(c *gin.Context) {
+		schema, ok := schemas[openapi.Key(c.Request.Method, c.FullPath())]
+		if !ok || !openapi.HasBody(c.Request.Method) {
+			c.Next()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			response.BadRequest(c, "Failed to read request body")
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		if violations := openapi.ValidateJSON(schema, body); len(violations) > 0 {
+			fieldErrors := make([]response.FieldError, 0, len(violations))
+			for _, v := range violations {
+				fieldErrors = append(fieldErrors, response.FieldError{Field: v.Field, Message: v.Message})
+			}
+			response.ValidationFailed(c, fieldErrors)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
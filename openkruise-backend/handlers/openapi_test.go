@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/openkruise/kruise-dashboard/extensions-backend/pkg/openapi"
+	"github.com/openkruise/kruise-dashboard/extensions-backend/pkg/response"
+)
+
+func TestValidateRequestBodies(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(ValidateRequestBodies())
+	r.POST("/api/v1/rollout/set-image/:namespace/:name", func(c *gin.Context) {
+		var req setRolloutImageRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			t.Errorf("handler could not read the body after validation: %v", err)
+		}
+		response.Success(c, req.Image)
+	})
+	r.GET("/api/v1/openapi.json", ServeOpenAPI(r))
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantFields []string
+	}{
+		{name: "valid", body: `{"container":"app","image":"app:v2","isInitContainer":false}`, wantStatus: http.StatusOK},
+		{name: "valid images list", body: `{"images":[{"container":"app","image":"app:v2"},{"container":"init","image":"init:v2","isInitContainer":true}]}`, wantStatus: http.StatusOK},
+		{name: "missing image", body: `{"images":[{"container":"app"}]}`, wantStatus: http.StatusBadRequest, wantFields: []string{"images[0].image"}},
+		{name: "unknown and mistyped fields", body: `{"container":"app","image":"app:v2","init":true,"prePull":"yes"}`, wantStatus: http.StatusBadRequest, wantFields: []string{"init", "prePull"}},
+		{name: "timeout out of range", body: `{"container":"app","image":"app:v2","prePullTimeoutSeconds":601}`, wantStatus: http.StatusBadRequest, wantFields: []string{"prePullTimeoutSeconds"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/rollout/set-image/default/web", strings.NewReader(tt.body))
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantStatus == http.StatusOK {
+				return
+			}
+			var resp response.ErrorResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("unmarshal: %v", err)
+			}
+			if resp.Code != "VALIDATION_FAILED" || len(resp.Errors) != len(tt.wantFields) {
+				t.Fatalf("response = %+v, want fields %v", resp, tt.wantFields)
+			}
+			for i, field := range tt.wantFields {
+				if resp.Errors[i].Field != field {
+					t.Errorf("Errors[%d].Field = %q, want %q", i, resp.Errors[i].Field, field)
+				}
+			}
+		})
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/openapi.json", nil))
+	var doc openapi.Document
+	if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("unmarshal document: %v", err)
+	}
+	if doc.Paths["/api/v1/rollout/set-image/{namespace}/{name}"]["post"].RequestBody == nil {
+		t.Errorf("set-image request body missing from document")
+	}
+}
+
+func TestAPIOperationsRequestMethods(t *testing.T) {
+	for key, op := range apiOperations {
+		method, _, _ := strings.Cut(key, " ")
+		if op.Request != nil && !openapi.HasBody(method) {
+			t.Errorf("%s declares a request body but %s requests have none", key, method)
+		}
+	}
+}
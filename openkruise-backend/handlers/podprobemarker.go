@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+	"github.com/openkruise/kruise-dashboard/extensions-backend/pkg/logger"
+	"github.com/openkruise/kruise-dashboard/extensions-backend/pkg/response"
+	"go.uber.org/zap"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var podProbeMarkerGVR = schema.GroupVersionResource{
+	Group:    "apps.kruise.io",
+	Version:  "v1alpha1",
+	Resource: "podprobemarkers",
+}
+
+// podProbeResults returns the results of a PodProbeMarker's probes on a pod. Kruise reports
+// each probe as a pod condition of the probe's podConditionType.
+func podProbeResults(marker map[string]interface{}, pod map[string]interface{}) []map[string]interface{} {
+	markerName, _, _ := unstructured.NestedString(marker, "metadata", "name")
+	probes, _, _ := unstructured.NestedSlice(marker, "spec", "probes")
+	conditions, _, _ := unstructured.NestedSlice(pod, "status", "conditions")
+
+	results := make([]map[string]interface{}, 0, len(probes))
+	for _, raw := range probes {
+		probe, _ := raw.(map[string]interface{})
+		if probe == nil {
+			continue
+		}
+		conditionType, _ := probe["podConditionType"].(string)
+		result := map[string]interface{}{
+			"probeMarker":   markerName,
+			"probe":         probe["name"],
+			"container":     probe["containerName"],
+			"conditionType": conditionType,
+			"status":        "Unknown",
+		}
+		for _, condRaw := range conditions {
+			cond, _ := condRaw.(map[string]interface{})
+			if cond == nil || cond["type"] != conditionType || conditionType == "" {
+				continue
+			}
+			result["status"] = cond["status"]
+			result["message"] = cond["message"]
+			result["lastProbeTime"] = cond["lastProbeTime"]
+			result["lastTransitionTime"] = cond["lastTransitionTime"]
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// listPodProbeMarkerSelectors lists the PodProbeMarkers of a namespace with their parsed selectors.
+func listPodProbeMarkerSelectors(namespace string) ([]unstructured.Unstructured, []labels.Selector, error) {
+	list, err := GetDynamicClient().Resource(podProbeMarkerGVR).Namespace(namespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, nil, err
+	}
+	markers := make([]unstructured.Unstructured, 0, len(list.Items))
+	selectors := make([]labels.Selector, 0, len(list.Items))
+	for i := range list.Items {
+		rawSelector, _, _ := unstructured.NestedMap(list.Items[i].Object, "spec", "selector")
+		selector, err := selectorFromUnstructured(rawSelector)
+		if err != nil {
+			logger.Log.Warn("Skipping pod probe marker with invalid selector",
+				zap.String("namespace", namespace),
+				zap.String("name", list.Items[i].GetName()),
+				zap.Error(err),
+			)
+			continue
+		}
+		markers = append(markers, list.Items[i])
+		selectors = append(selectors, selector)
+	}
+	return markers, selectors, nil
+}
+
+// collectPodProbeResults maps pod names to the results of every PodProbeMarker selecting them.
+// Missing PodProbeMarker support in the cluster yields an empty map.
+func collectPodProbeResults(namespace string, pods []interface{}) map[string][]map[string]interface{} {
+	results := map[string][]map[string]interface{}{}
+	markers, selectors, err := listPodProbeMarkerSelectors(namespace)
+	if err != nil {
+		logger.Log.Warn("Failed to list pod probe markers",
+			zap.String("namespace", namespace),
+			zap.Error(err),
+		)
+		return results
+	}
+
+	for _, raw := range pods {
+		pod, ok := raw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		podName, _, _ := unstructured.NestedString(pod, "metadata", "name")
+		podLabels, _, _ := unstructured.NestedStringMap(pod, "metadata", "labels")
+		for i := range markers {
+			if selectors[i].Empty() || !selectors[i].Matches(labels.Set(podLabels)) {
+				continue
+			}
+			results[podName] = append(results[podName], podProbeResults(markers[i].Object, pod)...)
+		}
+	}
+	return results
+}
+
+// ListPodProbeMarkers lists PodProbeMarkers in a namespace.
+func ListPodProbeMarkers(c *gin.Context) {
+	namespace := c.Param("namespace")
+
+	list, err := GetDynamicClient().Resource(podProbeMarkerGVR).Namespace(namespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		logger.Log.Error("Failed to list pod probe markers",
+			zap.String("namespace", namespace),
+			zap.Error(err),
+		)
+		response.InternalError(c, err)
+		return
+	}
+
+	items := make([]interface{}, 0, len(list.Items))
+	for i := range list.Items {
+		items = append(items, list.Items[i].Object)
+	}
+
+	response.Success(c, gin.H{
+		"podProbeMarkers": items,
+		"total":           len(items),
+		"namespace":       namespace,
+	})
+}
+
+// GetPodProbeMarker returns a PodProbeMarker with the probe results of the pods it selects.
+func GetPodProbeMarker(c *gin.Context) {
+	namespace := c.Param("namespace")
+	name := c.Param("name")
+
+	marker, err := GetDynamicClient().Resource(podProbeMarkerGVR).Namespace(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			response.NotFound(c, "podprobemarker")
+			return
+		}
+		logger.Log.Error("Failed to get pod probe marker",
+			zap.String("namespace", namespace),
+			zap.String("name", name),
+			zap.Error(err),
+		)
+		response.InternalError(c, err)
+		return
+	}
+
+	rawSelector, _, _ := unstructured.NestedMap(marker.Object, "spec", "selector")
+	selector, err := selectorFromUnstructured(rawSelector)
+	if err != nil {
+		response.BadRequest(c, "PodProbeMarker has an invalid selector: "+err.Error())
+		return
+	}
+
+	pods, _, err := listPodsBySelector(namespace, selector.String())
+	if err != nil {
+		logger.Log.Error("Failed to list pods for pod probe marker",
+			zap.String("namespace", namespace),
+			zap.String("name", name),
+			zap.Error(err),
+		)
+		response.InternalError(c, err)
+		return
+	}
+
+	podResults := make([]map[string]interface{}, 0, len(pods))
+	for i := range pods {
+		podResults = append(podResults, map[string]interface{}{
+			"pod":     pods[i].GetName(),
+			"results": podProbeResults(marker.Object, pods[i].Object),
+		})
+	}
+
+	response.Success(c, gin.H{
+		"podProbeMarker": marker.Object,
+		"pods":           podResults,
+	})
+}
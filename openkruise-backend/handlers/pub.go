@@ -0,0 +1,251 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+	"github.com/openkruise/kruise-dashboard/extensions-backend/pkg/logger"
+	"github.com/openkruise/kruise-dashboard/extensions-backend/pkg/response"
+	"go.uber.org/zap"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+var podUnavailableBudgetGVR = schema.GroupVersionResource{
+	Group:    "policy.kruise.io",
+	Version:  "v1alpha1",
+	Resource: "podunavailablebudgets",
+}
+
+// selectorFromUnstructured converts an unstructured metav1.LabelSelector into a labels.Selector.
+func selectorFromUnstructured(raw map[string]interface{}) (labels.Selector, error) {
+	var selector metav1.LabelSelector
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(raw, &selector); err != nil {
+		return nil, err
+	}
+	return metav1.LabelSelectorAsSelector(&selector)
+}
+
+// intOrStringFromUnstructured reads an int-or-percent field such as maxUnavailable.
+func intOrStringFromUnstructured(obj map[string]interface{}, fields ...string) (*intstr.IntOrString, bool) {
+	raw, found, _ := unstructured.NestedFieldNoCopy(obj, fields...)
+	if !found || raw == nil {
+		return nil, false
+	}
+	switch v := raw.(type) {
+	case string:
+		value := intstr.FromString(v)
+		return &value, true
+	case int64:
+		value := intstr.FromInt32(int32(v))
+		return &value, true
+	case float64:
+		value := intstr.FromInt32(int32(v))
+		return &value, true
+	}
+	return nil, false
+}
+
+func isPodAvailable(pod map[string]interface{}) bool {
+	if _, deleting, _ := unstructured.NestedString(pod, "metadata", "deletionTimestamp"); deleting {
+		return false
+	}
+	return isPodReady(pod)
+}
+
+// computePUBStatus computes the budget of a PodUnavailableBudget from the selected pods.
+// expected is the number of replicas the budget is measured against.
+func computePUBStatus(pub map[string]interface{}, pods []unstructured.Unstructured, expected int64) (map[string]interface{}, error) {
+	var currentAvailable int64
+	for i := range pods {
+		if isPodAvailable(pods[i].Object) {
+			currentAvailable++
+		}
+	}
+
+	var desiredAvailable int64
+	if minAvailable, ok := intOrStringFromUnstructured(pub, "spec", "minAvailable"); ok {
+		value, err := intstr.GetScaledValueFromIntOrPercent(minAvailable, int(expected), true)
+		if err != nil {
+			return nil, err
+		}
+		desiredAvailable = int64(value)
+	} else if maxUnavailable, ok := intOrStringFromUnstructured(pub, "spec", "maxUnavailable"); ok {
+		value, err := intstr.GetScaledValueFromIntOrPercent(maxUnavailable, int(expected), true)
+		if err != nil {
+			return nil, err
+		}
+		desiredAvailable = expected - int64(value)
+		if desiredAvailable < 0 {
+			desiredAvailable = 0
+		}
+	}
+
+	unavailableAllowed := currentAvailable - desiredAvailable
+	if unavailableAllowed < 0 {
+		unavailableAllowed = 0
+	}
+
+	return map[string]interface{}{
+		"totalReplicas":      expected,
+		"currentAvailable":   currentAvailable,
+		"desiredAvailable":   desiredAvailable,
+		"unavailableAllowed": unavailableAllowed,
+	}, nil
+}
+
+// podControllers returns the distinct controllers owning the given pods.
+func podControllers(pods []unstructured.Unstructured) []map[string]interface{} {
+	seen := map[string]bool{}
+	result := make([]map[string]interface{}, 0)
+	for i := range pods {
+		for _, ref := range pods[i].GetOwnerReferences() {
+			if ref.Controller == nil || !*ref.Controller {
+				continue
+			}
+			key := ref.Kind + "/" + ref.Name
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			result = append(result, map[string]interface{}{"kind": ref.Kind, "name": ref.Name})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return fmt.Sprint(result[i]["kind"], result[i]["name"]) < fmt.Sprint(result[j]["kind"], result[j]["name"])
+	})
+	return result
+}
+
+// resolvePUBTargets lists the pods a PodUnavailableBudget protects, the workloads they belong to,
+// and the replica count the budget is measured against.
+func resolvePUBTargets(namespace string, pub map[string]interface{}) ([]unstructured.Unstructured, []map[string]interface{}, int64, error) {
+	if targetRef, found, _ := unstructured.NestedMap(pub, "spec", "targetRef"); found {
+		kind, _ := targetRef["kind"].(string)
+		name, _ := targetRef["name"].(string)
+		gvr, _, err := resolveWorkloadRefGVR(kind)
+		if err != nil {
+			return nil, nil, 0, err
+		}
+		workload, err := GetDynamicClient().Resource(gvr).Namespace(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+		if err != nil {
+			return nil, nil, 0, err
+		}
+		labelSelector := extractLabelSelector(workload.Object)
+		if labelSelector == "" {
+			labelSelector = "app=" + name
+		}
+		pods, _, err := listPodsBySelector(namespace, labelSelector)
+		if err != nil {
+			return nil, nil, 0, err
+		}
+		expected, found, _ := unstructured.NestedInt64(workload.Object, "spec", "replicas")
+		if !found {
+			expected = int64(len(pods))
+		}
+		workloads := []map[string]interface{}{{"kind": kind, "name": name}}
+		return pods, workloads, expected, nil
+	}
+
+	rawSelector, found, _ := unstructured.NestedMap(pub, "spec", "selector")
+	if !found {
+		return nil, []map[string]interface{}{}, 0, nil
+	}
+	selector, err := selectorFromUnstructured(rawSelector)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	pods, _, err := listPodsBySelector(namespace, selector.String())
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	return pods, podControllers(pods), int64(len(pods)), nil
+}
+
+func summarizePodUnavailableBudget(namespace string, pub map[string]interface{}) map[string]interface{} {
+	name, _, _ := unstructured.NestedString(pub, "metadata", "name")
+	spec, _, _ := unstructured.NestedMap(pub, "spec")
+	reported, _, _ := unstructured.NestedMap(pub, "status")
+
+	summary := map[string]interface{}{
+		"name":      name,
+		"namespace": namespace,
+		"spec":      spec,
+		"status":    reported,
+	}
+
+	pods, workloads, expected, err := resolvePUBTargets(namespace, pub)
+	if err != nil {
+		logger.Log.Warn("Failed to resolve pod unavailable budget targets",
+			zap.String("namespace", namespace),
+			zap.String("name", name),
+			zap.Error(err),
+		)
+		summary["error"] = err.Error()
+		return summary
+	}
+	computed, err := computePUBStatus(pub, pods, expected)
+	if err != nil {
+		summary["error"] = err.Error()
+		return summary
+	}
+	summary["computed"] = computed
+	summary["workloads"] = workloads
+	return summary
+}
+
+// ListPodUnavailableBudgets lists PodUnavailableBudgets in a namespace with their computed budget.
+func ListPodUnavailableBudgets(c *gin.Context) {
+	namespace := c.Param("namespace")
+
+	list, err := GetDynamicClient().Resource(podUnavailableBudgetGVR).Namespace(namespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		logger.Log.Error("Failed to list pod unavailable budgets",
+			zap.String("namespace", namespace),
+			zap.Error(err),
+		)
+		response.InternalError(c, err)
+		return
+	}
+
+	budgets := make([]map[string]interface{}, 0, len(list.Items))
+	for i := range list.Items {
+		budgets = append(budgets, summarizePodUnavailableBudget(namespace, list.Items[i].Object))
+	}
+
+	response.Success(c, gin.H{
+		"podUnavailableBudgets": budgets,
+		"total":                 len(budgets),
+		"namespace":             namespace,
+	})
+}
+
+// GetPodUnavailableBudget returns a PodUnavailableBudget with its computed budget.
+func GetPodUnavailableBudget(c *gin.Context) {
+	namespace := c.Param("namespace")
+	name := c.Param("name")
+
+	pub, err := GetDynamicClient().Resource(podUnavailableBudgetGVR).Namespace(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			response.NotFound(c, "podunavailablebudget")
+			return
+		}
+		logger.Log.Error("Failed to get pod unavailable budget",
+			zap.String("namespace", namespace),
+			zap.String("name", name),
+			zap.Error(err),
+		)
+		response.InternalError(c, err)
+		return
+	}
+
+	response.Success(c, summarizePodUnavailableBudget(namespace, pub.Object))
+}
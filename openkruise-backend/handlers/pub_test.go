@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func readyPod(ready bool) unstructured.Unstructured {
+	status := "False"
+	if ready {
+		status = "True"
+	}
+	return unstructured.Unstructured{Object: map[string]interface{}{
+		"status": map[string]interface{}{
+			"phase":      "Pending",
+			"conditions": []interface{}{map[string]interface{}{"type": "Ready", "status": status}},
+		},
+	}}
+}
+
+func TestComputePUBStatus(t *testing.T) {
+	pods := []unstructured.Unstructured{readyPod(true), readyPod(true), readyPod(true), readyPod(false)}
+
+	tests := []struct {
+		name                   string
+		spec                   map[string]interface{}
+		expected               int64
+		wantDesiredAvailable   int64
+		wantUnavailableAllowed int64
+	}{
+		{
+			name:                   "maxUnavailable count",
+			spec:                   map[string]interface{}{"maxUnavailable": int64(1)},
+			expected:               4,
+			wantDesiredAvailable:   3,
+			wantUnavailableAllowed: 0,
+		},
+		{
+			name:                   "maxUnavailable percent rounds up",
+			spec:                   map[string]interface{}{"maxUnavailable": "30%"},
+			expected:               4,
+			wantDesiredAvailable:   2,
+			wantUnavailableAllowed: 1,
+		},
+		{
+			name:                   "minAvailable percent",
+			spec:                   map[string]interface{}{"minAvailable": "50%"},
+			expected:               4,
+			wantDesiredAvailable:   2,
+			wantUnavailableAllowed: 1,
+		},
+		{
+			name:                   "minAvailable above available",
+			spec:                   map[string]interface{}{"minAvailable": int64(5)},
+			expected:               4,
+			wantDesiredAvailable:   5,
+			wantUnavailableAllowed: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := computePUBStatus(map[string]interface{}{"spec": tt.spec}, pods, tt.expected)
+			if err != nil {
+				t.Fatalf("computePUBStatus() unexpected error: %v", err)
+			}
+			if got["currentAvailable"] != int64(3) {
+				t.Errorf("currentAvailable = %v, want 3", got["currentAvailable"])
+			}
+			if got["desiredAvailable"] != tt.wantDesiredAvailable {
+				t.Errorf("desiredAvailable = %v, want %d", got["desiredAvailable"], tt.wantDesiredAvailable)
+			}
+			if got["unavailableAllowed"] != tt.wantUnavailableAllowed {
+				t.Errorf("unavailableAllowed = %v, want %d", got["unavailableAllowed"], tt.wantUnavailableAllowed)
+			}
+		})
+	}
+}
+
+func TestPodProbeResults(t *testing.T) {
+	marker := map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "game-probe"},
+		"spec": map[string]interface{}{
+			"probes": []interface{}{
+				map[string]interface{}{"name": "healthy", "containerName": "main", "podConditionType": "game.kruise.io/healthy"},
+				map[string]interface{}{"name": "idle", "containerName": "main", "podConditionType": "game.kruise.io/idle"},
+			},
+		},
+	}
+	pod := map[string]interface{}{
+		"status": map[string]interface{}{
+			"conditions": []interface{}{
+				map[string]interface{}{"type": "game.kruise.io/healthy", "status": "True", "message": "ok"},
+			},
+		},
+	}
+
+	results := podProbeResults(marker, pod)
+	if len(results) != 2 {
+		t.Fatalf("len(results) = %d, want 2", len(results))
+	}
+	if results[0]["status"] != "True" || results[0]["message"] != "ok" {
+		t.Errorf("results[0] = %v, want status True with message", results[0])
+	}
+	if results[1]["status"] != "Unknown" {
+		t.Errorf("results[1].status = %v, want Unknown", results[1]["status"])
+	}
+}
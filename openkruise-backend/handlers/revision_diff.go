@@ -0,0 +1,393 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/openkruise/kruise-dashboard/extensions-backend/pkg/diff"
+	"github.com/openkruise/kruise-dashboard/extensions-backend/pkg/logger"
+	"github.com/openkruise/kruise-dashboard/extensions-backend/pkg/response"
+	"go.uber.org/zap"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	revisionSourceReplicaSet         = "ReplicaSet"
+	revisionSourceControllerRevision = "ControllerRevision"
+
+	changeCategoryImages      = "images"
+	changeCategoryEnv         = "env"
+	changeCategoryResources   = "resources"
+	changeCategoryProbes      = "probes"
+	changeCategoryVolumes     = "volumes"
+	changeCategoryLabels      = "labels"
+	changeCategoryAnnotations = "annotations"
+	changeCategoryOther       = "other"
+
+	unifiedDiffContext = 3
+)
+
+var controllerRevisionGVR = schema.GroupVersionResource{
+	Group:    "apps",
+	Version:  "v1",
+	Resource: "controllerrevisions",
+}
+
+// RevisionRef identifies a revision of a workload's pod template.
+type RevisionRef struct {
+	Name            string `json:"name"`
+	Source          string `json:"source" openapi:"enum=ReplicaSet|ControllerRevision"`
+	Revision        string `json:"revision,omitempty"`
+	PodTemplateHash string `json:"podTemplateHash,omitempty"`
+}
+
+// TemplateChange is one change between two pod templates, grouped by what it affects.
+type TemplateChange struct {
+	Category  string      `json:"category" openapi:"enum=images|env|resources|probes|volumes|labels|annotations|other"`
+	Container string      `json:"container,omitempty"`
+	Op        string      `json:"op" openapi:"enum=add|remove|replace"`
+	Path      string      `json:"path"`
+	From      interface{} `json:"from,omitempty"`
+	To        interface{} `json:"to,omitempty"`
+}
+
+// TemplateDiff compares two pod templates. Patch turns the from template into the to template;
+// UnifiedDiff shows the same change on the templates rendered as YAML.
+type TemplateDiff struct {
+	Changes     []TemplateChange `json:"changes"`
+	Patch       []diff.Operation `json:"patch"`
+	UnifiedDiff string           `json:"unifiedDiff"`
+}
+
+// RevisionDiff compares the pod templates of two revisions.
+type RevisionDiff struct {
+	From RevisionRef `json:"from"`
+	To   RevisionRef `json:"to"`
+	TemplateDiff
+}
+
+// templateRevision is a revision of a workload together with its pod template.
+type templateRevision struct {
+	ref         RevisionRef
+	template    map[string]interface{}
+	created     metav1.Time
+	annotations map[string]string
+}
+
+// matches reports whether id names the revision by object name, pod template hash, revision
+// number, or the hash suffix of a ControllerRevision name.
+func (r templateRevision) matches(id string) bool {
+	if id == "" {
+		return false
+	}
+	return r.ref.Name == id ||
+		r.ref.PodTemplateHash == id ||
+		r.ref.Revision == id ||
+		strings.HasSuffix(r.ref.Name, "-"+id)
+}
+
+// normalizeTemplate drops the fields that differ between any two revisions without being a change
+// of the template: revision hash labels, creation timestamps and strategic merge directives.
+func normalizeTemplate(template map[string]interface{}) map[string]interface{} {
+	if template == nil {
+		template = map[string]interface{}{}
+	}
+	template = runtime.DeepCopyJSON(template)
+	unstructured.RemoveNestedField(template, "metadata", "labels", "pod-template-hash")
+	unstructured.RemoveNestedField(template, "metadata", "labels", "controller-revision-hash")
+	unstructured.RemoveNestedField(template, "metadata", "creationTimestamp")
+	delete(template, "$patch")
+	if labels, found, _ := unstructured.NestedMap(template, "metadata", "labels"); found && len(labels) == 0 {
+		unstructured.RemoveNestedField(template, "metadata", "labels")
+	}
+	return template
+}
+
+func replicaSetRevision(rs unstructured.Unstructured) templateRevision {
+	template, _, _ := unstructured.NestedMap(rs.Object, "spec", "template")
+	return templateRevision{
+		ref: RevisionRef{
+			Name:            rs.GetName(),
+			Source:          revisionSourceReplicaSet,
+			Revision:        rs.GetAnnotations()["deployment.kubernetes.io/revision"],
+			PodTemplateHash: rs.GetLabels()["pod-template-hash"],
+		},
+		template:    normalizeTemplate(template),
+		created:     rs.GetCreationTimestamp(),
+		annotations: rs.GetAnnotations(),
+	}
+}
+
+func controllerRevisionRevision(cr unstructured.Unstructured) templateRevision {
+	template, _, _ := unstructured.NestedMap(cr.Object, "data", "spec", "template")
+	number, _, _ := unstructured.NestedInt64(cr.Object, "revision")
+	return templateRevision{
+		ref: RevisionRef{
+			Name:            cr.GetName(),
+			Source:          revisionSourceControllerRevision,
+			Revision:        strconv.FormatInt(number, 10),
+			PodTemplateHash: cr.GetLabels()["controller.kubernetes.io/hash"],
+		},
+		template:    normalizeTemplate(template),
+		created:     cr.GetCreationTimestamp(),
+		annotations: cr.GetAnnotations(),
+	}
+}
+
+// getRolloutWorkload gets the workload a rollout references, or nil for a rollout without a
+// workloadRef. It responds with an error and returns false if the workload cannot be read.
+func getRolloutWorkload(c *gin.Context, rollout *unstructured.Unstructured) (*unstructured.Unstructured, string, bool) {
+	workloadRef := extractWorkloadRefFromRollout(rollout)
+	refKind, _ := workloadRef["kind"].(string)
+	refName, _ := workloadRef["name"].(string)
+	if refKind == "" || refName == "" {
+		return nil, "", true
+	}
+	workloadGVR, _, err := resolveWorkloadRefGVR(refKind)
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return nil, "", false
+	}
+	workload, err := GetDynamicClient().Resource(workloadGVR).Namespace(rollout.GetNamespace()).Get(context.TODO(), refName, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			response.NotFound(c, refKind+" "+refName)
+			return nil, "", false
+		}
+		logger.Log.Error("Failed to get rollout workload",
+			zap.String("namespace", rollout.GetNamespace()),
+			zap.String("workload", refName),
+			zap.Error(err),
+		)
+		response.InternalError(c, err)
+		return nil, "", false
+	}
+	return workload, refKind, true
+}
+
+// listTemplateRevisions lists the revisions of a rollout's workload: ReplicaSets for Deployments,
+// ControllerRevisions for everything else.
+func listTemplateRevisions(rollout, workload *unstructured.Unstructured, refKind string) ([]templateRevision, error) {
+	namespace := workload.GetNamespace()
+	selector := extractLabelSelector(workload.Object)
+	if selector == "" {
+		return nil, fmt.Errorf("workload %s has no selector", workload.GetName())
+	}
+
+	var revisions []templateRevision
+	if strings.EqualFold(refKind, "deployment") {
+		replicaSets, err := listReplicaSetsBySelector(namespace, selector)
+		if err != nil {
+			return nil, err
+		}
+		matched := matchReplicaSetsForDeployment(replicaSets, workload)
+		if rolloutStrategyType(rollout.Object) == rolloutStrategyCanary {
+			matched = append(matched, listCanaryDeploymentReplicaSets(namespace, workload)...)
+		}
+		for _, rs := range matched {
+			revisions = append(revisions, replicaSetRevision(rs))
+		}
+		return revisions, nil
+	}
+
+	list, err := GetDynamicClient().Resource(controllerRevisionGVR).Namespace(namespace).List(context.TODO(), metav1.ListOptions{
+		LabelSelector: selector,
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, cr := range list.Items {
+		for _, owner := range cr.GetOwnerReferences() {
+			if owner.UID == workload.GetUID() {
+				revisions = append(revisions, controllerRevisionRevision(cr))
+				break
+			}
+		}
+	}
+	return revisions, nil
+}
+
+func findTemplateRevision(revisions []templateRevision, id string) (templateRevision, bool) {
+	for _, revision := range revisions {
+		if revision.ref.Name == id {
+			return revision, true
+		}
+	}
+	for _, revision := range revisions {
+		if revision.matches(id) {
+			return revision, true
+		}
+	}
+	return templateRevision{}, false
+}
+
+// valueAtPointer returns the value at the JSON pointer tokens in obj, or nil.
+func valueAtPointer(obj interface{}, tokens []string) interface{} {
+	current := obj
+	for _, token := range tokens {
+		switch value := current.(type) {
+		case map[string]interface{}:
+			current = value[token]
+		case []interface{}:
+			index, err := strconv.Atoi(token)
+			if err != nil || index < 0 || index >= len(value) {
+				return nil
+			}
+			current = value[index]
+		default:
+			return nil
+		}
+	}
+	return current
+}
+
+// classifyChange returns the category of a change at the given template path and the name of the
+// container it belongs to, if any.
+func classifyChange(tokens []string, from, to map[string]interface{}) (string, string) {
+	if len(tokens) >= 2 && tokens[0] == "metadata" {
+		switch tokens[1] {
+		case "labels":
+			return changeCategoryLabels, ""
+		case "annotations":
+			return changeCategoryAnnotations, ""
+		}
+		return changeCategoryOther, ""
+	}
+	if len(tokens) < 2 || tokens[0] != "spec" {
+		return changeCategoryOther, ""
+	}
+	if tokens[1] == "volumes" {
+		return changeCategoryVolumes, ""
+	}
+	if tokens[1] != "containers" && tokens[1] != "initContainers" {
+		return changeCategoryOther, ""
+	}
+
+	container := ""
+	if len(tokens) >= 3 {
+		for _, template := range []map[string]interface{}{from, to} {
+			if name, ok := valueAtPointer(template, append(tokens[:3:3], "name")).(string); ok {
+				container = name
+				break
+			}
+		}
+	}
+	if len(tokens) < 4 {
+		return changeCategoryOther, container
+	}
+	switch tokens[3] {
+	case "image":
+		return changeCategoryImages, container
+	case "env", "envFrom":
+		return changeCategoryEnv, container
+	case "resources":
+		return changeCategoryResources, container
+	case "livenessProbe", "readinessProbe", "startupProbe":
+		return changeCategoryProbes, container
+	case "volumeMounts":
+		return changeCategoryVolumes, container
+	}
+	return changeCategoryOther, container
+}
+
+// diffTemplates compares two normalized pod templates.
+func diffTemplates(fromName, toName string, from, to map[string]interface{}) (TemplateDiff, error) {
+	patch := diff.JSONPatch(from, to)
+	changes := make([]TemplateChange, 0, len(patch))
+	for _, op := range patch {
+		tokens := diff.SplitPointer(op.Path)
+		category, container := classifyChange(tokens, from, to)
+		change := TemplateChange{Category: category, Container: container, Op: op.Op, Path: op.Path, To: op.Value}
+		if op.Op != "add" {
+			change.From = valueAtPointer(from, tokens)
+		}
+		changes = append(changes, change)
+	}
+
+	fromYAML, err := yaml.Marshal(from)
+	if err != nil {
+		return TemplateDiff{}, err
+	}
+	toYAML, err := yaml.Marshal(to)
+	if err != nil {
+		return TemplateDiff{}, err
+	}
+
+	return TemplateDiff{
+		Changes:     changes,
+		Patch:       patch,
+		UnifiedDiff: diff.Unified(fromName, toName, string(fromYAML), string(toYAML), unifiedDiffContext),
+	}, nil
+}
+
+func diffTemplateRevisions(from, to templateRevision) (RevisionDiff, error) {
+	templateDiff, err := diffTemplates(from.ref.Name, to.ref.Name, from.template, to.template)
+	if err != nil {
+		return RevisionDiff{}, err
+	}
+	return RevisionDiff{From: from.ref, To: to.ref, TemplateDiff: templateDiff}, nil
+}
+
+// GetRolloutRevisionDiff compares the pod templates of two revisions of a rollout's workload. The
+// from and to query parameters accept a ReplicaSet or ControllerRevision name, a pod template hash
+// or a revision number, and default to the rollout's stable and updated revisions.
+func GetRolloutRevisionDiff(c *gin.Context) {
+	rollout, ok := getRolloutV2(c)
+	if !ok {
+		return
+	}
+	namespace := rollout.GetNamespace()
+
+	stableRevision, updatedRevision := extractRolloutRevisions(rollout)
+	fromID := c.DefaultQuery("from", stableRevision)
+	toID := c.DefaultQuery("to", updatedRevision)
+	if fromID == "" || toID == "" {
+		response.BadRequest(c, "from and to are required when the rollout has no stable and updated revision")
+		return
+	}
+
+	workload, refKind, ok := getRolloutWorkload(c, rollout)
+	if !ok {
+		return
+	}
+	if workload == nil {
+		response.BadRequest(c, "rollout has no workloadRef")
+		return
+	}
+
+	revisions, err := listTemplateRevisions(rollout, workload, refKind)
+	if err != nil {
+		logger.Log.Error("Failed to list workload revisions",
+			zap.String("namespace", namespace),
+			zap.String("workload", workload.GetName()),
+			zap.Error(err),
+		)
+		response.InternalError(c, err)
+		return
+	}
+	from, found := findTemplateRevision(revisions, fromID)
+	if !found {
+		response.NotFound(c, "revision "+fromID)
+		return
+	}
+	to, found := findTemplateRevision(revisions, toID)
+	if !found {
+		response.NotFound(c, "revision "+toID)
+		return
+	}
+
+	result, err := diffTemplateRevisions(from, to)
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+	response.Success(c, result)
+}
@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func podTemplate(image string, env map[string]interface{}, labels map[string]interface{}) map[string]interface{} {
+	container := map[string]interface{}{"name": "app", "image": image}
+	if env != nil {
+		container["env"] = []interface{}{env}
+	}
+	return map[string]interface{}{
+		"metadata": map[string]interface{}{"labels": labels},
+		"spec": map[string]interface{}{
+			"containers": []interface{}{
+				map[string]interface{}{"name": "sidecar", "image": "envoy:1.0"},
+				container,
+			},
+		},
+	}
+}
+
+func TestNormalizeTemplate(t *testing.T) {
+	template := normalizeTemplate(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"creationTimestamp": nil,
+			"labels":            map[string]interface{}{"pod-template-hash": "abc"},
+		},
+		"$patch": "replace",
+	})
+	if _, found, _ := unstructured.NestedFieldNoCopy(template, "metadata", "labels"); found {
+		t.Errorf("hash-only labels should be dropped: %v", template)
+	}
+	if _, found := template["$patch"]; found {
+		t.Error("$patch should be dropped")
+	}
+}
+
+func TestDiffTemplateRevisions(t *testing.T) {
+	from := templateRevision{
+		ref:      RevisionRef{Name: "web-1", Source: revisionSourceReplicaSet},
+		template: normalizeTemplate(podTemplate("web:v1", nil, map[string]interface{}{"app": "web", "pod-template-hash": "1"})),
+	}
+	to := templateRevision{
+		ref: RevisionRef{Name: "web-2", Source: revisionSourceReplicaSet},
+		template: normalizeTemplate(podTemplate("web:v2", map[string]interface{}{"name": "MODE", "value": "fast"},
+			map[string]interface{}{"app": "web", "tier": "front", "pod-template-hash": "2"})),
+	}
+
+	result, err := diffTemplateRevisions(from, to)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	byCategory := map[string]TemplateChange{}
+	for _, change := range result.Changes {
+		byCategory[change.Category] = change
+	}
+	if len(result.Changes) != 3 {
+		t.Fatalf("changes = %+v, want labels, image and env", result.Changes)
+	}
+	if image := byCategory[changeCategoryImages]; image.Container != "app" || image.From != "web:v1" || image.To != "web:v2" {
+		t.Errorf("image change = %+v", image)
+	}
+	if env := byCategory[changeCategoryEnv]; env.Container != "app" || env.Op != "add" {
+		t.Errorf("env change = %+v", env)
+	}
+	if labels := byCategory[changeCategoryLabels]; labels.Path != "/metadata/labels/tier" {
+		t.Errorf("label change = %+v", labels)
+	}
+
+	if !strings.HasPrefix(result.UnifiedDiff, "--- web-1\n+++ web-2\n") ||
+		!strings.Contains(result.UnifiedDiff, "-  - image: web:v1\n") ||
+		!strings.Contains(result.UnifiedDiff, "+    image: web:v2\n") {
+		t.Errorf("unified diff:\n%s", result.UnifiedDiff)
+	}
+}
+
+func TestClassifyChange(t *testing.T) {
+	template := podTemplate("web:v1", nil, nil)
+	cases := map[string]string{
+		"/spec/containers/0/readinessProbe/periodSeconds":   changeCategoryProbes,
+		"/spec/containers/1/resources/limits/cpu":           changeCategoryResources,
+		"/spec/containers/1/volumeMounts/0":                 changeCategoryVolumes,
+		"/spec/volumes/0/configMap/name":                    changeCategoryVolumes,
+		"/metadata/annotations/kubectl.kubernetes.io~1note": changeCategoryAnnotations,
+		"/spec/nodeSelector/zone":                           changeCategoryOther,
+	}
+	for path, want := range cases {
+		tokens := strings.Split(strings.TrimPrefix(path, "/"), "/")
+		if got, _ := classifyChange(tokens, template, template); got != want {
+			t.Errorf("classifyChange(%s) = %s, want %s", path, got, want)
+		}
+	}
+}
+
+func TestFindTemplateRevision(t *testing.T) {
+	revisions := []templateRevision{
+		{ref: RevisionRef{Name: "web-5d8f7", Source: revisionSourceControllerRevision, Revision: "3"}},
+		{ref: RevisionRef{Name: "web-7c9b6", Source: revisionSourceReplicaSet, Revision: "4", PodTemplateHash: "7c9b6"}},
+	}
+	for id, want := range map[string]string{"web-5d8f7": "web-5d8f7", "5d8f7": "web-5d8f7", "4": "web-7c9b6", "7c9b6": "web-7c9b6"} {
+		if revision, found := findTemplateRevision(revisions, id); !found || revision.ref.Name != want {
+			t.Errorf("findTemplateRevision(%s) = %s, want %s", id, revision.ref.Name, want)
+		}
+	}
+	if _, found := findTemplateRevision(revisions, "missing"); found {
+		t.Error("unknown revision should not be found")
+	}
+}
@@ -3,6 +3,7 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"sort"
@@ -324,7 +325,8 @@ func GetRolloutStatus(c *gin.Context) {
 	response.Success(c, rollout.Object)
 }
 
-// GetRolloutHistory returns the revision history of a rollout
+// GetRolloutHistory returns the revision history of a rollout's workload, reconstructed from its
+// ReplicaSets or ControllerRevisions, newest first.
 func GetRolloutHistory(c *gin.Context) {
 	namespace := c.Param("namespace")
 	name := c.Param("name")
@@ -339,14 +341,8 @@ func GetRolloutHistory(c *gin.Context) {
 		response.InternalError(c, err)
 		return
 	}
-	status, found, _ := unstructured.NestedMap(rollout.Object, "status")
-	if !found {
-		response.Success(c, gin.H{"history": nil})
-		return
-	}
-	history, found, _ := unstructured.NestedSlice(status, "history")
-	if !found {
-		response.Success(c, gin.H{"history": nil})
+	history, ok := rolloutHistory(c, rollout)
+	if !ok {
 		return
 	}
 	response.Success(c, history)
@@ -619,6 +615,14 @@ func isRolloutPromotable(rollout *unstructured.Unstructured) bool {
 	return specPaused || phase == "Paused" || phase == "Progressing"
 }
 
+// isRolloutActive reports whether a rollout is releasing a new revision, for v2 active=true.
+// kruise-rollout reports Initial, Healthy, Progressing, Terminating and Disabled phases; older
+// controllers also report Paused. v1 ListActiveRollouts keeps its broader phase check.
+func isRolloutActive(obj map[string]interface{}) bool {
+	phase, _, _ := unstructured.NestedString(obj, "status", "phase")
+	return phase == "Progressing" || phase == "Paused"
+}
+
 // PromoteRollout promotes a rollout by continuing from the current step (non-full promote).
 func PromoteRollout(c *gin.Context) {
 	namespace := c.Param("namespace")
@@ -757,7 +761,7 @@ func RollbackRollout(c *gin.Context) {
 		return
 	}
 
-	stableRevision, _, _ := unstructured.NestedString(rollout.Object, "status", "canaryStatus", "stableRevision")
+	stableRevision, _ := extractRolloutRevisions(rollout)
 	if stableRevision == "" {
 		response.Error(c, http.StatusConflict, "No stable revision available for rollback", nil, "STABLE_REVISION_NOT_FOUND")
 		return
@@ -801,16 +805,20 @@ func RollbackRollout(c *gin.Context) {
 	if !found {
 		annotations = map[string]string{}
 	}
+	changeCause := "rollback to " + stableRS.GetName()
 	annotations["kruise-dashboard.io/rolled-back-at"] = time.Now().UTC().Format(time.RFC3339Nano)
+	annotations[changeCauseAnnotation] = changeCause
 	if err := unstructured.SetNestedStringMap(deployment.Object, annotations, "metadata", "annotations"); err != nil {
 		response.InternalError(c, err)
 		return
 	}
 
-	if _, err = GetDynamicClient().Resource(deploymentGVR).Namespace(namespace).Update(context.TODO(), deployment, metav1.UpdateOptions{}); err != nil {
+	updatedDeployment, err := GetDynamicClient().Resource(deploymentGVR).Namespace(namespace).Update(context.TODO(), deployment, metav1.UpdateOptions{})
+	if err != nil {
 		response.InternalError(c, err)
 		return
 	}
+	recordRelease(c, rollout, updatedDeployment, releaseActionRollback, changeCause)
 
 	response.Success(c, gin.H{
 		"message":        "Rollback completed",
@@ -846,11 +854,39 @@ func GetRolloutAnalysis(c *gin.Context) {
 	})
 }
 
+// rolloutImageUpdate sets the image of one container or initContainer.
+type rolloutImageUpdate struct {
+	Container string `json:"container" openapi:"required"`
+	Image     string `json:"image" openapi:"required"`
+	IsInit    bool   `json:"isInitContainer"`
+}
+
 type setRolloutImageRequest struct {
-	Container     string `json:"container"`
-	Image         string `json:"image"`
-	IsInit        bool   `json:"isInitContainer"`
-	InitContainer bool   `json:"initContainer"`
+	Container             string               `json:"container" description:"Container to update; use images to update several containers"`
+	Image                 string               `json:"image"`
+	IsInit                bool                 `json:"isInitContainer"`
+	InitContainer         bool                 `json:"initContainer" openapi:"deprecated" description:"Alias of isInitContainer"`
+	Images                []rolloutImageUpdate `json:"images" description:"Containers and initContainers updated together in one workload update"`
+	ResolveDigests        bool                 `json:"resolveDigests" description:"Check that every image resolves to a manifest digest in its registry"`
+	DryRun                bool                 `json:"dryRun" description:"Return the pod template diff without updating the workload"`
+	PrePull               bool                 `json:"prePull"`
+	PrePullTimeoutSeconds int                  `json:"prePullTimeoutSeconds" openapi:"min=0,max=600"`
+}
+
+// updates returns the requested image updates: the images list, or the single container form.
+func (req setRolloutImageRequest) updates() []rolloutImageUpdate {
+	if len(req.Images) > 0 {
+		return req.Images
+	}
+	return []rolloutImageUpdate{{Container: req.Container, Image: req.Image, IsInit: req.IsInit || req.InitContainer}}
+}
+
+// imageField names the request field holding the image of the i-th update, for field errors.
+func (req setRolloutImageRequest) imageField(i int) string {
+	if len(req.Images) > 0 {
+		return fmt.Sprintf("images[%d].image", i)
+	}
+	return "image"
 }
 
 func bindSetRolloutImageRequest(c *gin.Context) (setRolloutImageRequest, bool) {
@@ -859,10 +895,24 @@ func bindSetRolloutImageRequest(c *gin.Context) (setRolloutImageRequest, bool) {
 		response.BadRequest(c, "Invalid request payload")
 		return req, false
 	}
-	if strings.TrimSpace(req.Container) == "" || strings.TrimSpace(req.Image) == "" {
-		response.BadRequest(c, "container and image are required")
+	if len(req.Images) > 0 && (req.Container != "" || req.Image != "") {
+		response.BadRequest(c, "container/image and images are mutually exclusive")
 		return req, false
 	}
+
+	seen := map[string]bool{}
+	for _, update := range req.updates() {
+		if strings.TrimSpace(update.Container) == "" || strings.TrimSpace(update.Image) == "" {
+			response.BadRequest(c, "container and image are required")
+			return req, false
+		}
+		key := fmt.Sprintf("%t/%s", update.IsInit, update.Container)
+		if seen[key] {
+			response.BadRequest(c, fmt.Sprintf("container %s is listed more than once", update.Container))
+			return req, false
+		}
+		seen[key] = true
+	}
 	return req, true
 }
 
@@ -892,36 +942,59 @@ func resolveRolloutImageTarget(
 	return workloadKind, workloadName, workloadGVR, true
 }
 
-func rolloutContainerPath(useInitContainers bool) []string {
-	if useInitContainers {
-		return []string{"spec", "template", "spec", "initContainers"}
+// applyImageUpdates sets container images in a workload object and returns the image each
+// updated container had. Nothing is changed if a container does not exist.
+func applyImageUpdates(workload map[string]interface{}, updates []rolloutImageUpdate) ([]string, error) {
+	targets := make([]containerTarget, len(updates))
+	for i, update := range updates {
+		targets[i] = containerTarget{Name: update.Container, IsInit: update.IsInit}
+	}
+	previous := make([]string, len(updates))
+	err := editContainers(workload, defaultTemplatePath, targets, func(i int, container map[string]interface{}) error {
+		previous[i], _ = container["image"].(string)
+		container["image"] = updates[i].Image
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return previous, nil
+}
+
+// imageChangeCause describes image updates like kubectl set image.
+func imageChangeCause(updates []rolloutImageUpdate) string {
+	pairs := make([]string, 0, len(updates))
+	for _, update := range updates {
+		pairs = append(pairs, update.Container+"="+update.Image)
 	}
-	return []string{"spec", "template", "spec", "containers"}
+	return "set image " + strings.Join(pairs, ",")
 }
 
-func updateImageInContainerList(containerList []interface{}, containerName, image string) ([]interface{}, bool) {
-	updated := false
-	result := make([]interface{}, 0, len(containerList))
-	for _, item := range containerList {
-		container, ok := item.(map[string]interface{})
-		if !ok {
-			result = append(result, item)
+// resolveImageDigests resolves the digest of every updated image with the credentials of the
+// workload's imagePullSecrets. Images that do not resolve are reported as field errors.
+func resolveImageDigests(req setRolloutImageRequest, updates []rolloutImageUpdate, workload *unstructured.Unstructured) (map[string]string, []response.FieldError) {
+	resolver := newImageResolver()
+	keychain := workloadKeychain(context.TODO(), workload.GetNamespace(), workload)
+	digests := map[string]string{}
+	var errs []response.FieldError
+	for i, update := range updates {
+		if _, done := digests[update.Image]; done {
 			continue
 		}
-		name, _ := container["name"].(string)
-		if name == containerName {
-			copyObj := runtime.DeepCopyJSON(container)
-			copyObj["image"] = image
-			result = append(result, copyObj)
-			updated = true
+		ctx, cancel := context.WithTimeout(context.Background(), imageDigestTimeout)
+		digest, err := resolver.Resolve(ctx, update.Image, keychain)
+		cancel()
+		if err != nil {
+			errs = append(errs, response.FieldError{Field: req.imageField(i), Message: err.Error()})
 			continue
 		}
-		result = append(result, container)
+		digests[update.Image] = digest
 	}
-	return result, updated
+	return digests, errs
 }
 
-// SetRolloutImage updates image for container or initContainer in rollout referenced workload.
+// SetRolloutImage updates the images of containers and initContainers of a rollout's workload in
+// one workload update. Digests can be checked first, and a dry run returns the template diff.
 func SetRolloutImage(c *gin.Context) {
 	namespace := c.Param("namespace")
 	name := c.Param("name")
@@ -930,7 +1003,7 @@ func SetRolloutImage(c *gin.Context) {
 	if !ok {
 		return
 	}
-	useInitContainers := req.IsInit || req.InitContainer
+	updates := req.updates()
 
 	rollout, err := GetDynamicClient().Resource(rolloutGVR).Namespace(namespace).Get(context.TODO(), name, metav1.GetOptions{})
 	if err != nil {
@@ -949,51 +1022,124 @@ func SetRolloutImage(c *gin.Context) {
 		return
 	}
 
-	path := rolloutContainerPath(useInitContainers)
-
-	containerList, found, _ := unstructured.NestedSlice(workload.Object, path...)
-	if !found || len(containerList) == 0 {
-		response.Error(c, http.StatusNotFound, "No containers found in workload", nil, "CONTAINERS_NOT_FOUND")
+	original := workload.DeepCopy()
+	previous, err := applyImageUpdates(workload.Object, updates)
+	if err != nil {
+		response.Error(c, http.StatusNotFound, err.Error(), nil, errorCodeContainerNotFound)
 		return
 	}
 
-	updatedList, updated := updateImageInContainerList(containerList, req.Container, req.Image)
-	if !updated {
-		response.Error(c, http.StatusNotFound, "Container not found", nil, "CONTAINER_NOT_FOUND")
-		return
+	var digests map[string]string
+	if req.ResolveDigests {
+		var errs []response.FieldError
+		if digests, errs = resolveImageDigests(req, updates, original); len(errs) > 0 {
+			response.FieldErrors(c, http.StatusBadRequest, "Image digests could not be resolved", errorCodeImageDigestUnresolved, errs)
+			return
+		}
 	}
 
-	if err := unstructured.SetNestedSlice(workload.Object, updatedList, path...); err != nil {
-		response.InternalError(c, err)
+	images := make([]gin.H, 0, len(updates))
+	for i, update := range updates {
+		image := gin.H{
+			"container":       update.Container,
+			"image":           update.Image,
+			"isInitContainer": update.IsInit,
+			"previousImage":   previous[i],
+		}
+		if digest, ok := digests[update.Image]; ok {
+			image["digest"] = digest
+		}
+		images = append(images, image)
+	}
+	result := gin.H{
+		"namespace":    namespace,
+		"rollout":      name,
+		"workloadKind": workloadKind,
+		"workloadName": workloadName,
+		"images":       images,
+	}
+	if len(req.Images) == 0 {
+		result["container"] = req.Container
+		result["image"] = req.Image
+		result["initContainer"] = updates[0].IsInit
+	}
+
+	if req.DryRun {
+		before, _, _ := unstructured.NestedMap(original.Object, "spec", "template")
+		after, _, _ := unstructured.NestedMap(workload.Object, "spec", "template")
+		templateDiff, err := diffTemplates(workloadName+" (current)", workloadName+" (updated)", normalizeTemplate(before), normalizeTemplate(after))
+		if err != nil {
+			response.InternalError(c, err)
+			return
+		}
+		result["message"] = "Dry run, workload not updated"
+		result["dryRun"] = true
+		result["diff"] = templateDiff
+		response.Success(c, result)
 		return
 	}
 
-	if _, err = GetDynamicClient().Resource(workloadGVR).Namespace(namespace).Update(context.TODO(), workload, metav1.UpdateOptions{}); err != nil {
+	// Optionally warm the images on the workload's nodes before the template changes.
+	if req.PrePull {
+		images := []string{}
+		pulled := map[string]bool{}
+		for _, update := range updates {
+			if !pulled[update.Image] {
+				pulled[update.Image] = true
+				images = append(images, update.Image)
+			}
+		}
+		prePulls, err := prePullWorkloadImages(namespace, workload, images, req.PrePullTimeoutSeconds)
+		if err != nil {
+			logger.Log.Error("Failed to pre-pull images, workload not updated",
+				zap.String("namespace", namespace),
+				zap.String("rollout", name),
+				zap.Strings("images", images),
+				zap.Error(err),
+			)
+			if errors.Is(err, errImagePrePullTimedOut) {
+				response.Error(c, http.StatusGatewayTimeout, err.Error(), nil, errorCodeImagePrePullTimeout)
+				return
+			}
+			response.Error(c, http.StatusInternalServerError, "Failed to pre-pull image: "+err.Error(), err, errorCodeImagePrePullFailed)
+			return
+		}
+		result["prePull"] = prePulls
+
+		// Re-read the workload so the update is not rejected for a stale resourceVersion.
+		latest, err := GetDynamicClient().Resource(workloadGVR).Namespace(namespace).Get(context.TODO(), workloadName, metav1.GetOptions{})
+		if err != nil {
+			response.InternalError(c, err)
+			return
+		}
+		if _, err := applyImageUpdates(latest.Object, updates); err != nil {
+			response.Error(c, http.StatusNotFound, err.Error(), nil, errorCodeContainerNotFound)
+			return
+		}
+		workload = latest
+	}
+
+	changeCause := imageChangeCause(updates)
+	setChangeCause(workload, changeCause)
+	updatedWorkload, err := GetDynamicClient().Resource(workloadGVR).Namespace(namespace).Update(context.TODO(), workload, metav1.UpdateOptions{})
+	if err != nil {
 		response.InternalError(c, err)
 		return
 	}
+	recordRelease(c, rollout, updatedWorkload, releaseActionSetImage, changeCause)
 
-	response.Success(c, gin.H{
-		"message":       "Image updated successfully",
-		"namespace":     namespace,
-		"rollout":       name,
-		"workloadKind":  workloadKind,
-		"workloadName":  workloadName,
-		"container":     req.Container,
-		"image":         req.Image,
-		"initContainer": useInitContainers,
-	})
+	result["message"] = "Image updated successfully"
+	response.Success(c, result)
 }
 
 // resolveWorkloadRefGVR maps a workloadRef kind to its GVR.
 func resolveWorkloadRefGVR(kind string) (schema.GroupVersionResource, string, error) {
-	kindLower := strings.ToLower(kind)
 	// Try the workload type registry first
-	if info, err := ResolveWorkloadType(kindLower); err == nil {
+	if _, info, ok := ResolveWorkloadKind(kind); ok {
 		return info.GVR, info.Kind, nil
 	}
 	// Fallback for built-in workload kinds.
-	switch kindLower {
+	switch strings.ToLower(kind) {
 	case "deployment":
 		return schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}, "Deployment", nil
 	case "replicaset":
@@ -1200,8 +1346,9 @@ func sortDeploymentRevisions(revisions []map[string]interface{}) {
 	})
 }
 
-// buildRevisionsForDeployment lists ReplicaSets for a Deployment and groups pods by RS.
-func buildRevisionsForDeployment(namespace string, workload *unstructured.Unstructured, pods []unstructured.Unstructured, stableRevision, canaryRevision string) []map[string]interface{} {
+// buildRevisionsForDeployment lists ReplicaSets for a Deployment and groups pods by RS. With
+// includeCanaryWorkload the ReplicaSets of the rollout's extra canary Deployment are included.
+func buildRevisionsForDeployment(namespace string, workload *unstructured.Unstructured, pods []unstructured.Unstructured, stableRevision, canaryRevision string, includeCanaryWorkload bool) []map[string]interface{} {
 	labelSelector := extractLabelSelector(workload.Object)
 	if labelSelector == "" {
 		return nil
@@ -1214,6 +1361,9 @@ func buildRevisionsForDeployment(namespace string, workload *unstructured.Unstru
 	}
 
 	matchedReplicaSets := matchReplicaSetsForDeployment(replicaSets, workload)
+	if includeCanaryWorkload {
+		matchedReplicaSets = append(matchedReplicaSets, listCanaryDeploymentReplicaSets(namespace, workload)...)
+	}
 	podsByRS := groupPodsByOwnerKind(pods, "ReplicaSet")
 
 	revisions := make([]map[string]interface{}, 0, len(matchedReplicaSets))
@@ -1288,15 +1438,6 @@ func extractWorkloadRefFromRollout(rollout *unstructured.Unstructured) map[strin
 	return workloadRef
 }
 
-func extractCanaryRevisions(rollout *unstructured.Unstructured) (string, string) {
-	stableRevision, _, _ := unstructured.NestedString(rollout.Object, "status", "canaryStatus", "stableRevision")
-	canaryRevision, _, _ := unstructured.NestedString(rollout.Object, "status", "canaryStatus", "canaryRevision")
-	if canaryRevision == "" {
-		canaryRevision, _, _ = unstructured.NestedString(rollout.Object, "status", "canaryStatus", "podTemplateHash")
-	}
-	return stableRevision, canaryRevision
-}
-
 func podsToObjects(pods []unstructured.Unstructured) []interface{} {
 	items := make([]interface{}, 0, len(pods))
 	for _, item := range pods {
@@ -1371,18 +1512,26 @@ func getWorkloadPodsWithFallback(
 	return workload, pods, items, false, nil
 }
 
+// buildRevisionsForWorkload groups the workload's pods by revision and names each group after the
+// rollout strategy.
 func buildRevisionsForWorkload(
 	refKind string,
 	namespace string,
 	workload *unstructured.Unstructured,
 	pods []unstructured.Unstructured,
-	stableRevision string,
-	canaryRevision string,
+	rollout *unstructured.Unstructured,
 ) []map[string]interface{} {
+	stableRevision, canaryRevision := extractRolloutRevisions(rollout)
+	strategy := rolloutStrategyType(rollout.Object)
+
+	var revisions []map[string]interface{}
 	if strings.EqualFold(refKind, "deployment") {
-		return buildRevisionsForDeployment(namespace, workload, pods, stableRevision, canaryRevision)
+		revisions = buildRevisionsForDeployment(namespace, workload, pods, stableRevision, canaryRevision, strategy == rolloutStrategyCanary)
+	} else {
+		revisions = buildRevisionsForNonDeployment(pods, stableRevision, canaryRevision)
 	}
-	return buildRevisionsForNonDeployment(pods, stableRevision, canaryRevision)
+	assignRevisionRoles(revisions, strategy)
+	return revisions
 }
 
 // GetRolloutPods returns pods related to a rollout's referenced workload, with revision grouping
@@ -1423,10 +1572,7 @@ func GetRolloutPods(c *gin.Context) {
 		return
 	}
 
-	// 3. Extract stable/canary revision info from rollout status
-	stableRevision, canaryRevision := extractCanaryRevisions(rollout)
-
-	// 4. Resolve workload + list pods (with fallback on unresolved kind)
+	// 3. Resolve workload + list pods (with fallback on unresolved kind)
 	workload, pods, items, usedFallback, err := getWorkloadPodsWithFallback(namespace, refKind, refName)
 	if err != nil {
 		response.InternalError(c, err)
@@ -1440,10 +1586,10 @@ func GetRolloutPods(c *gin.Context) {
 		return
 	}
 
-	// 5. Build revision groups
-	revisions := buildRevisionsForWorkload(refKind, namespace, workload, pods, stableRevision, canaryRevision)
+	// 4. Build revision groups by the rollout's stable/updated revisions
+	revisions := buildRevisionsForWorkload(refKind, namespace, workload, pods, rollout)
 
-	// 6. Extract containers from workload
+	// 5. Extract containers from workload
 	containers := extractContainers(workload.Object)
 
 	response.Success(c, gin.H{
@@ -1520,17 +1666,17 @@ func RetryRollout(c *gin.Context) {
 func ListAllRollouts(c *gin.Context) {
 	namespace := c.Param("namespace")
 
-	allItems := []interface{}{}
+	q, ok := bindListQuery(c, sortByName, sortByCreationTimestamp)
+	if !ok {
+		return
+	}
 
 	v1beta1GVR := rolloutGVRForVersion(rolloutAPIVersionV1beta1)
-	v1beta1List, err := GetDynamicClient().Resource(v1beta1GVR).Namespace(namespace).List(context.TODO(), metav1.ListOptions{})
-	if err == nil {
-		for _, item := range v1beta1List.Items {
-			allItems = append(allItems, item.Object)
+	list, err := listResourcePage(context.TODO(), v1beta1GVR, namespace, metav1.ListOptions{}, q)
+	if err != nil {
+		if handleListError(c, err) {
+			return
 		}
-	}
-
-	if len(allItems) == 0 && v1beta1List == nil {
 		logger.Log.Error("Failed to list rollouts",
 			zap.String("namespace", namespace),
 			zap.Error(err),
@@ -1539,10 +1685,21 @@ func ListAllRollouts(c *gin.Context) {
 		return
 	}
 
+	allItems := []interface{}{}
+	for i := range list.Items {
+		if q.Summary {
+			allItems = append(allItems, summarizeRollout(&list.Items[i]))
+			continue
+		}
+		list.Items[i].SetManagedFields(nil)
+		allItems = append(allItems, list.Items[i].Object)
+	}
+
 	response.Success(c, gin.H{
 		"rollouts":  allItems,
 		"total":     len(allItems),
 		"namespace": namespace,
+		"continue":  list.GetContinue(),
 	})
 }
 
@@ -0,0 +1,306 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/openkruise/kruise-dashboard/extensions-backend/pkg/logger"
+	"github.com/openkruise/kruise-dashboard/extensions-backend/pkg/response"
+	"go.uber.org/zap"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/yaml"
+)
+
+const errorCodeRolloutPreflightFailed = "ROLLOUT_PREFLIGHT_FAILED"
+
+// rolloutWorkloadKinds are the workload kinds kruise-rollout can roll out.
+var rolloutWorkloadKinds = map[string]bool{
+	"Deployment":  true,
+	"CloneSet":    true,
+	"StatefulSet": true,
+	"DaemonSet":   true,
+}
+
+type rolloutTrafficInput struct {
+	Service            string `json:"service" openapi:"required" description:"Service selecting the workload's pods"`
+	IngressName        string `json:"ingressName"`
+	IngressClassType   string `json:"ingressClassType" description:"Ingress provider, e.g. nginx or aliyun-alb; defaults to nginx"`
+	HTTPRouteName      string `json:"httpRouteName" description:"Gateway API HTTPRoute; mutually exclusive with ingressName"`
+	GracePeriodSeconds *int64 `json:"gracePeriodSeconds" openapi:"min=0"`
+}
+
+type createRolloutRequest struct {
+	Name           string               `json:"name" openapi:"required"`
+	WorkloadType   string               `json:"workloadType" openapi:"required" description:"Workload type as listed by /workload-types"`
+	WorkloadName   string               `json:"workloadName" openapi:"required"`
+	Strategy       string               `json:"strategy" openapi:"enum=canary|partition" description:"canary runs the new revision in an extra Deployment; defaults to partition"`
+	Steps          []rolloutStepInput   `json:"steps" openapi:"required"`
+	TrafficRouting *rolloutTrafficInput `json:"trafficRouting"`
+	DryRun         bool                 `json:"dryRun" description:"Run the pre-flight checks and return the manifest without creating the rollout"`
+}
+
+// RolloutManifest is a generated Rollout, as YAML for review and as the object sent to the cluster.
+type RolloutManifest struct {
+	Created  bool                   `json:"created"`
+	YAML     string                 `json:"yaml"`
+	Manifest map[string]interface{} `json:"manifest"`
+}
+
+// buildRolloutSteps converts the requested steps, reporting every invalid step as a field error.
+// Replicas may not decrease between steps, and traffic steps need a traffic routing.
+func buildRolloutSteps(req createRolloutRequest) ([]interface{}, []response.FieldError) {
+	var errs []response.FieldError
+	if len(req.Steps) == 0 {
+		return nil, append(errs, response.FieldError{Field: "steps", Message: "at least one step is required"})
+	}
+
+	steps := make([]interface{}, 0, len(req.Steps))
+	for i, input := range req.Steps {
+		field := fmt.Sprintf("steps[%d]", i)
+		step, err := buildCanaryStep(input)
+		if err != nil {
+			errs = append(errs, response.FieldError{Field: field, Message: err.Error()})
+			continue
+		}
+		if req.TrafficRouting == nil && (input.Traffic != "" || len(input.Matches) > 0) {
+			errs = append(errs, response.FieldError{Field: field, Message: "traffic and matches require trafficRouting"})
+		}
+		if len(steps) > 0 && stepReplicasDecrease(steps[len(steps)-1].(map[string]interface{})["replicas"], step["replicas"]) {
+			errs = append(errs, response.FieldError{Field: field + ".replicas", Message: "replicas must not decrease from the previous step"})
+		}
+		steps = append(steps, step)
+	}
+	return steps, errs
+}
+
+// buildRolloutManifest generates a v1beta1 Rollout for the request. The steps are already built.
+func buildRolloutManifest(namespace string, req createRolloutRequest, info WorkloadTypeInfo, steps []interface{}) map[string]interface{} {
+	canary := map[string]interface{}{"steps": steps}
+	if req.Strategy == rolloutStrategyCanary {
+		canary["enableExtraWorkloadForCanary"] = true
+	}
+
+	if traffic := req.TrafficRouting; traffic != nil {
+		routing := map[string]interface{}{"service": traffic.Service}
+		if traffic.GracePeriodSeconds != nil {
+			routing["gracePeriodSeconds"] = *traffic.GracePeriodSeconds
+		}
+		switch {
+		case traffic.IngressName != "":
+			ingress := map[string]interface{}{"name": traffic.IngressName}
+			if traffic.IngressClassType != "" {
+				ingress["classType"] = traffic.IngressClassType
+			}
+			routing["ingress"] = ingress
+		case traffic.HTTPRouteName != "":
+			routing["gateway"] = map[string]interface{}{"httpRouteName": traffic.HTTPRouteName}
+		}
+		canary["trafficRoutings"] = []interface{}{routing}
+	}
+
+	return map[string]interface{}{
+		"apiVersion": rolloutAPIGroup + "/" + rolloutAPIVersionV1beta1,
+		"kind":       rolloutKind,
+		"metadata": map[string]interface{}{
+			"name":      req.Name,
+			"namespace": namespace,
+		},
+		"spec": map[string]interface{}{
+			"workloadRef": map[string]interface{}{
+				"apiVersion": info.GVR.GroupVersion().String(),
+				"kind":       info.Kind,
+				"name":       req.WorkloadName,
+			},
+			"strategy": map[string]interface{}{"canary": canary},
+		},
+	}
+}
+
+// workloadPodLabels returns the workload's selector and the labels of its pod template.
+func workloadPodLabels(workload map[string]interface{}, info WorkloadTypeInfo) (labels.Selector, labels.Set, error) {
+	rawSelector, found, _ := unstructured.NestedMap(workload, info.SelectorPath...)
+	if !found {
+		return nil, nil, fmt.Errorf("workload has no selector")
+	}
+	selector, err := selectorFromUnstructured(rawSelector)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid selector: %v", err)
+	}
+	templateLabels, _, _ := unstructured.NestedStringMap(workload, append(append([]string{}, info.TemplatePath...), "metadata", "labels")...)
+	return selector, labels.Set(templateLabels), nil
+}
+
+// checkWorkloadSelector verifies that the workload selects its own pods with a non-empty selector,
+// and that the traffic Service, if any, selects them too.
+func checkWorkloadSelector(workload map[string]interface{}, info WorkloadTypeInfo, serviceSelector map[string]string) []response.FieldError {
+	var errs []response.FieldError
+	selector, podLabels, err := workloadPodLabels(workload, info)
+	switch {
+	case err != nil:
+		return append(errs, response.FieldError{Field: "workloadName", Message: err.Error()})
+	case selector.Empty():
+		errs = append(errs, response.FieldError{Field: "workloadName", Message: "workload selector is empty and would match every pod"})
+	case !selector.Matches(podLabels):
+		errs = append(errs, response.FieldError{Field: "workloadName", Message: "workload selector does not match its pod template labels"})
+	}
+	if serviceSelector != nil {
+		if len(serviceSelector) == 0 {
+			errs = append(errs, response.FieldError{Field: "trafficRouting.service", Message: "service has no selector"})
+		} else if !labels.SelectorFromSet(serviceSelector).Matches(podLabels) {
+			errs = append(errs, response.FieldError{Field: "trafficRouting.service", Message: "service selector does not match the workload's pods"})
+		}
+	}
+	return errs
+}
+
+// rolloutBoundTo returns the name of the rollout among rollouts that references the workload.
+func rolloutBoundTo(rollouts []unstructured.Unstructured, kind, name string) string {
+	for i := range rollouts {
+		ref := extractWorkloadRefFromRollout(&rollouts[i])
+		refKind, _ := ref["kind"].(string)
+		refName, _ := ref["name"].(string)
+		if strings.EqualFold(refKind, kind) && refName == name {
+			return rollouts[i].GetName()
+		}
+	}
+	return ""
+}
+
+// checkTrafficRouting verifies that the objects a traffic routing names exist, and returns the
+// Service selector for the selector check.
+func checkTrafficRouting(namespace string, traffic *rolloutTrafficInput) (map[string]string, []response.FieldError) {
+	var errs []response.FieldError
+	if traffic.IngressName != "" && traffic.HTTPRouteName != "" {
+		errs = append(errs, response.FieldError{Field: "trafficRouting", Message: "ingressName and httpRouteName are mutually exclusive"})
+	}
+
+	var serviceSelector map[string]string
+	service, err := GetK8sClient().CoreV1().Services(namespace).Get(context.TODO(), traffic.Service, metav1.GetOptions{})
+	if err != nil {
+		errs = append(errs, response.FieldError{Field: "trafficRouting.service", Message: err.Error()})
+	} else {
+		serviceSelector = service.Spec.Selector
+		if serviceSelector == nil {
+			serviceSelector = map[string]string{}
+		}
+	}
+
+	if traffic.IngressName != "" {
+		if _, err := GetK8sClient().NetworkingV1().Ingresses(namespace).Get(context.TODO(), traffic.IngressName, metav1.GetOptions{}); err != nil {
+			errs = append(errs, response.FieldError{Field: "trafficRouting.ingressName", Message: err.Error()})
+		}
+	}
+	if traffic.HTTPRouteName != "" {
+		if route := inspectHTTPRoute(namespace, map[string]interface{}{"httpRouteName": traffic.HTTPRouteName}, "", ""); !route.Exists {
+			errs = append(errs, response.FieldError{Field: "trafficRouting.httpRouteName", Message: route.Message})
+		}
+	}
+	return serviceSelector, errs
+}
+
+// CreateRollout generates a Rollout for a workload from a step plan. Before creating it, pre-flight
+// checks verify the workload type, that the workload exists and is not bound to another rollout,
+// its selector, and the traffic routing objects. With dryRun the manifest is only returned.
+func CreateRollout(c *gin.Context) {
+	namespace := c.Param("namespace")
+
+	var req createRolloutRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request payload")
+		return
+	}
+
+	var errs []response.FieldError
+	info, err := ResolveWorkloadType(req.WorkloadType)
+	if err != nil {
+		errs = append(errs, response.FieldError{Field: "workloadType", Message: err.Error()})
+	} else if !rolloutWorkloadKinds[info.Kind] {
+		errs = append(errs, response.FieldError{Field: "workloadType", Message: fmt.Sprintf("kruise-rollout does not support %s", info.Kind)})
+	} else if req.Strategy == rolloutStrategyCanary && info.Kind != "Deployment" {
+		errs = append(errs, response.FieldError{Field: "strategy", Message: "canary with an extra workload is only supported for Deployment"})
+	}
+
+	steps, stepErrs := buildRolloutSteps(req)
+	errs = append(errs, stepErrs...)
+
+	var serviceSelector map[string]string
+	if req.TrafficRouting != nil {
+		var trafficErrs []response.FieldError
+		serviceSelector, trafficErrs = checkTrafficRouting(namespace, req.TrafficRouting)
+		errs = append(errs, trafficErrs...)
+	}
+
+	rollouts, err := GetDynamicClient().Resource(rolloutGVR).Namespace(namespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		logger.Log.Error("Failed to list rollouts for pre-flight check",
+			zap.String("namespace", namespace),
+			zap.Error(err),
+		)
+		response.InternalError(c, err)
+		return
+	}
+	for _, rollout := range rollouts.Items {
+		if rollout.GetName() == req.Name {
+			errs = append(errs, response.FieldError{Field: "name", Message: "a rollout with this name already exists"})
+		}
+	}
+
+	if info.Kind != "" {
+		workload, err := GetDynamicClient().Resource(info.GVR).Namespace(namespace).Get(context.TODO(), req.WorkloadName, metav1.GetOptions{})
+		switch {
+		case apierrors.IsNotFound(err):
+			errs = append(errs, response.FieldError{Field: "workloadName", Message: fmt.Sprintf("%s %s not found", info.Kind, req.WorkloadName)})
+		case err != nil:
+			logger.Log.Error("Failed to get workload for pre-flight check",
+				zap.String("namespace", namespace),
+				zap.String("workload", req.WorkloadName),
+				zap.Error(err),
+			)
+			response.InternalError(c, err)
+			return
+		default:
+			if bound := rolloutBoundTo(rollouts.Items, info.Kind, req.WorkloadName); bound != "" {
+				errs = append(errs, response.FieldError{Field: "workloadName", Message: fmt.Sprintf("workload is already bound to rollout %s", bound)})
+			}
+			errs = append(errs, checkWorkloadSelector(workload.Object, info, serviceSelector)...)
+		}
+	}
+
+	if len(errs) > 0 {
+		response.FieldErrors(c, http.StatusBadRequest, "Rollout pre-flight checks failed", errorCodeRolloutPreflightFailed, errs)
+		return
+	}
+
+	manifest := buildRolloutManifest(namespace, req, info, steps)
+	if !req.DryRun {
+		created, err := GetDynamicClient().Resource(rolloutGVR).Namespace(namespace).Create(context.TODO(), &unstructured.Unstructured{Object: manifest}, metav1.CreateOptions{})
+		if err != nil {
+			logger.Log.Error("Failed to create rollout",
+				zap.String("namespace", namespace),
+				zap.String("name", req.Name),
+				zap.Error(err),
+			)
+			response.InternalError(c, err)
+			return
+		}
+		manifest = created.Object
+		logger.Log.Info("Rollout created",
+			zap.String("namespace", namespace),
+			zap.String("name", req.Name),
+			zap.String("workload", req.WorkloadName),
+		)
+	}
+
+	data, err := yaml.Marshal(manifest)
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+	response.Success(c, RolloutManifest{Created: !req.DryRun, YAML: string(data), Manifest: manifest})
+}
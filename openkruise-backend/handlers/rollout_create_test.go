@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestBuildRolloutSteps(t *testing.T) {
+	steps, errs := buildRolloutSteps(createRolloutRequest{Steps: []rolloutStepInput{
+		{Replicas: "20%"},
+		{Replicas: "50%", Pause: &rolloutStepPause{}},
+		{Replicas: "100%"},
+	}})
+	if len(errs) != 0 || len(steps) != 3 {
+		t.Fatalf("steps = %v, errs = %v", steps, errs)
+	}
+
+	_, errs = buildRolloutSteps(createRolloutRequest{Steps: []rolloutStepInput{
+		{Replicas: "50%"},
+		{Replicas: "20%"},
+		{Replicas: "120%"},
+		{Replicas: "100%", Traffic: "100%"},
+	}})
+	fields := map[string]bool{}
+	for _, err := range errs {
+		fields[err.Field] = true
+	}
+	for _, field := range []string{"steps[1].replicas", "steps[2]", "steps[3]"} {
+		if !fields[field] {
+			t.Errorf("missing error for %s in %v", field, errs)
+		}
+	}
+
+	if _, errs := buildRolloutSteps(createRolloutRequest{}); len(errs) != 1 || errs[0].Field != "steps" {
+		t.Errorf("empty plan errs = %v", errs)
+	}
+}
+
+func TestBuildRolloutManifest(t *testing.T) {
+	req := createRolloutRequest{
+		Name:         "web",
+		WorkloadName: "web",
+		Strategy:     rolloutStrategyCanary,
+		TrafficRouting: &rolloutTrafficInput{
+			Service:     "web",
+			IngressName: "web",
+		},
+	}
+	steps := []interface{}{map[string]interface{}{"replicas": "20%", "traffic": "20%"}}
+	manifest := buildRolloutManifest("default", req, workloadTypeRegistry["deployment"], steps)
+
+	if ref, _, _ := unstructured.NestedStringMap(manifest, "spec", "workloadRef"); ref["apiVersion"] != "apps/v1" || ref["kind"] != "Deployment" || ref["name"] != "web" {
+		t.Errorf("workloadRef = %v", ref)
+	}
+	if extra, _, _ := unstructured.NestedBool(manifest, "spec", "strategy", "canary", "enableExtraWorkloadForCanary"); !extra {
+		t.Error("canary strategy should enable the extra workload")
+	}
+	routings, _, _ := unstructured.NestedSlice(manifest, "spec", "strategy", "canary", "trafficRoutings")
+	if len(routings) != 1 {
+		t.Fatalf("trafficRoutings = %v", routings)
+	}
+	if name, _, _ := unstructured.NestedString(routings[0].(map[string]interface{}), "ingress", "name"); name != "web" {
+		t.Errorf("ingress name = %q", name)
+	}
+	if rolloutStrategyType(manifest) != rolloutStrategyCanary {
+		t.Errorf("strategy = %s, want canary", rolloutStrategyType(manifest))
+	}
+}
+
+func TestCheckWorkloadSelector(t *testing.T) {
+	workload := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"selector": map[string]interface{}{"matchLabels": map[string]interface{}{"app": "web"}},
+			"template": map[string]interface{}{
+				"metadata": map[string]interface{}{"labels": map[string]interface{}{"app": "web", "tier": "front"}},
+			},
+		},
+	}
+	info := workloadTypeRegistry["cloneset"]
+
+	if errs := checkWorkloadSelector(workload, info, map[string]string{"app": "web"}); len(errs) != 0 {
+		t.Errorf("compatible selectors errs = %v", errs)
+	}
+	if errs := checkWorkloadSelector(workload, info, map[string]string{"app": "api"}); len(errs) != 1 || errs[0].Field != "trafficRouting.service" {
+		t.Errorf("mismatched service errs = %v", errs)
+	}
+
+	unstructured.SetNestedStringMap(workload, map[string]string{"app": "other"}, "spec", "template", "metadata", "labels")
+	if errs := checkWorkloadSelector(workload, info, nil); len(errs) != 1 || errs[0].Field != "workloadName" {
+		t.Errorf("selector not matching the template errs = %v", errs)
+	}
+
+	unstructured.SetNestedMap(workload, map[string]interface{}{}, "spec", "selector")
+	if errs := checkWorkloadSelector(workload, info, nil); len(errs) != 1 {
+		t.Errorf("empty selector errs = %v", errs)
+	}
+}
+
+func TestRolloutBoundTo(t *testing.T) {
+	rollouts := []unstructured.Unstructured{
+		{Object: map[string]interface{}{
+			"metadata": map[string]interface{}{"name": "web-rollout"},
+			"spec": map[string]interface{}{"workloadRef": map[string]interface{}{
+				"apiVersion": "apps/v1", "kind": "Deployment", "name": "web",
+			}},
+		}},
+		{Object: map[string]interface{}{
+			"metadata": map[string]interface{}{"name": "api-rollout"},
+			"spec": map[string]interface{}{"objectRef": map[string]interface{}{"workloadRef": map[string]interface{}{
+				"apiVersion": "apps.kruise.io/v1alpha1", "kind": "CloneSet", "name": "api",
+			}}},
+		}},
+	}
+	if got := rolloutBoundTo(rollouts, "Deployment", "web"); got != "web-rollout" {
+		t.Errorf("bound to %q, want web-rollout", got)
+	}
+	if got := rolloutBoundTo(rollouts, "CloneSet", "api"); got != "api-rollout" {
+		t.Errorf("v1alpha1 bound to %q, want api-rollout", got)
+	}
+	if got := rolloutBoundTo(rollouts, "CloneSet", "web"); got != "" {
+		t.Errorf("unbound workload bound to %q", got)
+	}
+}
@@ -0,0 +1,282 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/openkruise/kruise-dashboard/extensions-backend/pkg/logger"
+	"github.com/openkruise/kruise-dashboard/extensions-backend/pkg/response"
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/util/retry"
+)
+
+const (
+	// changeCauseAnnotation is the kubectl change cause; Deployments copy it to their ReplicaSets.
+	changeCauseAnnotation = "kubernetes.io/change-cause"
+	// revisionHistoryAnnotation lists the earlier numbers of a ReplicaSet that a rollback reused.
+	revisionHistoryAnnotation = "deployment.kubernetes.io/revision-history"
+	// releaseAuditAnnotation holds the dashboard's audit records of template changes on a rollout.
+	releaseAuditAnnotation = "kruise-dashboard.io/release-audit"
+	maxReleaseAuditRecords = 20
+
+	releaseActionSetImage = "set-image"
+	releaseActionRollback = "rollback"
+
+	releaseOutcomeCurrent     = "current"
+	releaseOutcomeProgressing = "progressing"
+	releaseOutcomeCompleted   = "completed"
+	releaseOutcomeSuperseded  = "superseded"
+	releaseOutcomeRolledBack  = "rolledBack"
+)
+
+// remoteUserHeaders carry the user authenticated by a proxy in front of the dashboard.
+var remoteUserHeaders = []string{"X-Forwarded-User", "X-Remote-User"}
+
+// trustProxyUserHeaders enables remoteUserHeaders. Without a proxy that strips and sets them, any
+// client could claim to be any user, so they are ignored unless TRUST_PROXY_USER_HEADERS is true.
+var trustProxyUserHeaders, _ = strconv.ParseBool(os.Getenv("TRUST_PROXY_USER_HEADERS"))
+
+// releaseAuditRecord records a pod template change made through the dashboard. TemplateHash
+// identifies the template so the record can be matched to the revision it produced.
+type releaseAuditRecord struct {
+	TemplateHash string `json:"templateHash"`
+	Action       string `json:"action"`
+	TriggeredBy  string `json:"triggeredBy,omitempty"`
+	ChangeCause  string `json:"changeCause,omitempty"`
+	Time         string `json:"time"`
+}
+
+// RolloutHistoryEntry is one revision of a rollout's workload. Outcome is current for the stable
+// revision, progressing for the revision being rolled out, completed for older revisions,
+// superseded for newer revisions replaced before they became stable, and rolledBack for revisions
+// replaced by a rollback.
+type RolloutHistoryEntry struct {
+	Revision          string             `json:"revision"`
+	Name              string             `json:"name"`
+	Source            string             `json:"source" openapi:"enum=ReplicaSet|ControllerRevision"`
+	PodTemplateHash   string             `json:"podTemplateHash,omitempty"`
+	CreationTimestamp string             `json:"creationTimestamp,omitempty"`
+	Containers        []ContainerSummary `json:"containers"`
+	ChangeCause       string             `json:"changeCause,omitempty"`
+	Action            string             `json:"action,omitempty" openapi:"enum=set-image|rollback"`
+	TriggeredBy       string             `json:"triggeredBy,omitempty"`
+	PreviousRevisions []string           `json:"previousRevisions,omitempty"`
+	Outcome           string             `json:"outcome" openapi:"enum=current|progressing|completed|superseded|rolledBack"`
+}
+
+// templateHash identifies a normalized pod template.
+func templateHash(template map[string]interface{}) string {
+	data, _ := json.Marshal(template)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
+
+// requestUser returns the user a trusted proxy authenticated for the request, if any.
+func requestUser(c *gin.Context) string {
+	if !trustProxyUserHeaders {
+		return ""
+	}
+	for _, header := range remoteUserHeaders {
+		if user := strings.TrimSpace(c.GetHeader(header)); user != "" {
+			return user
+		}
+	}
+	return ""
+}
+
+// setChangeCause sets the change cause of a workload before it is updated.
+func setChangeCause(workload *unstructured.Unstructured, cause string) {
+	annotations := workload.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[changeCauseAnnotation] = cause
+	workload.SetAnnotations(annotations)
+}
+
+func releaseAuditRecords(rollout *unstructured.Unstructured) []releaseAuditRecord {
+	var records []releaseAuditRecord
+	if raw := rollout.GetAnnotations()[releaseAuditAnnotation]; raw != "" {
+		if err := json.Unmarshal([]byte(raw), &records); err != nil {
+			logger.Log.Warn("Ignoring malformed release audit annotation",
+				zap.String("namespace", rollout.GetNamespace()),
+				zap.String("rollout", rollout.GetName()),
+				zap.Error(err),
+			)
+			return nil
+		}
+	}
+	return records
+}
+
+// recordRelease appends an audit record for the template of an updated workload to the rollout,
+// keeping the newest records. The rollout is re-read and updated under its resourceVersion, retrying
+// on conflict, so concurrent releases do not drop each other's records. Failures are logged: the
+// template change itself has succeeded.
+func recordRelease(c *gin.Context, rollout, workload *unstructured.Unstructured, action, changeCause string) {
+	template, _, _ := unstructured.NestedMap(workload.Object, "spec", "template")
+	record := releaseAuditRecord{
+		TemplateHash: templateHash(normalizeTemplate(template)),
+		Action:       action,
+		TriggeredBy:  requestUser(c),
+		ChangeCause:  changeCause,
+		Time:         time.Now().UTC().Format(time.RFC3339),
+	}
+
+	client := GetDynamicClient().Resource(rolloutGVR).Namespace(rollout.GetNamespace())
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		current, err := client.Get(context.TODO(), rollout.GetName(), metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		records := append(releaseAuditRecords(current), record)
+		if len(records) > maxReleaseAuditRecords {
+			records = records[len(records)-maxReleaseAuditRecords:]
+		}
+		data, err := json.Marshal(records)
+		if err != nil {
+			return err
+		}
+		annotations := current.GetAnnotations()
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+		annotations[releaseAuditAnnotation] = string(data)
+		current.SetAnnotations(annotations)
+		_, err = client.Update(context.TODO(), current, metav1.UpdateOptions{})
+		return err
+	})
+	if err != nil {
+		logger.Log.Warn("Failed to record release audit",
+			zap.String("namespace", rollout.GetNamespace()),
+			zap.String("rollout", rollout.GetName()),
+			zap.String("action", action),
+			zap.Error(err),
+		)
+	}
+}
+
+func revisionNumber(revision templateRevision) int64 {
+	number, _ := strconv.ParseInt(revision.ref.Revision, 10, 64)
+	return number
+}
+
+func templateContainers(template map[string]interface{}) []ContainerSummary {
+	containers := []ContainerSummary{}
+	for _, field := range []string{"containers", "initContainers"} {
+		containerType := "container"
+		if field == "initContainers" {
+			containerType = "initContainer"
+		}
+		specContainers, _, _ := unstructured.NestedSlice(template, "spec", field)
+		for _, raw := range specContainers {
+			container, _ := raw.(map[string]interface{})
+			name, _ := container["name"].(string)
+			image, _ := container["image"].(string)
+			containers = append(containers, ContainerSummary{Name: name, Image: image, Type: containerType})
+		}
+	}
+	return containers
+}
+
+// buildRolloutHistory reconstructs the history of a rollout's workload from its revisions, the
+// rollout's stable and updated revisions and the dashboard's audit records. Entries are newest
+// first.
+func buildRolloutHistory(rollout *unstructured.Unstructured, revisions []templateRevision) []RolloutHistoryEntry {
+	sort.SliceStable(revisions, func(i, j int) bool {
+		if ni, nj := revisionNumber(revisions[i]), revisionNumber(revisions[j]); ni != nj {
+			return ni < nj
+		}
+		return revisions[i].created.Before(&revisions[j].created)
+	})
+
+	// The latest record for a template wins: a rollback reuses the template of an older revision.
+	audits := map[string]releaseAuditRecord{}
+	for _, record := range releaseAuditRecords(rollout) {
+		audits[record.TemplateHash] = record
+	}
+
+	stableRevision, updatedRevision := extractRolloutRevisions(rollout)
+	stableIndex := -1
+	for i, revision := range revisions {
+		if revision.matches(stableRevision) {
+			stableIndex = i
+		}
+	}
+
+	entries := make([]RolloutHistoryEntry, len(revisions))
+	for i, revision := range revisions {
+		audit := audits[templateHash(revision.template)]
+		entry := RolloutHistoryEntry{
+			Revision:          revision.ref.Revision,
+			Name:              revision.ref.Name,
+			Source:            revision.ref.Source,
+			PodTemplateHash:   revision.ref.PodTemplateHash,
+			CreationTimestamp: formatTimestamp(revision.created),
+			Containers:        templateContainers(revision.template),
+			ChangeCause:       revision.annotations[changeCauseAnnotation],
+			Action:            audit.Action,
+			TriggeredBy:       audit.TriggeredBy,
+		}
+		if entry.ChangeCause == "" {
+			entry.ChangeCause = audit.ChangeCause
+		}
+		if previous := revision.annotations[revisionHistoryAnnotation]; previous != "" {
+			entry.PreviousRevisions = strings.Split(previous, ",")
+		}
+		entries[i] = entry
+	}
+
+	for i, revision := range revisions {
+		switch {
+		case i == stableIndex:
+			entries[i].Outcome = releaseOutcomeCurrent
+		case revision.matches(updatedRevision):
+			entries[i].Outcome = releaseOutcomeProgressing
+		case i+1 < len(entries) && (entries[i+1].Action == releaseActionRollback || len(entries[i+1].PreviousRevisions) > 0):
+			entries[i].Outcome = releaseOutcomeRolledBack
+		case stableIndex >= 0 && i > stableIndex:
+			entries[i].Outcome = releaseOutcomeSuperseded
+		default:
+			entries[i].Outcome = releaseOutcomeCompleted
+		}
+	}
+
+	for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+		entries[i], entries[j] = entries[j], entries[i]
+	}
+	return entries
+}
+
+// rolloutHistory reconstructs the history of a rollout. It responds with an error and returns
+// false on failure.
+func rolloutHistory(c *gin.Context, rollout *unstructured.Unstructured) ([]RolloutHistoryEntry, bool) {
+	workload, refKind, ok := getRolloutWorkload(c, rollout)
+	if !ok {
+		return nil, false
+	}
+	if workload == nil {
+		return []RolloutHistoryEntry{}, true
+	}
+	revisions, err := listTemplateRevisions(rollout, workload, refKind)
+	if err != nil {
+		logger.Log.Error("Failed to list workload revisions for history",
+			zap.String("namespace", rollout.GetNamespace()),
+			zap.String("rollout", rollout.GetName()),
+			zap.Error(err),
+		)
+		response.InternalError(c, err)
+		return nil, false
+	}
+	return buildRolloutHistory(rollout, revisions), true
+}
@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+func historyRevision(name, number, hash, image string, annotations map[string]string) templateRevision {
+	return templateRevision{
+		ref:         RevisionRef{Name: name, Source: revisionSourceReplicaSet, Revision: number, PodTemplateHash: hash},
+		template:    normalizeTemplate(podTemplate(image, nil, map[string]interface{}{"app": "web"})),
+		created:     metav1.NewTime(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)),
+		annotations: annotations,
+	}
+}
+
+func historyRollout(stable, canary string, records []releaseAuditRecord) *unstructured.Unstructured {
+	rollout := &unstructured.Unstructured{Object: map[string]interface{}{
+		"status": map[string]interface{}{
+			"canaryStatus": map[string]interface{}{"stableRevision": stable, "canaryRevision": canary},
+		},
+	}}
+	if records != nil {
+		data, _ := json.Marshal(records)
+		rollout.SetAnnotations(map[string]string{releaseAuditAnnotation: string(data)})
+	}
+	return rollout
+}
+
+func TestBuildRolloutHistoryOutcomes(t *testing.T) {
+	revisions := []templateRevision{
+		historyRevision("web-v3", "3", "h3", "web:v3", nil),
+		historyRevision("web-v1", "1", "h1", "web:v1", map[string]string{changeCauseAnnotation: "initial"}),
+		historyRevision("web-v2", "2", "h2", "web:v2", nil),
+		historyRevision("web-v4", "4", "h4", "web:v4", nil),
+	}
+	entries := buildRolloutHistory(historyRollout("h2", "h4", nil), revisions)
+
+	want := map[string]string{
+		"4": releaseOutcomeProgressing,
+		"3": releaseOutcomeSuperseded,
+		"2": releaseOutcomeCurrent,
+		"1": releaseOutcomeCompleted,
+	}
+	if len(entries) != 4 || entries[0].Revision != "4" || entries[3].Revision != "1" {
+		t.Fatalf("entries are not newest first: %+v", entries)
+	}
+	for _, entry := range entries {
+		if entry.Outcome != want[entry.Revision] {
+			t.Errorf("revision %s outcome = %s, want %s", entry.Revision, entry.Outcome, want[entry.Revision])
+		}
+	}
+	if entries[3].ChangeCause != "initial" || entries[3].Containers[1].Image != "web:v1" {
+		t.Errorf("revision 1 = %+v", entries[3])
+	}
+}
+
+func TestBuildRolloutHistoryRollback(t *testing.T) {
+	// Rolling back to web:v1 reuses its ReplicaSet, which moves from revision 1 to 3.
+	revisions := []templateRevision{
+		historyRevision("web-v1", "3", "h1", "web:v1", map[string]string{revisionHistoryAnnotation: "1"}),
+		historyRevision("web-v2", "2", "h2", "web:v2", nil),
+	}
+	records := []releaseAuditRecord{
+		{TemplateHash: templateHash(revisions[1].template), Action: releaseActionSetImage, TriggeredBy: "alice", ChangeCause: "set image app=web:v2"},
+		{TemplateHash: templateHash(revisions[0].template), Action: releaseActionRollback, TriggeredBy: "bob", ChangeCause: "rollback to web-v1"},
+	}
+	entries := buildRolloutHistory(historyRollout("h1", "h1", records), revisions)
+
+	if entries[0].Outcome != releaseOutcomeCurrent || entries[0].TriggeredBy != "bob" || entries[0].Action != releaseActionRollback {
+		t.Errorf("revision 3 = %+v", entries[0])
+	}
+	if len(entries[0].PreviousRevisions) != 1 || entries[0].PreviousRevisions[0] != "1" {
+		t.Errorf("previous revisions = %v", entries[0].PreviousRevisions)
+	}
+	if entries[1].Outcome != releaseOutcomeRolledBack || entries[1].TriggeredBy != "alice" || entries[1].ChangeCause != "set image app=web:v2" {
+		t.Errorf("revision 2 = %+v", entries[1])
+	}
+}
+
+func TestRequestUser(t *testing.T) {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("POST", "/", nil)
+	if user := requestUser(c); user != "" {
+		t.Errorf("user without proxy headers = %q", user)
+	}
+	c.Request.Header.Set("X-Remote-User", "alice")
+	if user := requestUser(c); user != "" {
+		t.Errorf("user from untrusted proxy headers = %q, want empty", user)
+	}
+
+	previous := trustProxyUserHeaders
+	defer func() { trustProxyUserHeaders = previous }()
+	trustProxyUserHeaders = true
+	if user := requestUser(c); user != "alice" {
+		t.Errorf("user = %q, want alice", user)
+	}
+}
+
+func TestRecordReleaseRereadsRollout(t *testing.T) {
+	stored := historyRollout("", "", []releaseAuditRecord{{TemplateHash: "concurrent", Action: releaseActionSetImage}})
+	stored.SetAPIVersion(rolloutGVR.GroupVersion().String())
+	stored.SetKind("Rollout")
+	stored.SetNamespace("default")
+	stored.SetName("web")
+
+	previous := dynamicClient
+	defer func() { dynamicClient = previous }()
+	dynamicClient = dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), stored)
+
+	// The handler's copy predates the concurrent record.
+	stale := historyRollout("", "", nil)
+	stale.SetNamespace("default")
+	stale.SetName("web")
+	workload := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{"template": podTemplate("web:v2", nil, map[string]interface{}{"app": "web"})},
+	}}
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("POST", "/", nil)
+	recordRelease(c, stale, workload, releaseActionRollback, "rollback")
+
+	updated, err := dynamicClient.Resource(rolloutGVR).Namespace("default").Get(context.Background(), "web", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	records := releaseAuditRecords(updated)
+	if len(records) != 2 || records[0].TemplateHash != "concurrent" || records[1].Action != releaseActionRollback {
+		t.Errorf("records = %+v, want the concurrent record kept", records)
+	}
+}
@@ -0,0 +1,491 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/openkruise/kruise-dashboard/extensions-backend/pkg/logger"
+	"github.com/openkruise/kruise-dashboard/extensions-backend/pkg/response"
+	"go.uber.org/zap"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+const (
+	nextStepAnnotation = "rollouts.kruise.io/next-step"
+
+	canaryStepStatePaused  = "StepPaused"
+	canaryStepStateReady   = "StepReady"
+	canaryStepStateUpgrade = "StepUpgrade"
+
+	errorCodeRolloutStepOutOfRange   = "ROLLOUT_STEP_OUT_OF_RANGE"
+	errorCodeRolloutNoCanarySteps    = "ROLLOUT_NO_CANARY_STEPS"
+	errorCodePromoteFullNotConfirmed = "PROMOTE_FULL_NOT_CONFIRMED"
+	errorCodeRolloutAtLastStep       = "ROLLOUT_ALREADY_AT_LAST_STEP"
+)
+
+type rolloutHeaderMatch struct {
+	Type  string `json:"type" openapi:"enum=Exact|RegularExpression"`
+	Name  string `json:"name" openapi:"required"`
+	Value string `json:"value" openapi:"required"`
+}
+
+type rolloutStepMatch struct {
+	Headers []rolloutHeaderMatch `json:"headers" openapi:"required"`
+}
+
+type rolloutStepPause struct {
+	Duration *int64 `json:"duration" openapi:"min=0"`
+}
+
+type rolloutStepInput struct {
+	Replicas string             `json:"replicas" openapi:"required" description:"Pod count or percentage, e.g. \"1\" or \"20%\""`
+	Traffic  string             `json:"traffic" description:"Traffic percentage, e.g. \"20%\""`
+	Pause    *rolloutStepPause  `json:"pause"`
+	Matches  []rolloutStepMatch `json:"matches"`
+}
+
+type updateRolloutStepsRequest struct {
+	Steps []rolloutStepInput `json:"steps" openapi:"required"`
+}
+
+type jumpRolloutStepRequest struct {
+	StepIndex int64 `json:"stepIndex" openapi:"required,min=1"`
+}
+
+// parseStepReplicas parses a step's replicas as a pod count or a percentage of at most 100%.
+func parseStepReplicas(value string) (intstr.IntOrString, error) {
+	value = strings.TrimSpace(value)
+	if strings.HasSuffix(value, "%") {
+		percent, err := strconv.Atoi(strings.TrimSuffix(value, "%"))
+		if err != nil || percent < 0 || percent > 100 {
+			return intstr.IntOrString{}, fmt.Errorf("replicas %q must be a percentage between 0%% and 100%%", value)
+		}
+		return intstr.FromString(value), nil
+	}
+	count, err := strconv.Atoi(value)
+	if err != nil || count < 0 {
+		return intstr.IntOrString{}, fmt.Errorf("replicas %q must be a non-negative integer or a percentage", value)
+	}
+	return intstr.FromInt32(int32(count)), nil
+}
+
+// buildCanaryStep converts a requested step into its unstructured form.
+func buildCanaryStep(input rolloutStepInput) (map[string]interface{}, error) {
+	replicas, err := parseStepReplicas(input.Replicas)
+	if err != nil {
+		return nil, err
+	}
+	step := map[string]interface{}{}
+	if replicas.Type == intstr.Int {
+		step["replicas"] = int64(replicas.IntVal)
+	} else {
+		step["replicas"] = replicas.StrVal
+	}
+
+	if traffic := strings.TrimSpace(input.Traffic); traffic != "" {
+		percent, err := strconv.Atoi(strings.TrimSuffix(traffic, "%"))
+		if !strings.HasSuffix(traffic, "%") || err != nil || percent < 0 || percent > 100 {
+			return nil, fmt.Errorf("traffic %q must be a percentage between 0%% and 100%%", traffic)
+		}
+		step["traffic"] = traffic
+	}
+
+	if input.Pause != nil {
+		pause := map[string]interface{}{}
+		if input.Pause.Duration != nil {
+			pause["duration"] = *input.Pause.Duration
+		}
+		step["pause"] = pause
+	}
+
+	if len(input.Matches) > 0 {
+		matches := make([]interface{}, 0, len(input.Matches))
+		for _, match := range input.Matches {
+			headers := make([]interface{}, 0, len(match.Headers))
+			for _, header := range match.Headers {
+				headerType := header.Type
+				if headerType == "" {
+					headerType = "Exact"
+				}
+				headers = append(headers, map[string]interface{}{
+					"type":  headerType,
+					"name":  header.Name,
+					"value": header.Value,
+				})
+			}
+			matches = append(matches, map[string]interface{}{"headers": headers})
+		}
+		step["matches"] = matches
+	}
+	return step, nil
+}
+
+// stepReplicasDecrease reports whether next scales below prev. Counts and percentages are not
+// comparable and are never reported.
+func stepReplicasDecrease(prev, next interface{}) bool {
+	prevValue, prevOK := intOrStringFromUnstructured(map[string]interface{}{"v": prev}, "v")
+	nextValue, nextOK := intOrStringFromUnstructured(map[string]interface{}{"v": next}, "v")
+	if !prevOK || !nextOK || prevValue.Type != nextValue.Type {
+		return false
+	}
+	if prevValue.Type == intstr.Int {
+		return nextValue.IntVal < prevValue.IntVal
+	}
+	prevPercent, _ := strconv.Atoi(strings.TrimSuffix(prevValue.StrVal, "%"))
+	nextPercent, _ := strconv.Atoi(strings.TrimSuffix(nextValue.StrVal, "%"))
+	return nextPercent < prevPercent
+}
+
+// mergeRemainingSteps keeps the steps up to and including the current one and replaces the rest.
+func mergeRemainingSteps(existing []interface{}, currentIndex int64, remaining []rolloutStepInput) ([]interface{}, error) {
+	if currentIndex < 0 {
+		currentIndex = 0
+	}
+	if currentIndex > int64(len(existing)) {
+		currentIndex = int64(len(existing))
+	}
+
+	steps := append([]interface{}{}, existing[:currentIndex]...)
+	for i, input := range remaining {
+		step, err := buildCanaryStep(input)
+		if err != nil {
+			return nil, fmt.Errorf("steps[%d]: %w", i, err)
+		}
+		if len(steps) > 0 {
+			prev, _ := steps[len(steps)-1].(map[string]interface{})
+			if stepReplicasDecrease(prev["replicas"], step["replicas"]) {
+				return nil, fmt.Errorf("steps[%d]: replicas must not decrease from the previous step", i)
+			}
+		}
+		steps = append(steps, step)
+	}
+	if len(steps) == 0 {
+		return nil, fmt.Errorf("a rollout needs at least one step")
+	}
+	return steps, nil
+}
+
+func getRolloutForSteps(c *gin.Context, action string) (*unstructured.Unstructured, bool) {
+	namespace := c.Param("namespace")
+	name := c.Param("name")
+
+	rollout, err := GetDynamicClient().Resource(rolloutGVR).Namespace(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			response.NotFound(c, "rollout")
+			return nil, false
+		}
+		logger.Log.Error("Failed to get rollout for "+action,
+			zap.String("namespace", namespace),
+			zap.String("name", name),
+			zap.Error(err),
+		)
+		response.InternalError(c, err)
+		return nil, false
+	}
+	return rollout, true
+}
+
+// GetRolloutSteps returns the canary steps of a rollout and the progress through them.
+func GetRolloutSteps(c *gin.Context) {
+	rollout, ok := getRolloutForSteps(c, "steps")
+	if !ok {
+		return
+	}
+
+	currentIndex, currentState, _ := rolloutProgress(rollout.Object)
+	nextIndex, _, _ := unstructured.NestedInt64(rollout.Object, rolloutStatusPath(rollout.Object, "nextStepIndex")...)
+	steps := rolloutSteps(rollout.Object)
+
+	response.Success(c, gin.H{
+		"strategy":         rolloutStrategyType(rollout.Object),
+		"steps":            steps,
+		"totalSteps":       len(steps),
+		"currentStepIndex": currentIndex,
+		"currentStepState": currentState,
+		"nextStepIndex":    nextIndex,
+	})
+}
+
+// UpdateRolloutSteps replaces the steps after the current one. Completed steps and the step in
+// progress are kept as they are.
+func UpdateRolloutSteps(c *gin.Context) {
+	namespace := c.Param("namespace")
+	name := c.Param("name")
+
+	var req updateRolloutStepsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request payload")
+		return
+	}
+
+	rollout, ok := getRolloutForSteps(c, "step update")
+	if !ok {
+		return
+	}
+	stepsPath := rolloutStepsPath(rollout.Object)
+	existing, found, _ := unstructured.NestedSlice(rollout.Object, stepsPath...)
+	if !found {
+		response.Error(c, http.StatusConflict, "Rollout has no steps", nil, errorCodeRolloutNoCanarySteps)
+		return
+	}
+	currentIndex, _, _ := rolloutProgress(rollout.Object)
+
+	steps, err := mergeRemainingSteps(existing, currentIndex, req.Steps)
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+	if err := unstructured.SetNestedSlice(rollout.Object, steps, stepsPath...); err != nil {
+		response.InternalError(c, err)
+		return
+	}
+
+	updated, err := GetDynamicClient().Resource(rolloutGVR).Namespace(namespace).Update(context.TODO(), rollout, metav1.UpdateOptions{})
+	if err != nil {
+		logger.Log.Error("Failed to update rollout steps",
+			zap.String("namespace", namespace),
+			zap.String("name", name),
+			zap.Error(err),
+		)
+		response.InternalError(c, err)
+		return
+	}
+
+	logger.Log.Info("Rollout steps updated",
+		zap.String("namespace", namespace),
+		zap.String("name", name),
+		zap.Int64("keptSteps", currentIndex),
+		zap.Int("totalSteps", len(steps)),
+	)
+	response.Success(c, gin.H{
+		"message":          "Rollout steps updated",
+		"steps":            rolloutSteps(updated.Object),
+		"currentStepIndex": currentIndex,
+	})
+}
+
+// requestNextStep sets nextStepIndex in the rollout's canaryStatus or blueGreenStatus and releases a
+// paused step, then records the target in the next-step annotation. It reports whether the jump
+// takes effect immediately.
+func requestNextStep(gvr schema.GroupVersionResource, rollout *unstructured.Unstructured, target int64) (bool, error) {
+	namespace, name := rollout.GetNamespace(), rollout.GetName()
+	_, currentState, _ := rolloutProgress(rollout.Object)
+
+	progress := map[string]interface{}{"nextStepIndex": target}
+	immediate := currentState == canaryStepStatePaused
+	if immediate {
+		progress["currentStepState"] = canaryStepStateReady
+	}
+	statusPatch, _ := json.Marshal(map[string]interface{}{
+		"status": map[string]interface{}{rolloutStatusKey(rollout.Object): progress},
+	})
+	if _, err := GetDynamicClient().Resource(gvr).Namespace(namespace).Patch(context.TODO(), name, types.MergePatchType, statusPatch, metav1.PatchOptions{}, "status"); err != nil {
+		return false, err
+	}
+
+	annotationPatch, _ := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]interface{}{nextStepAnnotation: strconv.FormatInt(target, 10)},
+		},
+	})
+	if _, err := GetDynamicClient().Resource(gvr).Namespace(namespace).Patch(context.TODO(), name, types.MergePatchType, annotationPatch, metav1.PatchOptions{}); err != nil {
+		logger.Log.Warn("Failed to record next-step annotation",
+			zap.String("namespace", namespace),
+			zap.String("name", name),
+			zap.Error(err),
+		)
+	}
+	return immediate, nil
+}
+
+// JumpRolloutStep moves an in-progress rollout to the given step. kruise-rollout moves to
+// nextStepIndex when the current step is ready, so a paused step is released
+// immediately and a running step jumps once it completes.
+func JumpRolloutStep(c *gin.Context) {
+	namespace := c.Param("namespace")
+	name := c.Param("name")
+
+	var req jumpRolloutStepRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request payload")
+		return
+	}
+
+	rollout, ok := getRolloutForSteps(c, "step jump")
+	if !ok {
+		return
+	}
+	steps, _, _ := unstructured.NestedSlice(rollout.Object, rolloutStepsPath(rollout.Object)...)
+	if req.StepIndex < 1 || req.StepIndex > int64(len(steps)) {
+		response.Error(c, http.StatusBadRequest, fmt.Sprintf("stepIndex must be between 1 and %d", len(steps)), nil, errorCodeRolloutStepOutOfRange)
+		return
+	}
+	currentIndex, _, inProgress := rolloutProgress(rollout.Object)
+	if !inProgress {
+		response.Error(c, http.StatusConflict, "Rollout has no step in progress", nil, errorCodeRolloutNotPromotable)
+		return
+	}
+
+	immediate, err := requestNextStep(rolloutGVR, rollout, req.StepIndex)
+	if err != nil {
+		logger.Log.Error("Failed to patch rollout status for step jump",
+			zap.String("namespace", namespace),
+			zap.String("name", name),
+			zap.Error(err),
+		)
+		response.InternalError(c, err)
+		return
+	}
+
+	logger.Log.Info("Rollout step jump requested",
+		zap.String("namespace", namespace),
+		zap.String("name", name),
+		zap.Int64("from", currentIndex),
+		zap.Int64("to", req.StepIndex),
+		zap.Bool("immediate", immediate),
+	)
+	response.Success(c, gin.H{
+		"message":          "Rollout step jump requested",
+		"currentStepIndex": currentIndex,
+		"targetStepIndex":  req.StepIndex,
+		"immediate":        immediate,
+	})
+}
+
+type promoteFullRolloutRequest struct {
+	Confirm bool `json:"confirm" openapi:"required" description:"Must be true; promote-full skips every remaining step"`
+}
+
+// getRolloutServedVersion fetches a rollout through v1beta1 and falls back to v1alpha1 on clusters
+// whose kruise-rollout predates v1beta1.
+func getRolloutServedVersion(namespace, name string) (*unstructured.Unstructured, schema.GroupVersionResource, error) {
+	gvr := rolloutGVRForVersion(rolloutAPIVersionV1beta1)
+	rollout, err := GetDynamicClient().Resource(gvr).Namespace(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	if err == nil || !apierrors.IsNotFound(err) {
+		return rollout, gvr, err
+	}
+	gvr = rolloutGVRForVersion(rolloutAPIVersionV1alpha1)
+	rollout, alphaErr := GetDynamicClient().Resource(gvr).Namespace(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	if alphaErr != nil {
+		return nil, gvr, err
+	}
+	return rollout, gvr, nil
+}
+
+// PromoteFullRollout skips all remaining canary steps and releases the new version to every pod.
+// Controllers serving v1beta1 jump through nextStepIndex of canaryStatus or blueGreenStatus;
+// v1alpha1 controllers have no step jump, so the last step is made current and restarted from its
+// upgrade state.
+func PromoteFullRollout(c *gin.Context) {
+	namespace := c.Param("namespace")
+	name := c.Param("name")
+
+	var req promoteFullRolloutRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request payload")
+		return
+	}
+	if !req.Confirm {
+		response.Error(c, http.StatusBadRequest, "Promote-full must be confirmed with \"confirm\": true", nil, errorCodePromoteFullNotConfirmed)
+		return
+	}
+
+	rollout, gvr, err := getRolloutServedVersion(namespace, name)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			response.NotFound(c, "rollout")
+			return
+		}
+		logger.Log.Error("Failed to get rollout for promote-full",
+			zap.String("namespace", namespace),
+			zap.String("name", name),
+			zap.Error(err),
+		)
+		response.InternalError(c, err)
+		return
+	}
+
+	steps, _, _ := unstructured.NestedSlice(rollout.Object, rolloutStepsPath(rollout.Object)...)
+	if len(steps) == 0 {
+		response.Error(c, http.StatusConflict, "Rollout has no steps", nil, errorCodeRolloutNoCanarySteps)
+		return
+	}
+	currentIndex, currentState, inProgress := rolloutProgress(rollout.Object)
+	if !inProgress || !isRolloutPromotable(rollout) {
+		response.Error(c, http.StatusConflict, "Rollout has no step in progress", nil, errorCodeRolloutNotPromotable)
+		return
+	}
+	target := int64(len(steps))
+	specPaused, _, _ := unstructured.NestedBool(rollout.Object, "spec", "paused")
+	// A v1beta1 rollout already running its last step is only held back by a step or spec pause;
+	// without either there is nothing to promote.
+	if gvr.Version != rolloutAPIVersionV1alpha1 && currentIndex >= target && currentState != canaryStepStatePaused && !specPaused {
+		response.Error(c, http.StatusConflict, "Rollout is already running its last step", nil, errorCodeRolloutAtLastStep)
+		return
+	}
+
+	if specPaused {
+		unpause, _ := json.Marshal(map[string]interface{}{"spec": map[string]interface{}{"paused": false}})
+		if _, err := GetDynamicClient().Resource(gvr).Namespace(namespace).Patch(context.TODO(), name, types.MergePatchType, unpause, metav1.PatchOptions{}); err != nil {
+			logger.Log.Error("Failed to unpause rollout for promote-full",
+				zap.String("namespace", namespace),
+				zap.String("name", name),
+				zap.Error(err),
+			)
+			response.InternalError(c, err)
+			return
+		}
+	}
+
+	immediate := true
+	if gvr.Version == rolloutAPIVersionV1alpha1 {
+		statusPatch, _ := json.Marshal(map[string]interface{}{
+			"status": map[string]interface{}{rolloutStatusCanary: map[string]interface{}{
+				"currentStepIndex": target,
+				"currentStepState": canaryStepStateUpgrade,
+			}},
+		})
+		_, err = GetDynamicClient().Resource(gvr).Namespace(namespace).Patch(context.TODO(), name, types.MergePatchType, statusPatch, metav1.PatchOptions{}, "status")
+	} else if currentIndex < target {
+		immediate, err = requestNextStep(gvr, rollout, target)
+	} else if currentState == canaryStepStatePaused {
+		// Already on the last step: releasing its pause completes the rollout.
+		immediate, err = requestNextStep(gvr, rollout, target)
+	}
+	if err != nil {
+		logger.Log.Error("Failed to patch rollout status for promote-full",
+			zap.String("namespace", namespace),
+			zap.String("name", name),
+			zap.Error(err),
+		)
+		response.InternalError(c, err)
+		return
+	}
+
+	logger.Log.Info("Rollout promote-full requested",
+		zap.String("namespace", namespace),
+		zap.String("name", name),
+		zap.String("apiVersion", gvr.Version),
+		zap.Int64("from", currentIndex),
+		zap.Int64("to", target),
+	)
+	response.Success(c, gin.H{
+		"message":          "Rollout promote-full requested",
+		"apiVersion":       gvr.Version,
+		"currentStepIndex": currentIndex,
+		"targetStepIndex":  target,
+		"totalSteps":       target,
+		"immediate":        immediate,
+	})
+}
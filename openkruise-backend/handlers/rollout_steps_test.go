@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/openkruise/kruise-dashboard/extensions-backend/pkg/response"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+func TestParseStepReplicas(t *testing.T) {
+	for _, value := range []string{"1", "0", "20%", "100%"} {
+		if _, err := parseStepReplicas(value); err != nil {
+			t.Errorf("parseStepReplicas(%q) error = %v", value, err)
+		}
+	}
+	for _, value := range []string{"", "-1", "101%", "abc", "1.5"} {
+		if _, err := parseStepReplicas(value); err == nil {
+			t.Errorf("parseStepReplicas(%q) expected an error", value)
+		}
+	}
+}
+
+func TestBuildCanaryStep(t *testing.T) {
+	duration := int64(60)
+	step, err := buildCanaryStep(rolloutStepInput{
+		Replicas: "2",
+		Traffic:  "20%",
+		Pause:    &rolloutStepPause{Duration: &duration},
+		Matches:  []rolloutStepMatch{{Headers: []rolloutHeaderMatch{{Name: "user", Value: "beta"}}}},
+	})
+	if err != nil {
+		t.Fatalf("buildCanaryStep error = %v", err)
+	}
+	if step["replicas"] != int64(2) || step["traffic"] != "20%" {
+		t.Errorf("step = %v", step)
+	}
+	if pause := step["pause"].(map[string]interface{}); pause["duration"] != int64(60) {
+		t.Errorf("pause = %v", pause)
+	}
+	headers := step["matches"].([]interface{})[0].(map[string]interface{})["headers"].([]interface{})
+	if headers[0].(map[string]interface{})["type"] != "Exact" {
+		t.Errorf("header type should default to Exact, got %v", headers[0])
+	}
+
+	if _, err := buildCanaryStep(rolloutStepInput{Replicas: "1", Traffic: "20"}); err == nil {
+		t.Error("traffic without % should be rejected")
+	}
+}
+
+func TestMergeRemainingSteps(t *testing.T) {
+	existing := []interface{}{
+		map[string]interface{}{"replicas": "20%"},
+		map[string]interface{}{"replicas": "50%"},
+		map[string]interface{}{"replicas": "100%"},
+	}
+
+	steps, err := mergeRemainingSteps(existing, 1, []rolloutStepInput{{Replicas: "30%"}, {Replicas: "100%"}})
+	if err != nil {
+		t.Fatalf("mergeRemainingSteps error = %v", err)
+	}
+	if len(steps) != 3 {
+		t.Fatalf("len(steps) = %d, want 3", len(steps))
+	}
+	if got := steps[0].(map[string]interface{})["replicas"]; got != "20%" {
+		t.Errorf("completed step changed to %v", got)
+	}
+	if got := steps[1].(map[string]interface{})["replicas"]; got != "30%" {
+		t.Errorf("steps[1].replicas = %v, want 30%%", got)
+	}
+
+	if _, err := mergeRemainingSteps(existing, 2, []rolloutStepInput{{Replicas: "30%"}}); err == nil {
+		t.Error("replicas decreasing below the current step should be rejected")
+	}
+	if _, err := mergeRemainingSteps(existing, 0, nil); err == nil {
+		t.Error("an empty step list should be rejected")
+	}
+
+	// Counts and percentages are not compared.
+	if _, err := mergeRemainingSteps(existing, 2, []rolloutStepInput{{Replicas: "1"}}); err != nil {
+		t.Errorf("mixed replicas kinds error = %v", err)
+	}
+}
+
+func TestPromoteFullRequiresConfirmation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(ValidateRequestBodies())
+	r.POST("/api/v1/rollout/promote-full/:namespace/:name", PromoteFullRollout)
+
+	tests := []struct {
+		body     string
+		wantCode string
+	}{
+		{body: `{}`, wantCode: "VALIDATION_FAILED"},
+		{body: `{"confirm":"yes"}`, wantCode: "VALIDATION_FAILED"},
+		{body: `{"confirm":false}`, wantCode: errorCodePromoteFullNotConfirmed},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/rollout/promote-full/default/web", strings.NewReader(tt.body)))
+
+		var resp response.ErrorResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("unmarshal: %v", err)
+		}
+		if w.Code != http.StatusBadRequest || resp.Code != tt.wantCode {
+			t.Errorf("body %s: status = %d, code = %q, want 400 %q", tt.body, w.Code, resp.Code, tt.wantCode)
+		}
+	}
+}
+
+func TestPromoteFullAtLastStep(t *testing.T) {
+	previous := dynamicClient
+	defer func() { dynamicClient = previous }()
+
+	rollout := func(state string) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "rollouts.kruise.io/v1beta1",
+			"kind":       "Rollout",
+			"metadata":   map[string]interface{}{"name": "web", "namespace": "default"},
+			"spec": map[string]interface{}{"strategy": map[string]interface{}{"canary": map[string]interface{}{
+				"steps": []interface{}{map[string]interface{}{"replicas": "20%"}, map[string]interface{}{"replicas": "100%"}},
+			}}},
+			"status": map[string]interface{}{
+				"phase": "Progressing",
+				"canaryStatus": map[string]interface{}{
+					"currentStepIndex": int64(2),
+					"currentStepState": state,
+				},
+			},
+		}}
+	}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/api/v1/rollout/promote-full/:namespace/:name", PromoteFullRollout)
+	tests := []struct {
+		state      string
+		wantStatus int
+	}{
+		{state: canaryStepStateUpgrade, wantStatus: http.StatusConflict},
+		{state: canaryStepStatePaused, wantStatus: http.StatusOK},
+	}
+	for _, tt := range tests {
+		dynamicClient = dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), rollout(tt.state))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/rollout/promote-full/default/web", strings.NewReader(`{"confirm":true}`)))
+		if w.Code != tt.wantStatus {
+			t.Errorf("state %s: status = %d, want %d: %s", tt.state, w.Code, tt.wantStatus, w.Body.String())
+		}
+		if tt.wantStatus == http.StatusConflict && !strings.Contains(w.Body.String(), errorCodeRolloutAtLastStep) {
+			t.Errorf("state %s: body = %s, want %s", tt.state, w.Body.String(), errorCodeRolloutAtLastStep)
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+
+	"github.com/openkruise/kruise-dashboard/extensions-backend/pkg/logger"
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const (
+	// rolloutStrategyCanary runs the new revision in an extra canary workload next to the stable one.
+	rolloutStrategyCanary = "canary"
+	// rolloutStrategyPartition updates the workload in place, a batch of pods per step.
+	rolloutStrategyPartition = "partition"
+	// rolloutStrategyBlueGreen brings up the new revision beside the old one and switches traffic.
+	rolloutStrategyBlueGreen = "blueGreen"
+
+	rolloutSpecCanary    = "canary"
+	rolloutSpecBlueGreen = "blueGreen"
+
+	rolloutStatusCanary    = "canaryStatus"
+	rolloutStatusBlueGreen = "blueGreenStatus"
+
+	// v1alpha1 rollouts opt into partition-style Deployment rolling with this annotation.
+	rollingStyleAnnotation = "rollouts.kruise.io/rolling-style"
+	rollingStylePartition  = "partition"
+
+	// canaryDeploymentLabel marks the extra Deployment created for a canary rollout.
+	canaryDeploymentLabel = "rollouts.kruise.io/canary-deployment"
+
+	revisionRoleStable   = "stable"
+	revisionRoleCanary   = "canary"
+	revisionRoleBlue     = "blue"
+	revisionRoleGreen    = "green"
+	revisionRolePrevious = "previous"
+)
+
+// Rollout actions, named after their endpoints under /rollout.
+const (
+	rolloutActionPause       = "pause"
+	rolloutActionResume      = "resume"
+	rolloutActionEnable      = "enable"
+	rolloutActionDisable     = "disable"
+	rolloutActionRestart     = "restart"
+	rolloutActionRetry       = "retry"
+	rolloutActionPromote     = "promote"
+	rolloutActionPromoteFull = "promote-full"
+	rolloutActionJump        = "jump"
+	rolloutActionEditSteps   = "steps"
+	rolloutActionRollback    = "rollback"
+	rolloutActionSetImage    = "set-image"
+)
+
+// rolloutSpecKey returns the key of the strategy under spec.strategy.
+func rolloutSpecKey(obj map[string]interface{}) string {
+	if _, found, _ := unstructured.NestedMap(obj, "spec", "strategy", rolloutSpecBlueGreen); found {
+		return rolloutSpecBlueGreen
+	}
+	return rolloutSpecCanary
+}
+
+// rolloutStatusKey returns the key of the progress status under status.
+func rolloutStatusKey(obj map[string]interface{}) string {
+	if rolloutSpecKey(obj) == rolloutSpecBlueGreen {
+		return rolloutStatusBlueGreen
+	}
+	return rolloutStatusCanary
+}
+
+func rolloutStepsPath(obj map[string]interface{}) []string {
+	return []string{"spec", "strategy", rolloutSpecKey(obj), "steps"}
+}
+
+func rolloutTrafficRoutingsPath(obj map[string]interface{}) []string {
+	return []string{"spec", "strategy", rolloutSpecKey(obj), "trafficRoutings"}
+}
+
+// rolloutStatusPath returns the path of a field of the progress status, e.g. currentStepIndex.
+func rolloutStatusPath(obj map[string]interface{}, field string) []string {
+	return []string{"status", rolloutStatusKey(obj), field}
+}
+
+// rolloutStrategyType detects how a rollout moves pods to the new revision. v1beta1 canaries use an
+// extra workload only with enableExtraWorkloadForCanary; v1alpha1 Deployment canaries use one
+// unless the rolling-style annotation asks for partition. Other workloads always roll in place.
+func rolloutStrategyType(obj map[string]interface{}) string {
+	if rolloutSpecKey(obj) == rolloutSpecBlueGreen {
+		return rolloutStrategyBlueGreen
+	}
+
+	rollout := &unstructured.Unstructured{Object: obj}
+	if extra, found, _ := unstructured.NestedBool(obj, "spec", "strategy", rolloutSpecCanary, "enableExtraWorkloadForCanary"); found {
+		if extra {
+			return rolloutStrategyCanary
+		}
+		return rolloutStrategyPartition
+	}
+	if strings.HasSuffix(rollout.GetAPIVersion(), "/"+rolloutAPIVersionV1alpha1) {
+		refKind, _ := extractWorkloadRefFromRollout(rollout)["kind"].(string)
+		if strings.EqualFold(refKind, "deployment") && rollout.GetAnnotations()[rollingStyleAnnotation] != rollingStylePartition {
+			return rolloutStrategyCanary
+		}
+	}
+	return rolloutStrategyPartition
+}
+
+// rolloutProgress returns the current step and its state, and whether a rollout is in progress.
+func rolloutProgress(obj map[string]interface{}) (int64, string, bool) {
+	currentIndex, found, _ := unstructured.NestedInt64(obj, rolloutStatusPath(obj, "currentStepIndex")...)
+	state, _, _ := unstructured.NestedString(obj, rolloutStatusPath(obj, "currentStepState")...)
+	return currentIndex, state, found
+}
+
+// extractRolloutRevisions returns the stable revision and the revision being rolled out.
+func extractRolloutRevisions(rollout *unstructured.Unstructured) (string, string) {
+	obj := rollout.Object
+	stableRevision, _, _ := unstructured.NestedString(obj, rolloutStatusPath(obj, "stableRevision")...)
+	updatedField := "canaryRevision"
+	if rolloutStatusKey(obj) == rolloutStatusBlueGreen {
+		updatedField = "updatedRevision"
+	}
+	updatedRevision, _, _ := unstructured.NestedString(obj, rolloutStatusPath(obj, updatedField)...)
+	if updatedRevision == "" {
+		updatedRevision, _, _ = unstructured.NestedString(obj, rolloutStatusPath(obj, "podTemplateHash")...)
+	}
+	return stableRevision, updatedRevision
+}
+
+// rolloutActions lists the actions that apply to a rollout in its current state and strategy.
+func rolloutActions(obj map[string]interface{}) []string {
+	rollout := &unstructured.Unstructured{Object: obj}
+	actions := []string{}
+
+	if disabled, _, _ := unstructured.NestedBool(obj, "spec", "disabled"); disabled {
+		return append(actions, rolloutActionEnable)
+	}
+	actions = append(actions, rolloutActionDisable, rolloutActionSetImage, rolloutActionRestart)
+
+	if rolloutPaused(obj) {
+		actions = append(actions, rolloutActionResume)
+	} else {
+		actions = append(actions, rolloutActionPause)
+	}
+
+	steps, _, _ := unstructured.NestedSlice(obj, rolloutStepsPath(obj)...)
+	if _, _, inProgress := rolloutProgress(obj); inProgress && len(steps) > 0 && isRolloutPromotable(rollout) {
+		actions = append(actions, rolloutActionPromote, rolloutActionPromoteFull, rolloutActionJump, rolloutActionRetry)
+	}
+	if len(steps) > 0 {
+		actions = append(actions, rolloutActionEditSteps)
+	}
+
+	refKind, _ := extractWorkloadRefFromRollout(rollout)["kind"].(string)
+	if strings.EqualFold(refKind, "deployment") {
+		actions = append(actions, rolloutActionRollback)
+	}
+	return actions
+}
+
+// revisionRole names a revision group after the strategy: stable and canary, or blue and green.
+func revisionRole(strategy string, isStable, isUpdated bool) string {
+	switch {
+	case isUpdated && strategy == rolloutStrategyBlueGreen:
+		return revisionRoleGreen
+	case isUpdated:
+		return revisionRoleCanary
+	case isStable && strategy == rolloutStrategyBlueGreen:
+		return revisionRoleBlue
+	case isStable:
+		return revisionRoleStable
+	}
+	return revisionRolePrevious
+}
+
+func assignRevisionRoles(revisions []map[string]interface{}, strategy string) {
+	for _, revision := range revisions {
+		isStable, _ := revision["isStable"].(bool)
+		isCanary, _ := revision["isCanary"].(bool)
+		revision["role"] = revisionRole(strategy, isStable, isCanary)
+	}
+}
+
+// listCanaryDeploymentReplicaSets returns the ReplicaSets of the extra Deployments that a canary
+// rollout created for workload.
+func listCanaryDeploymentReplicaSets(namespace string, workload *unstructured.Unstructured) []unstructured.Unstructured {
+	canaries, err := GetDynamicClient().Resource(deploymentGVR).Namespace(namespace).List(context.TODO(), metav1.ListOptions{
+		LabelSelector: canaryDeploymentLabel + "=" + workload.GetName(),
+	})
+	if err != nil {
+		logger.Log.Warn("Failed to list canary Deployments", zap.Error(err))
+		return nil
+	}
+
+	var replicaSets []unstructured.Unstructured
+	for i := range canaries.Items {
+		canary := &canaries.Items[i]
+		selector := extractLabelSelector(canary.Object)
+		if selector == "" {
+			continue
+		}
+		owned, err := listReplicaSetsBySelector(namespace, selector)
+		if err != nil {
+			logger.Log.Warn("Failed to list canary ReplicaSets", zap.Error(err))
+			continue
+		}
+		replicaSets = append(replicaSets, matchReplicaSetsForDeployment(owned, canary)...)
+	}
+	return replicaSets
+}
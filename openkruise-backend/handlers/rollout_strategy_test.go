@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestRolloutStrategyType(t *testing.T) {
+	tests := []struct {
+		name    string
+		rollout map[string]interface{}
+		want    string
+	}{
+		{
+			name: "blue-green",
+			rollout: map[string]interface{}{
+				"apiVersion": "rollouts.kruise.io/v1beta1",
+				"spec":       map[string]interface{}{"strategy": map[string]interface{}{"blueGreen": map[string]interface{}{}}},
+			},
+			want: rolloutStrategyBlueGreen,
+		},
+		{
+			name: "v1beta1 extra workload",
+			rollout: map[string]interface{}{
+				"apiVersion": "rollouts.kruise.io/v1beta1",
+				"spec": map[string]interface{}{"strategy": map[string]interface{}{"canary": map[string]interface{}{
+					"enableExtraWorkloadForCanary": true,
+				}}},
+			},
+			want: rolloutStrategyCanary,
+		},
+		{
+			name: "v1beta1 default",
+			rollout: map[string]interface{}{
+				"apiVersion": "rollouts.kruise.io/v1beta1",
+				"spec": map[string]interface{}{
+					"workloadRef": map[string]interface{}{"kind": "Deployment", "name": "web"},
+					"strategy":    map[string]interface{}{"canary": map[string]interface{}{}},
+				},
+			},
+			want: rolloutStrategyPartition,
+		},
+		{
+			name: "v1alpha1 deployment",
+			rollout: map[string]interface{}{
+				"apiVersion": "rollouts.kruise.io/v1alpha1",
+				"spec": map[string]interface{}{
+					"objectRef": map[string]interface{}{"workloadRef": map[string]interface{}{"kind": "Deployment", "name": "web"}},
+					"strategy":  map[string]interface{}{"canary": map[string]interface{}{}},
+				},
+			},
+			want: rolloutStrategyCanary,
+		},
+		{
+			name: "v1alpha1 deployment partition style",
+			rollout: map[string]interface{}{
+				"apiVersion": "rollouts.kruise.io/v1alpha1",
+				"metadata":   map[string]interface{}{"annotations": map[string]interface{}{rollingStyleAnnotation: "partition"}},
+				"spec": map[string]interface{}{
+					"objectRef": map[string]interface{}{"workloadRef": map[string]interface{}{"kind": "Deployment", "name": "web"}},
+					"strategy":  map[string]interface{}{"canary": map[string]interface{}{}},
+				},
+			},
+			want: rolloutStrategyPartition,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rolloutStrategyType(tt.rollout); got != tt.want {
+				t.Errorf("rolloutStrategyType() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBlueGreenStatusIsRead(t *testing.T) {
+	rollout := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{"strategy": map[string]interface{}{"blueGreen": map[string]interface{}{
+			"steps": []interface{}{
+				map[string]interface{}{"replicas": "100%", "traffic": "0%"},
+				map[string]interface{}{"replicas": "100%", "traffic": "100%"},
+			},
+		}}},
+		"status": map[string]interface{}{
+			"phase": "Progressing",
+			"blueGreenStatus": map[string]interface{}{
+				"currentStepIndex": int64(1),
+				"currentStepState": "StepPaused",
+				"stableRevision":   "web-6f8b",
+				"updatedRevision":  "web-9c2d",
+			},
+		},
+	}}
+
+	stable, updated := extractRolloutRevisions(rollout)
+	if stable != "web-6f8b" || updated != "web-9c2d" {
+		t.Errorf("revisions = %q, %q", stable, updated)
+	}
+	summary := summarizeRollout(rollout)
+	if summary.Strategy != rolloutStrategyBlueGreen || summary.CurrentStepIndex != 1 || summary.TotalSteps != 2 || !summary.Paused {
+		t.Errorf("summary = %+v", summary)
+	}
+
+	actions := map[string]bool{}
+	for _, action := range rolloutActions(rollout.Object) {
+		actions[action] = true
+	}
+	for _, want := range []string{rolloutActionPromote, rolloutActionJump, rolloutActionResume} {
+		if !actions[want] {
+			t.Errorf("actions missing %q: %v", want, actions)
+		}
+	}
+	if actions[rolloutActionRollback] {
+		t.Error("rollback offered without a Deployment workloadRef")
+	}
+}
+
+func TestRevisionRole(t *testing.T) {
+	if got := revisionRole(rolloutStrategyBlueGreen, false, true); got != revisionRoleGreen {
+		t.Errorf("blue-green updated role = %q", got)
+	}
+	if got := revisionRole(rolloutStrategyBlueGreen, true, false); got != revisionRoleBlue {
+		t.Errorf("blue-green stable role = %q", got)
+	}
+	if got := revisionRole(rolloutStrategyPartition, false, true); got != revisionRoleCanary {
+		t.Errorf("partition updated role = %q", got)
+	}
+	if got := revisionRole(rolloutStrategyCanary, false, false); got != revisionRolePrevious {
+		t.Errorf("old revision role = %q", got)
+	}
+}
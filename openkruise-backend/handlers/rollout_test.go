@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func imageWorkload() map[string]interface{} {
+	return map[string]interface{}{
+		"spec": map[string]interface{}{"template": map[string]interface{}{"spec": map[string]interface{}{
+			"initContainers": []interface{}{map[string]interface{}{"name": "init", "image": "init:v1"}},
+			"containers": []interface{}{
+				map[string]interface{}{"name": "app", "image": "app:v1"},
+				map[string]interface{}{"name": "sidecar", "image": "envoy:v1"},
+			},
+		}}},
+	}
+}
+
+func TestApplyImageUpdates(t *testing.T) {
+	workload := imageWorkload()
+	previous, err := applyImageUpdates(workload, []rolloutImageUpdate{
+		{Container: "app", Image: "app:v2"},
+		{Container: "sidecar", Image: "envoy:v2"},
+		{Container: "init", Image: "init:v2", IsInit: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(previous, ",") != "app:v1,envoy:v1,init:v1" {
+		t.Errorf("previous = %v", previous)
+	}
+	if images := templateImages(workload, []string{"spec", "template"}); strings.Join(images, ",") != "app:v2,envoy:v2,init:v2" {
+		t.Errorf("images = %v", images)
+	}
+
+	// A missing container fails the whole update.
+	workload = imageWorkload()
+	if _, err := applyImageUpdates(workload, []rolloutImageUpdate{
+		{Container: "app", Image: "app:v2"},
+		{Container: "app", Image: "app:v2", IsInit: true},
+	}); err == nil {
+		t.Fatal("expected an error for a missing initContainer")
+	}
+	containers, _, _ := unstructured.NestedSlice(workload, "spec", "template", "spec", "containers")
+	if image := containers[0].(map[string]interface{})["image"]; image != "app:v1" {
+		t.Errorf("failed update changed the workload: image = %v", image)
+	}
+}
+
+func TestBindSetRolloutImageRequest(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		wantOK bool
+	}{
+		{name: "single container", body: `{"container":"app","image":"app:v2"}`, wantOK: true},
+		{name: "images list", body: `{"images":[{"container":"app","image":"app:v2"},{"container":"app","image":"app:v2","isInitContainer":true}]}`, wantOK: true},
+		{name: "both forms", body: `{"container":"app","image":"app:v2","images":[{"container":"sidecar","image":"envoy:v2"}]}`},
+		{name: "duplicate container", body: `{"images":[{"container":"app","image":"app:v2"},{"container":"app","image":"app:v3"}]}`},
+		{name: "empty", body: `{}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			if _, ok := bindSetRolloutImageRequest(c); ok != tt.wantOK {
+				t.Errorf("ok = %v, want %v: %s", ok, tt.wantOK, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestImageChangeCause(t *testing.T) {
+	cause := imageChangeCause([]rolloutImageUpdate{{Container: "app", Image: "app:v2"}, {Container: "sidecar", Image: "envoy:v2"}})
+	if cause != "set image app=app:v2,sidecar=envoy:v2" {
+		t.Errorf("cause = %q", cause)
+	}
+}
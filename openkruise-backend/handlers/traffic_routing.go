@@ -0,0 +1,396 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/openkruise/kruise-dashboard/extensions-backend/pkg/logger"
+	"github.com/openkruise/kruise-dashboard/extensions-backend/pkg/response"
+	"go.uber.org/zap"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	// kruise-rollout creates "<service>-canary" and "<ingress>-canary" next to the originals.
+	canaryNameSuffix = "-canary"
+
+	nginxAnnotationPrefix = "nginx.ingress.kubernetes.io"
+	albAnnotationPrefix   = "alb.ingress.kubernetes.io"
+	ingressClassAliyunALB = "aliyun-alb"
+
+	headerMatchExact             = "Exact"
+	headerMatchRegularExpression = "RegularExpression"
+)
+
+var httpRouteGVRs = []schema.GroupVersionResource{
+	{Group: "gateway.networking.k8s.io", Version: "v1", Resource: "httproutes"},
+	{Group: "gateway.networking.k8s.io", Version: "v1beta1", Resource: "httproutes"},
+}
+
+// TrafficService is a Service that a traffic routing sends stable or canary traffic to.
+type TrafficService struct {
+	Name     string            `json:"name"`
+	Exists   bool              `json:"exists"`
+	Selector map[string]string `json:"selector,omitempty"`
+}
+
+// TrafficObject is a routing object observed in the cluster. CanaryWeight and Matches are what the
+// object currently routes to the canary; InSync compares them with the current step and is unset
+// when the object's routing cannot be interpreted.
+type TrafficObject struct {
+	Kind         string                   `json:"kind"`
+	APIVersion   string                   `json:"apiVersion"`
+	Name         string                   `json:"name"`
+	Exists       bool                     `json:"exists"`
+	CanaryWeight *int64                   `json:"canaryWeight,omitempty"`
+	Matches      []map[string]interface{} `json:"matches,omitempty"`
+	InSync       *bool                    `json:"inSync,omitempty"`
+	Message      string                   `json:"message,omitempty"`
+}
+
+// TrafficRoute is one entry of spec.strategy.canary.trafficRoutings.
+type TrafficRoute struct {
+	StableService      TrafficService  `json:"stableService"`
+	CanaryService      TrafficService  `json:"canaryService"`
+	GracePeriodSeconds int64           `json:"gracePeriodSeconds"`
+	Objects            []TrafficObject `json:"objects"`
+}
+
+// TrafficRouting is the traffic routing of a rollout as observed in the cluster.
+type TrafficRouting struct {
+	CurrentStepIndex int64                    `json:"currentStepIndex"`
+	ExpectedWeight   *int64                   `json:"expectedWeight,omitempty"`
+	ExpectedMatches  []map[string]interface{} `json:"expectedMatches,omitempty"`
+	Routes           []TrafficRoute           `json:"routes"`
+	// InSync is false when any interpreted routing object disagrees with the current step. It can be
+	// false briefly while the controller is moving between steps.
+	InSync bool `json:"inSync"`
+}
+
+// expectedCanaryTraffic returns the canary weight and header matches that the current step asks
+// for. A rollout without a canary in progress expects no canary traffic; a step without traffic
+// leaves the weight unspecified.
+func expectedCanaryTraffic(obj map[string]interface{}) (*int64, []map[string]interface{}) {
+	currentIndex, _, hasCanary := rolloutProgress(obj)
+	phase, _, _ := unstructured.NestedString(obj, "status", "phase")
+	if !hasCanary || currentIndex < 1 || phase == "Healthy" || phase == "Completed" {
+		zero := int64(0)
+		return &zero, nil
+	}
+
+	steps := rolloutSteps(obj)
+	if int(currentIndex) > len(steps) {
+		return nil, nil
+	}
+	step := steps[currentIndex-1]
+	var weight *int64
+	if percent, err := strconv.ParseInt(strings.TrimSuffix(step.Traffic, "%"), 10, 64); err == nil && step.Traffic != "" {
+		weight = &percent
+	}
+	return weight, step.Matches
+}
+
+// ingressCanaryState reads the canary weight and header match from the annotations of a canary
+// Ingress managed by kruise-rollout.
+func ingressCanaryState(annotations map[string]string, classType string) (*int64, []map[string]interface{}) {
+	prefix := nginxAnnotationPrefix
+	if classType == ingressClassAliyunALB {
+		prefix = albAnnotationPrefix
+	}
+	if annotations[prefix+"/canary"] != "true" {
+		zero := int64(0)
+		return &zero, nil
+	}
+
+	var weight *int64
+	if raw, ok := annotations[prefix+"/canary-weight"]; ok {
+		if parsed, err := strconv.ParseInt(raw, 10, 64); err == nil {
+			weight = &parsed
+		}
+	}
+
+	var matches []map[string]interface{}
+	if header := annotations[prefix+"/canary-by-header"]; header != "" {
+		headerMatch := map[string]interface{}{"name": header, "type": headerMatchExact}
+		if value, ok := annotations[prefix+"/canary-by-header-value"]; ok {
+			headerMatch["value"] = value
+		} else if pattern, ok := annotations[prefix+"/canary-by-header-pattern"]; ok {
+			headerMatch["value"] = pattern
+			headerMatch["type"] = headerMatchRegularExpression
+		}
+		matches = append(matches, map[string]interface{}{"headers": []interface{}{headerMatch}})
+	}
+	return weight, matches
+}
+
+// httpRouteCanaryState reads the canary weight from the backendRefs weights of an HTTPRoute and
+// collects the matches of rules that route only to the canary Service.
+func httpRouteCanaryState(route map[string]interface{}, stableService, canaryService string) (*int64, []map[string]interface{}) {
+	rules, _, _ := unstructured.NestedSlice(route, "spec", "rules")
+	var weight *int64
+	var matches []map[string]interface{}
+	for _, raw := range rules {
+		rule, _ := raw.(map[string]interface{})
+		backendRefs, _, _ := unstructured.NestedSlice(rule, "backendRefs")
+
+		var stableWeight, canaryWeight int64
+		hasStable, hasCanary := false, false
+		for _, rawRef := range backendRefs {
+			ref, _ := rawRef.(map[string]interface{})
+			name, _, _ := unstructured.NestedString(ref, "name")
+			refWeight, found, _ := unstructured.NestedInt64(ref, "weight")
+			if !found {
+				// Gateway API defaults a backendRef's weight to 1.
+				refWeight = 1
+			}
+			switch name {
+			case stableService:
+				hasStable = true
+				stableWeight += refWeight
+			case canaryService:
+				hasCanary = true
+				canaryWeight += refWeight
+			}
+		}
+
+		switch {
+		case hasCanary && !hasStable:
+			ruleMatches, _, _ := unstructured.NestedSlice(rule, "matches")
+			for _, m := range ruleMatches {
+				if match, ok := m.(map[string]interface{}); ok {
+					matches = append(matches, match)
+				}
+			}
+		case hasStable && weight == nil:
+			percent := int64(0)
+			if total := stableWeight + canaryWeight; total > 0 {
+				percent = canaryWeight * 100 / total
+			}
+			weight = &percent
+		}
+	}
+	return weight, matches
+}
+
+// headerMatchKeys flattens header matches to sorted "name=value" keys for comparison.
+func headerMatchKeys(matches []map[string]interface{}) []string {
+	keys := []string{}
+	for _, match := range matches {
+		headers, _, _ := unstructured.NestedSlice(match, "headers")
+		for _, raw := range headers {
+			header, _ := raw.(map[string]interface{})
+			name, _, _ := unstructured.NestedString(header, "name")
+			value, _, _ := unstructured.NestedString(header, "value")
+			keys = append(keys, strings.ToLower(name)+"="+value)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// trafficInSync compares observed routing with the expected weight and header matches. Only the
+// parts the current step specifies are compared.
+func trafficInSync(expectedWeight *int64, expectedMatches []map[string]interface{}, weight *int64, matches []map[string]interface{}) (bool, string) {
+	if expectedWeight != nil {
+		observed := int64(0)
+		if weight != nil {
+			observed = *weight
+		}
+		if observed != *expectedWeight {
+			return false, fmt.Sprintf("canary weight is %d%%, the current step expects %d%%", observed, *expectedWeight)
+		}
+	}
+	if len(expectedMatches) > 0 {
+		if strings.Join(headerMatchKeys(matches), ",") != strings.Join(headerMatchKeys(expectedMatches), ",") {
+			return false, "header matches differ from the current step"
+		}
+	}
+	return true, ""
+}
+
+func getTrafficService(namespace, name string) TrafficService {
+	svc := TrafficService{Name: name}
+	if name == "" {
+		return svc
+	}
+	service, err := GetK8sClient().CoreV1().Services(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			logger.Log.Warn("Failed to get traffic routing service",
+				zap.String("namespace", namespace),
+				zap.String("name", name),
+				zap.Error(err),
+			)
+		}
+		return svc
+	}
+	svc.Exists = true
+	svc.Selector = service.Spec.Selector
+	return svc
+}
+
+func inspectIngress(namespace string, ingress map[string]interface{}) TrafficObject {
+	name, _, _ := unstructured.NestedString(ingress, "name")
+	classType, _, _ := unstructured.NestedString(ingress, "classType")
+	canaryName := name + canaryNameSuffix
+	obj := TrafficObject{Kind: "Ingress", APIVersion: "networking.k8s.io/v1", Name: canaryName}
+
+	if _, err := GetK8sClient().NetworkingV1().Ingresses(namespace).Get(context.TODO(), name, metav1.GetOptions{}); err != nil {
+		obj.Message = fmt.Sprintf("ingress %s: %v", name, err)
+		return obj
+	}
+	canary, err := GetK8sClient().NetworkingV1().Ingresses(namespace).Get(context.TODO(), canaryName, metav1.GetOptions{})
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			obj.Message = err.Error()
+			return obj
+		}
+		// No canary Ingress means no canary traffic.
+		zero := int64(0)
+		obj.CanaryWeight = &zero
+		return obj
+	}
+	obj.Exists = true
+	obj.CanaryWeight, obj.Matches = ingressCanaryState(canary.Annotations, classType)
+	return obj
+}
+
+func inspectHTTPRoute(namespace string, gateway map[string]interface{}, stableService, canaryService string) TrafficObject {
+	name, _, _ := unstructured.NestedString(gateway, "httpRouteName")
+	obj := TrafficObject{Kind: "HTTPRoute", Name: name}
+
+	var lastErr error
+	for _, gvr := range httpRouteGVRs {
+		route, err := GetDynamicClient().Resource(gvr).Namespace(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		obj.APIVersion = gvr.GroupVersion().String()
+		obj.Exists = true
+		obj.CanaryWeight, obj.Matches = httpRouteCanaryState(route.Object, stableService, canaryService)
+		return obj
+	}
+	obj.APIVersion = httpRouteGVRs[0].GroupVersion().String()
+	obj.Message = lastErr.Error()
+	return obj
+}
+
+// resourceForKind resolves the resource of a kind through discovery.
+func resourceForKind(apiVersion, kind string) (schema.GroupVersionResource, error) {
+	gv, err := schema.ParseGroupVersion(apiVersion)
+	if err != nil {
+		return schema.GroupVersionResource{}, err
+	}
+	resources, err := GetK8sClient().Discovery().ServerResourcesForGroupVersion(apiVersion)
+	if err != nil {
+		return schema.GroupVersionResource{}, err
+	}
+	for _, r := range resources.APIResources {
+		if r.Kind == kind && !strings.Contains(r.Name, "/") {
+			return gv.WithResource(r.Name), nil
+		}
+	}
+	return schema.GroupVersionResource{}, fmt.Errorf("kind %s is not served by %s", kind, apiVersion)
+}
+
+func inspectCustomNetworkRef(namespace string, ref map[string]interface{}) TrafficObject {
+	apiVersion, _, _ := unstructured.NestedString(ref, "apiVersion")
+	kind, _, _ := unstructured.NestedString(ref, "kind")
+	name, _, _ := unstructured.NestedString(ref, "name")
+	obj := TrafficObject{Kind: kind, APIVersion: apiVersion, Name: name}
+
+	gvr, err := resourceForKind(apiVersion, kind)
+	if err != nil {
+		obj.Message = err.Error()
+		return obj
+	}
+	if _, err := GetDynamicClient().Resource(gvr).Namespace(namespace).Get(context.TODO(), name, metav1.GetOptions{}); err != nil {
+		obj.Message = err.Error()
+		return obj
+	}
+	obj.Exists = true
+	obj.Message = "routing of custom network references is defined by lua scripts and is not interpreted"
+	return obj
+}
+
+// inspectTrafficRouting resolves the Services and routing objects of every entry in the trafficRoutings
+// of the rollout's canary or blueGreen strategy. It returns nil for rollouts without traffic routing.
+func inspectTrafficRouting(rollout *unstructured.Unstructured) *TrafficRouting {
+	routings, _, _ := unstructured.NestedSlice(rollout.Object, rolloutTrafficRoutingsPath(rollout.Object)...)
+	if len(routings) == 0 {
+		return nil
+	}
+	namespace := rollout.GetNamespace()
+	currentIndex, _, _ := rolloutProgress(rollout.Object)
+	expectedWeight, expectedMatches := expectedCanaryTraffic(rollout.Object)
+
+	result := &TrafficRouting{
+		CurrentStepIndex: currentIndex,
+		ExpectedWeight:   expectedWeight,
+		ExpectedMatches:  expectedMatches,
+		Routes:           make([]TrafficRoute, 0, len(routings)),
+		InSync:           true,
+	}
+	for _, raw := range routings {
+		routing, _ := raw.(map[string]interface{})
+		serviceName, _, _ := unstructured.NestedString(routing, "service")
+		gracePeriod, _, _ := unstructured.NestedInt64(routing, "gracePeriodSeconds")
+		route := TrafficRoute{
+			StableService:      getTrafficService(namespace, serviceName),
+			CanaryService:      getTrafficService(namespace, serviceName+canaryNameSuffix),
+			GracePeriodSeconds: gracePeriod,
+			Objects:            []TrafficObject{},
+		}
+
+		if ingress, found, _ := unstructured.NestedMap(routing, "ingress"); found {
+			route.Objects = append(route.Objects, inspectIngress(namespace, ingress))
+		}
+		if gateway, found, _ := unstructured.NestedMap(routing, "gateway"); found {
+			route.Objects = append(route.Objects, inspectHTTPRoute(namespace, gateway, serviceName, serviceName+canaryNameSuffix))
+		}
+		customRefs, _, _ := unstructured.NestedSlice(routing, "customNetworkRefs")
+		for _, rawRef := range customRefs {
+			if ref, ok := rawRef.(map[string]interface{}); ok {
+				route.Objects = append(route.Objects, inspectCustomNetworkRef(namespace, ref))
+			}
+		}
+
+		for i := range route.Objects {
+			obj := &route.Objects[i]
+			if obj.CanaryWeight == nil && len(obj.Matches) == 0 {
+				continue
+			}
+			inSync, message := trafficInSync(expectedWeight, expectedMatches, obj.CanaryWeight, obj.Matches)
+			obj.InSync = &inSync
+			if !inSync {
+				obj.Message = message
+				result.InSync = false
+			}
+		}
+		result.Routes = append(result.Routes, route)
+	}
+	return result
+}
+
+// GetRolloutTrafficRouting reports the traffic routing of a rollout and whether it agrees with
+// the current step.
+func GetRolloutTrafficRouting(c *gin.Context) {
+	rollout, ok := getRolloutV2(c)
+	if !ok {
+		return
+	}
+	routing := inspectTrafficRouting(rollout)
+	if routing == nil {
+		response.NotFound(c, "traffic routing")
+		return
+	}
+	response.Success(c, routing)
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"testing"
+)
+
+func TestIngressCanaryState(t *testing.T) {
+	weight, matches := ingressCanaryState(map[string]string{
+		"nginx.ingress.kubernetes.io/canary":                 "true",
+		"nginx.ingress.kubernetes.io/canary-weight":          "20",
+		"nginx.ingress.kubernetes.io/canary-by-header":       "x-canary",
+		"nginx.ingress.kubernetes.io/canary-by-header-value": "true",
+	}, "nginx")
+	if weight == nil || *weight != 20 {
+		t.Errorf("weight = %v, want 20", weight)
+	}
+	if keys := headerMatchKeys(matches); len(keys) != 1 || keys[0] != "x-canary=true" {
+		t.Errorf("matches = %v", keys)
+	}
+
+	// ALB ingresses use their own annotation prefix.
+	weight, _ = ingressCanaryState(map[string]string{
+		"alb.ingress.kubernetes.io/canary":        "true",
+		"alb.ingress.kubernetes.io/canary-weight": "50",
+	}, ingressClassAliyunALB)
+	if weight == nil || *weight != 50 {
+		t.Errorf("alb weight = %v, want 50", weight)
+	}
+
+	weight, _ = ingressCanaryState(map[string]string{}, "nginx")
+	if weight == nil || *weight != 0 {
+		t.Errorf("non-canary ingress weight = %v, want 0", weight)
+	}
+}
+
+func TestHTTPRouteCanaryState(t *testing.T) {
+	route := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"rules": []interface{}{
+				map[string]interface{}{
+					"matches": []interface{}{map[string]interface{}{
+						"headers": []interface{}{map[string]interface{}{"name": "X-Canary", "value": "true"}},
+					}},
+					"backendRefs": []interface{}{map[string]interface{}{"name": "web-canary"}},
+				},
+				map[string]interface{}{
+					"backendRefs": []interface{}{
+						map[string]interface{}{"name": "web", "weight": int64(70)},
+						map[string]interface{}{"name": "web-canary", "weight": int64(30)},
+					},
+				},
+			},
+		},
+	}
+
+	weight, matches := httpRouteCanaryState(route, "web", "web-canary")
+	if weight == nil || *weight != 30 {
+		t.Errorf("weight = %v, want 30", weight)
+	}
+	if keys := headerMatchKeys(matches); len(keys) != 1 || keys[0] != "x-canary=true" {
+		t.Errorf("matches = %v", keys)
+	}
+}
+
+func TestExpectedCanaryTraffic(t *testing.T) {
+	rollout := map[string]interface{}{
+		"spec": map[string]interface{}{"strategy": map[string]interface{}{"canary": map[string]interface{}{
+			"steps": []interface{}{
+				map[string]interface{}{"replicas": "20%", "traffic": "10%"},
+				map[string]interface{}{"replicas": "50%"},
+			},
+		}}},
+		"status": map[string]interface{}{
+			"phase":        "Progressing",
+			"canaryStatus": map[string]interface{}{"currentStepIndex": int64(1)},
+		},
+	}
+	if weight, _ := expectedCanaryTraffic(rollout); weight == nil || *weight != 10 {
+		t.Errorf("step 1 weight = %v, want 10", weight)
+	}
+
+	rollout["status"].(map[string]interface{})["canaryStatus"] = map[string]interface{}{"currentStepIndex": int64(2)}
+	if weight, _ := expectedCanaryTraffic(rollout); weight != nil {
+		t.Errorf("step without traffic weight = %v, want unspecified", *weight)
+	}
+
+	rollout["status"] = map[string]interface{}{"phase": "Healthy"}
+	if weight, _ := expectedCanaryTraffic(rollout); weight == nil || *weight != 0 {
+		t.Errorf("healthy rollout weight = %v, want 0", weight)
+	}
+}
+
+func TestTrafficInSync(t *testing.T) {
+	ten, twenty := int64(10), int64(20)
+	if ok, _ := trafficInSync(&ten, nil, &ten, nil); !ok {
+		t.Error("equal weights should be in sync")
+	}
+	if ok, message := trafficInSync(&ten, nil, &twenty, nil); ok || message == "" {
+		t.Error("different weights should not be in sync")
+	}
+	if ok, _ := trafficInSync(nil, nil, &twenty, nil); !ok {
+		t.Error("an unspecified weight should not be compared")
+	}
+
+	expected := []map[string]interface{}{{"headers": []interface{}{map[string]interface{}{"name": "user", "value": "beta"}}}}
+	if ok, _ := trafficInSync(nil, expected, nil, nil); ok {
+		t.Error("missing header matches should not be in sync")
+	}
+}
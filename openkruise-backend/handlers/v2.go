@@ -0,0 +1,248 @@
+package handlers
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+	"github.com/openkruise/kruise-dashboard/extensions-backend/pkg/logger"
+	"github.com/openkruise/kruise-dashboard/extensions-backend/pkg/response"
+	"go.uber.org/zap"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// The /api/v2 handlers return typed DTOs. Every response uses the standard {data: ...} envelope;
+// lists always carry ListData, never a bare array or a null.
+
+// getRolloutV2 fetches a rollout, responding with 404 or 500 on failure.
+func getRolloutV2(c *gin.Context) (*unstructured.Unstructured, bool) {
+	namespace := c.Param("namespace")
+	name := c.Param("name")
+
+	rollout, err := GetDynamicClient().Resource(rolloutGVR).Namespace(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			response.NotFound(c, "rollout")
+			return nil, false
+		}
+		logger.Log.Error("Failed to get rollout",
+			zap.String("namespace", namespace),
+			zap.String("name", name),
+			zap.Error(err),
+		)
+		response.InternalError(c, err)
+		return nil, false
+	}
+	return rollout, true
+}
+
+// getWorkloadV2 resolves the :type parameter and fetches the workload, responding on failure.
+func getWorkloadV2(c *gin.Context) (*unstructured.Unstructured, string, WorkloadTypeInfo, bool) {
+	namespace := c.Param("namespace")
+	workloadType := c.Param("type")
+	name := c.Param("name")
+
+	info, err := ResolveWorkloadType(workloadType)
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return nil, "", info, false
+	}
+
+	workload, err := GetDynamicClient().Resource(info.GVR).Namespace(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			response.NotFound(c, workloadType)
+			return nil, "", info, false
+		}
+		logger.Log.Error("Failed to get workload",
+			zap.String("namespace", namespace),
+			zap.String("type", workloadType),
+			zap.String("name", name),
+			zap.Error(err),
+		)
+		response.InternalError(c, err)
+		return nil, "", info, false
+	}
+	return workload, workloadType, info, true
+}
+
+// ListRolloutsV2 lists rollout summaries in a namespace; active=true keeps the rollouts that
+// ListActiveRollouts returns, filtered before paging.
+func ListRolloutsV2(c *gin.Context) {
+	namespace := c.Param("namespace")
+
+	q, ok := bindListQuery(c, sortByName, sortByCreationTimestamp)
+	if !ok {
+		return
+	}
+
+	var list *unstructured.UnstructuredList
+	var err error
+	if c.Query("active") == "true" {
+		list, err = listFilteredPage(context.TODO(), rolloutGVR, namespace, metav1.ListOptions{}, q, isRolloutActive)
+	} else {
+		list, err = listResourcePage(context.TODO(), rolloutGVR, namespace, metav1.ListOptions{}, q)
+	}
+	if err != nil {
+		if handleListError(c, err) {
+			return
+		}
+		logger.Log.Error("Failed to list rollouts",
+			zap.String("namespace", namespace),
+			zap.Error(err),
+		)
+		response.InternalError(c, err)
+		return
+	}
+
+	summaries := make([]RolloutSummary, 0, len(list.Items))
+	for i := range list.Items {
+		summaries = append(summaries, summarizeRollout(&list.Items[i]))
+	}
+
+	response.Success(c, newListData(summaries, list.GetContinue()))
+}
+
+// GetRolloutV2 returns a rollout's detail.
+func GetRolloutV2(c *gin.Context) {
+	rollout, ok := getRolloutV2(c)
+	if !ok {
+		return
+	}
+	detail := detailRollout(rollout)
+	detail.TrafficRouting = inspectTrafficRouting(rollout)
+	response.Success(c, detail)
+}
+
+// GetRolloutHistoryV2 returns the revision history of a rollout's workload, newest first.
+func GetRolloutHistoryV2(c *gin.Context) {
+	rollout, ok := getRolloutV2(c)
+	if !ok {
+		return
+	}
+
+	history, ok := rolloutHistory(c, rollout)
+	if !ok {
+		return
+	}
+	response.Success(c, newListData(history, ""))
+}
+
+// rolloutWorkloadPodsV2 resolves the pods and revisions of a rollout's workload. A rollout without
+// a workloadRef has neither.
+func rolloutWorkloadPodsV2(c *gin.Context, rollout *unstructured.Unstructured) ([]interface{}, []Revision, bool) {
+	namespace := rollout.GetNamespace()
+	workloadRef := extractWorkloadRefFromRollout(rollout)
+	refKind, _ := workloadRef["kind"].(string)
+	refName, _ := workloadRef["name"].(string)
+	if refKind == "" || refName == "" {
+		return []interface{}{}, []Revision{}, true
+	}
+
+	workload, pods, items, usedFallback, err := getWorkloadPodsWithFallback(namespace, refKind, refName)
+	if err != nil {
+		response.InternalError(c, err)
+		return nil, nil, false
+	}
+	if usedFallback {
+		return items, []Revision{}, true
+	}
+
+	rawRevisions := buildRevisionsForWorkload(refKind, namespace, workload, pods, rollout)
+	revisions := make([]Revision, 0, len(rawRevisions))
+	for _, raw := range rawRevisions {
+		revisions = append(revisions, revisionFromMap(raw))
+	}
+	return items, revisions, true
+}
+
+// GetRolloutPodsV2 lists the pods of a rollout's workload.
+func GetRolloutPodsV2(c *gin.Context) {
+	rollout, ok := getRolloutV2(c)
+	if !ok {
+		return
+	}
+	items, _, ok := rolloutWorkloadPodsV2(c, rollout)
+	if !ok {
+		return
+	}
+	response.Success(c, newListData(summarizePods(items), ""))
+}
+
+// GetRolloutRevisionsV2 lists the revisions of a rollout's workload with their pods.
+func GetRolloutRevisionsV2(c *gin.Context) {
+	rollout, ok := getRolloutV2(c)
+	if !ok {
+		return
+	}
+	_, revisions, ok := rolloutWorkloadPodsV2(c, rollout)
+	if !ok {
+		return
+	}
+	response.Success(c, newListData(revisions, ""))
+}
+
+// ListWorkloadsV2 lists workload summaries of one type in a namespace.
+func ListWorkloadsV2(c *gin.Context) {
+	namespace := c.Param("namespace")
+	workloadType := c.Param("type")
+
+	info, err := ResolveWorkloadType(workloadType)
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+	q, ok := bindListQuery(c, sortByName, sortByCreationTimestamp, sortByReadiness)
+	if !ok {
+		return
+	}
+
+	list, err := listResourcePage(context.TODO(), info.GVR, namespace, metav1.ListOptions{}, q)
+	if err != nil {
+		if handleListError(c, err) {
+			return
+		}
+		logger.Log.Error("Failed to list workloads",
+			zap.String("namespace", namespace),
+			zap.String("type", workloadType),
+			zap.Error(err),
+		)
+		response.InternalError(c, err)
+		return
+	}
+
+	summaries := make([]WorkloadSummary, 0, len(list.Items))
+	for i := range list.Items {
+		summaries = append(summaries, summarizeWorkload(&list.Items[i], workloadType, info))
+	}
+	response.Success(c, newListData(summaries, list.GetContinue()))
+}
+
+// GetWorkloadV2 returns a workload summary.
+func GetWorkloadV2(c *gin.Context) {
+	workload, workloadType, info, ok := getWorkloadV2(c)
+	if !ok {
+		return
+	}
+	summary := summarizeWorkload(workload, workloadType, info)
+	if info.Scalable {
+		summary.Autoscaler = workloadHPASummary(workload.GetNamespace(), info, workload.GetName())
+	}
+	response.Success(c, summary)
+}
+
+// GetWorkloadPodsV2 lists the pods of a workload.
+func GetWorkloadPodsV2(c *gin.Context) {
+	workload, _, info, ok := getWorkloadV2(c)
+	if !ok {
+		return
+	}
+
+	items, err := listWorkloadPods(workload.GetNamespace(), workload.GetName(), info, workload)
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+	response.Success(c, newListData(summarizePods(items), ""))
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+func TestListRolloutsV2Active(t *testing.T) {
+	previous := dynamicClient
+	defer func() { dynamicClient = previous }()
+
+	rollout := func(name, phase string) runtime.Object {
+		obj := &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "rollouts.kruise.io/v1beta1",
+			"kind":       "Rollout",
+			"metadata":   map[string]interface{}{"name": name, "namespace": "default"},
+		}}
+		if phase != "" {
+			obj.Object["status"] = map[string]interface{}{"phase": phase}
+		}
+		return obj
+	}
+	dynamicClient = dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{rolloutGVR: "RolloutList"},
+		rollout("a", "Healthy"), rollout("b", "Progressing"), rollout("c", ""),
+		rollout("d", "Progressing"), rollout("e", "Disabled"), rollout("f", "Paused"),
+	)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/api/v2/rollouts/:namespace", ListRolloutsV2)
+
+	var names []string
+	next := ""
+	for pages := 0; pages < 10; pages++ {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v2/rollouts/default?active=true&limit=2&continue="+next, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+		}
+		var resp struct {
+			Data ListData[RolloutSummary] `json:"data"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("unmarshal: %v", err)
+		}
+		if resp.Data.Continue != "" && len(resp.Data.Items) != 2 {
+			t.Errorf("page %d has %d items, want a full page before the last", pages, len(resp.Data.Items))
+		}
+		for _, item := range resp.Data.Items {
+			names = append(names, item.Name)
+		}
+		if next = resp.Data.Continue; next == "" {
+			break
+		}
+	}
+	if want := []string{"b", "d", "f"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("active rollouts = %v, want %v", names, want)
+	}
+}
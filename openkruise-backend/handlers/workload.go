@@ -2,8 +2,12 @@ package handlers
 
 import (
 	"context"
+	"encoding/base64"
 	"fmt"
+	"net/http"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -16,7 +20,8 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 )
 
-// GetWorkload returns a specific workload by type and name
+// GetWorkload returns a specific workload by type and name: the raw object, or with view=summary
+// its WorkloadSummary, which carries the evaluated health.
 func GetWorkload(c *gin.Context) {
 	namespace := c.Param("namespace")
 	workloadType := c.Param("type")
@@ -27,6 +32,11 @@ func GetWorkload(c *gin.Context) {
 		response.BadRequest(c, err.Error())
 		return
 	}
+	view := c.DefaultQuery("view", listViewFull)
+	if view != listViewFull && view != listViewSummary {
+		response.BadRequest(c, "view must be full or summary")
+		return
+	}
 
 	workload, err := GetDynamicClient().Resource(info.GVR).Namespace(namespace).Get(context.TODO(), name, metav1.GetOptions{})
 	if err != nil {
@@ -40,6 +50,10 @@ func GetWorkload(c *gin.Context) {
 		return
 	}
 
+	if view == listViewSummary {
+		response.Success(c, summarizeWorkload(workload, workloadType, info))
+		return
+	}
 	response.Success(c, workload)
 }
 
@@ -67,15 +81,24 @@ func GetWorkloadPods(c *gin.Context) {
 		return
 	}
 
-	// Extract selector labels from the workload
-	labelSelector := extractLabelSelector(workload.Object)
-
-	// If no selector found, use a fallback based on workload name
-	if labelSelector == "" {
-		labelSelector = "app=" + name
+	items, err := listWorkloadPods(namespace, name, info, workload)
+	if err != nil {
+		response.InternalError(c, err)
+		return
 	}
 
-	// Get pods using the label selector
+	response.Success(c, gin.H{
+		"workload":     workload.Object,
+		"pods":         items,
+		"probeResults": collectPodProbeResults(namespace, items),
+	})
+}
+
+// listWorkloadPods lists the pods owned by a workload. Pods are selected with the
+// workload's label selector (falling back to app=<name>) and filtered by owner reference.
+func listWorkloadPods(namespace, name string, info WorkloadTypeInfo, workload *unstructured.Unstructured) ([]interface{}, error) {
+	labelSelector := workloadPodSelector(info, workload)
+
 	podGVR := schema.GroupVersionResource{Group: "", Version: "v1", Resource: "pods"}
 	pods, err := GetDynamicClient().Resource(podGVR).Namespace(namespace).List(context.TODO(), metav1.ListOptions{
 		LabelSelector: labelSelector,
@@ -86,27 +109,44 @@ func GetWorkloadPods(c *gin.Context) {
 			zap.String("labelSelector", labelSelector),
 			zap.Error(err),
 		)
-		response.InternalError(c, err)
-		return
+		return nil, err
 	}
 
-	// Filter pods by owner reference
-	items := filterPodsByOwner(pods.Items, name, info.Kind)
+	return filterPodsByOwner(pods.Items, name, info.Kind), nil
+}
 
-	response.Success(c, gin.H{
-		"workload": workload.Object,
-		"pods":     items,
-	})
+// workloadPodSelector returns the label selector matching a workload's pods, falling back to
+// app=<name> for workloads without one.
+func workloadPodSelector(info WorkloadTypeInfo, workload *unstructured.Unstructured) string {
+	if selector := workloadLabelSelector(info, workload.Object); selector != "" {
+		return selector
+	}
+	return "app=" + workload.GetName()
+}
+
+// workloadLabelSelector extracts the label selector of a workload using the selector path of its
+// type, or selects by controller UID for types that label their pods with it.
+func workloadLabelSelector(info WorkloadTypeInfo, workloadObj map[string]interface{}) string {
+	if info.ControllerUIDLabel != "" {
+		if uid, _, _ := unstructured.NestedString(workloadObj, "metadata", "uid"); uid != "" {
+			return info.ControllerUIDLabel + "=" + uid
+		}
+	}
+	if len(info.SelectorPath) == 0 {
+		return extractLabelSelector(workloadObj)
+	}
+	return extractLabelSelectorAt(workloadObj, info.SelectorPath...)
 }
 
 // extractLabelSelector extracts the label selector string from a workload object
 func extractLabelSelector(workloadObj map[string]interface{}) string {
-	spec, ok := workloadObj["spec"].(map[string]interface{})
-	if !ok {
-		return ""
-	}
-	selector, ok := spec["selector"].(map[string]interface{})
-	if !ok {
+	return extractLabelSelectorAt(workloadObj, "spec", "selector")
+}
+
+// extractLabelSelectorAt extracts the label selector string found at the given field path
+func extractLabelSelectorAt(workloadObj map[string]interface{}, path ...string) string {
+	selector, found, _ := unstructured.NestedMap(workloadObj, path...)
+	if !found {
 		return ""
 	}
 
@@ -218,8 +258,16 @@ func ListWorkloads(c *gin.Context) {
 		return
 	}
 
-	workloads, err := GetDynamicClient().Resource(info.GVR).Namespace(namespace).List(context.TODO(), metav1.ListOptions{})
+	q, ok := bindListQuery(c, sortByName, sortByCreationTimestamp, sortByReadiness)
+	if !ok {
+		return
+	}
+
+	workloads, err := listResourcePage(context.TODO(), info.GVR, namespace, metav1.ListOptions{}, q)
 	if err != nil {
+		if handleListError(c, err) {
+			return
+		}
 		logger.Log.Error("Failed to list workloads",
 			zap.String("namespace", namespace),
 			zap.String("type", workloadType),
@@ -229,37 +277,168 @@ func ListWorkloads(c *gin.Context) {
 		return
 	}
 
+	if q.Summary {
+		summaries := make([]WorkloadSummary, 0, len(workloads.Items))
+		for i := range workloads.Items {
+			summaries = append(summaries, summarizeWorkload(&workloads.Items[i], workloadType, info))
+		}
+		response.Success(c, gin.H{
+			"items":     summaries,
+			"continue":  workloads.GetContinue(),
+			"namespace": namespace,
+			"type":      workloadType,
+		})
+		return
+	}
+
+	stripManagedFields(workloads.Items)
 	response.Success(c, workloads)
 }
 
-// kruiseWorkloadGVRs defines the GVRs for listing all workloads.
-// These must stay in sync with workloadTypeRegistry in workload_types.go.
-var kruiseWorkloadGVRs = []schema.GroupVersionResource{
-	{Group: "apps.kruise.io", Version: "v1alpha1", Resource: "clonesets"},
-	{Group: "apps.kruise.io", Version: "v1beta1", Resource: "statefulsets"},
-	{Group: "apps.kruise.io", Version: "v1alpha1", Resource: "daemonsets"},
-	{Group: "apps.kruise.io", Version: "v1alpha1", Resource: "broadcastjobs"},
-	{Group: "apps.kruise.io", Version: "v1alpha1", Resource: "advancedcronjobs"},
+// workloadListItems converts a listed page to response items: summaries, or raw objects without
+// managed fields.
+func workloadListItems(list *unstructured.UnstructuredList, info WorkloadTypeInfo, q listQuery) []interface{} {
+	workloadType, _, _ := ResolveWorkloadKind(info.Kind)
+	items := make([]interface{}, 0, len(list.Items))
+	for i := range list.Items {
+		if q.Summary {
+			items = append(items, summarizeWorkload(&list.Items[i], workloadType, info))
+			continue
+		}
+		list.Items[i].SetManagedFields(nil)
+		items = append(items, list.Items[i].Object)
+	}
+	return items
+}
+
+// encodeAllWorkloadsContinue builds the continue token of ListAllWorkloads: the resource to resume
+// at and that resource's own continue token.
+func encodeAllWorkloadsContinue(resource, inner string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(resource + "\n" + inner))
+}
+
+func decodeAllWorkloadsContinue(token string) (string, string, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return "", "", errInvalidContinue
+	}
+	resource, inner, ok := strings.Cut(string(raw), "\n")
+	if !ok || resource == "" {
+		return "", "", errInvalidContinue
+	}
+	return resource, inner, nil
+}
+
+// sortedKruiseWorkloadTypes returns the types listed by ListAllWorkloads in a stable order, by
+// resource, so that continue tokens can name the type to resume at.
+func sortedKruiseWorkloadTypes() []WorkloadTypeInfo {
+	types := kruiseWorkloadTypes()
+	sort.Slice(types, func(i, j int) bool { return types[i].GVR.Resource < types[j].GVR.Resource })
+	return types
+}
+
+// listAllWorkloadsPage pages through the workload types one after another: a page holds up to
+// limit workloads, taken from the resumed type and then the following ones. Items are sorted
+// within each type.
+func listAllWorkloadsPage(ctx context.Context, namespace string, q listQuery) (map[string]interface{}, error) {
+	types := sortedKruiseWorkloadTypes()
+	start, inner := 0, ""
+	if q.Continue != "" {
+		resource, token, err := decodeAllWorkloadsContinue(q.Continue)
+		if err != nil {
+			return nil, err
+		}
+		start = -1
+		for i, info := range types {
+			if info.GVR.Resource == resource {
+				start = i
+				break
+			}
+		}
+		if start < 0 {
+			return nil, errInvalidContinue
+		}
+		inner = token
+	}
+
+	results := map[string]interface{}{}
+	next := ""
+	remaining := q.Limit
+	for i := start; i < len(types); i++ {
+		info := types[i]
+		typeQuery := q
+		typeQuery.Limit = remaining
+		typeQuery.Continue = ""
+		if i == start {
+			typeQuery.Continue = inner
+		}
+		list, err := listResourcePage(ctx, info.GVR, namespace, metav1.ListOptions{}, typeQuery)
+		if err != nil {
+			return nil, err
+		}
+		results[info.GVR.Resource] = workloadListItems(list, info, q)
+
+		if list.GetContinue() != "" {
+			next = encodeAllWorkloadsContinue(info.GVR.Resource, list.GetContinue())
+			break
+		}
+		if q.Limit > 0 {
+			remaining -= int64(len(list.Items))
+			if remaining <= 0 {
+				if i+1 < len(types) {
+					next = encodeAllWorkloadsContinue(types[i+1].GVR.Resource, "")
+				}
+				break
+			}
+		}
+	}
+	results["continue"] = next
+	return results, nil
 }
 
-// ListAllWorkloads lists all Kruise workload resources in a namespace
+// ListAllWorkloads lists all Kruise workload resources in a namespace, grouped by resource. With
+// limit or continue the types are paged one after another and the response carries the continue
+// token of the next page.
 func ListAllWorkloads(c *gin.Context) {
 	namespace := c.Param("namespace")
 	results := make(map[string][]interface{})
 
+	q, ok := bindListQuery(c, sortByName, sortByCreationTimestamp, sortByReadiness)
+	if !ok {
+		return
+	}
+	if q.Limit > 0 || q.Continue != "" {
+		page, err := listAllWorkloadsPage(context.TODO(), namespace, q)
+		if err != nil {
+			if handleListError(c, err) {
+				return
+			}
+			logger.Log.Error("Failed to list workloads",
+				zap.String("namespace", namespace),
+				zap.Error(err),
+			)
+			response.InternalError(c, err)
+			return
+		}
+		response.Success(c, page)
+		return
+	}
+
 	type result struct {
 		resource string
 		data     []interface{}
 		err      error
 	}
 
-	resultChan := make(chan result, len(kruiseWorkloadGVRs))
+	workloadTypes := kruiseWorkloadTypes()
+	resultChan := make(chan result, len(workloadTypes))
 
-	for _, gvr := range kruiseWorkloadGVRs {
-		go func(gvr schema.GroupVersionResource) {
+	for _, info := range workloadTypes {
+		go func(info WorkloadTypeInfo) {
 			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 			defer cancel()
 
+			gvr := info.GVR
 			list, err := GetDynamicClient().Resource(gvr).Namespace(namespace).List(ctx, metav1.ListOptions{})
 			if err != nil {
 				logger.Log.Warn("Failed to list workload resource",
@@ -275,20 +454,16 @@ func ListAllWorkloads(c *gin.Context) {
 				return
 			}
 
-			items := make([]interface{}, 0, len(list.Items))
-			for _, item := range list.Items {
-				items = append(items, item.Object)
-			}
-
+			sortUnstructured(list.Items, q)
 			resultChan <- result{
 				resource: gvr.Resource,
-				data:     items,
+				data:     workloadListItems(list, info, q),
 				err:      nil,
 			}
-		}(gvr)
+		}(info)
 	}
 
-	for i := 0; i < len(kruiseWorkloadGVRs); i++ {
+	for i := 0; i < len(workloadTypes); i++ {
 		select {
 		case res := <-resultChan:
 			results[res.resource] = res.data
@@ -329,6 +504,36 @@ func ScaleWorkload(c *gin.Context) {
 		return
 	}
 
+	// An active HPA would revert the replica count, so scaling it requires force=true. If the HPAs
+	// cannot be listed, one may exist, so that requires force=true too.
+	force := c.Query("force") == "true"
+	var warning gin.H
+	hpa, err := findWorkloadHPA(namespace, info, name)
+	if err != nil {
+		logger.Log.Warn("Failed to look up HPA before scaling",
+			zap.String("namespace", namespace),
+			zap.String("type", workloadType),
+			zap.String("name", name),
+			zap.Error(err),
+		)
+		message := fmt.Sprintf("Could not check whether a HorizontalPodAutoscaler controls %s %s: %v", workloadType, name, err)
+		if !force {
+			response.Error(c, http.StatusServiceUnavailable, message+"; retry or pass force=true", nil, errorCodeHPALookupFailed)
+			return
+		}
+		warning = gin.H{"code": errorCodeHPALookupFailed, "message": message}
+	} else if hpa != nil {
+		if summary := summarizeHPA(hpa); summary.Active {
+			message := fmt.Sprintf("HorizontalPodAutoscaler %s controls the replicas of %s %s (min %d, max %d); change its minReplicas/maxReplicas instead",
+				summary.Name, workloadType, name, summary.MinReplicas, summary.MaxReplicas)
+			if !force {
+				response.Error(c, http.StatusConflict, message, nil, errorCodeHPAControlsReplicas)
+				return
+			}
+			warning = gin.H{"code": errorCodeHPAControlsReplicas, "message": message}
+		}
+	}
+
 	patchBytes := []byte(fmt.Sprintf(`{"spec":{"replicas":%d}}`, replicas))
 	_, err = GetDynamicClient().Resource(info.GVR).Namespace(namespace).Patch(context.TODO(), name, types.MergePatchType, patchBytes, metav1.PatchOptions{}, "scale")
 	if err != nil {
@@ -350,10 +555,14 @@ func ScaleWorkload(c *gin.Context) {
 		zap.Int("replicas", replicas),
 	)
 
-	response.Success(c, gin.H{
+	result := gin.H{
 		"message":  fmt.Sprintf("Successfully scaled %s %s to %d replicas", workloadType, name, replicas),
 		"replicas": replicas,
-	})
+	}
+	if warning != nil {
+		result["warning"] = warning
+	}
+	response.Success(c, result)
 }
 
 // RestartWorkload restarts a workload by adding an annotation
@@ -385,37 +594,16 @@ func RestartWorkload(c *gin.Context) {
 	}
 
 	// Add restart annotation on the pod template metadata to trigger rollout
-	workloadObj := workload.Object
-	var spec map[string]interface{}
-	if s, ok := workloadObj["spec"].(map[string]interface{}); ok {
-		spec = s
-	} else {
-		spec = map[string]interface{}{}
-		workloadObj["spec"] = spec
-	}
-
-	var template map[string]interface{}
-	if t, ok := spec["template"].(map[string]interface{}); ok {
-		template = t
-	} else {
-		template = map[string]interface{}{}
-		spec["template"] = template
-	}
-
-	var tplMeta map[string]interface{}
-	if m, ok := template["metadata"].(map[string]interface{}); ok {
-		tplMeta = m
-	} else {
-		tplMeta = map[string]interface{}{}
-		template["metadata"] = tplMeta
-	}
-
-	if annotations, ok := tplMeta["annotations"].(map[string]interface{}); ok {
-		annotations["kubectl.kubernetes.io/restartedAt"] = time.Now().Format(time.RFC3339)
-	} else {
-		tplMeta["annotations"] = map[string]interface{}{
-			"kubectl.kubernetes.io/restartedAt": time.Now().Format(time.RFC3339),
-		}
+	annotationPath := append(append([]string{}, info.TemplatePath...), "metadata", "annotations", "kubectl.kubernetes.io/restartedAt")
+	if err := unstructured.SetNestedField(workload.Object, time.Now().Format(time.RFC3339), annotationPath...); err != nil {
+		logger.Log.Error("Failed to set restart annotation",
+			zap.String("namespace", namespace),
+			zap.String("type", workloadType),
+			zap.String("name", name),
+			zap.Error(err),
+		)
+		response.InternalError(c, err)
+		return
 	}
 
 	_, err = GetDynamicClient().Resource(info.GVR).Namespace(namespace).Update(context.TODO(), workload, metav1.UpdateOptions{})
@@ -0,0 +1,539 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/openkruise/kruise-dashboard/extensions-backend/pkg/logger"
+	"github.com/openkruise/kruise-dashboard/extensions-backend/pkg/response"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const (
+	errorCodeInvalidContainerEdit   = "INVALID_CONTAINER_EDIT"
+	errorCodeWorkloadBoundToRollout = "WORKLOAD_BOUND_TO_ROLLOUT"
+)
+
+// containerProbeFields are the probe fields of a container that can be replaced or removed.
+var containerProbeFields = []string{"livenessProbe", "readinessProbe", "startupProbe"}
+
+// containerTarget names a container or initContainer of a pod template.
+type containerTarget struct {
+	Name   string
+	IsInit bool
+}
+
+func (t containerTarget) String() string {
+	if t.IsInit {
+		return "initContainer " + t.Name
+	}
+	return "container " + t.Name
+}
+
+// containerEditError is an edit that could not be applied to the i-th target container.
+type containerEditError struct {
+	Index int
+	Err   error
+}
+
+func (e *containerEditError) Error() string {
+	return e.Err.Error()
+}
+
+// containerListPath locates the containers or initContainers of the pod template at templatePath.
+func containerListPath(templatePath []string, isInit bool) []string {
+	field := "containers"
+	if isInit {
+		field = "initContainers"
+	}
+	return append(append([]string{}, templatePath...), "spec", field)
+}
+
+// editContainers calls edit for each target container of the pod template at templatePath.
+// Nothing is changed if a container does not exist or an edit fails; edit failures are returned
+// as *containerEditError.
+func editContainers(workload map[string]interface{}, templatePath []string, targets []containerTarget, edit func(i int, container map[string]interface{}) error) error {
+	lists := map[bool][]interface{}{}
+	for _, isInit := range []bool{false, true} {
+		lists[isInit], _, _ = unstructured.NestedSlice(workload, containerListPath(templatePath, isInit)...)
+	}
+
+	touched := map[bool]bool{}
+	for i, target := range targets {
+		found := false
+		for _, item := range lists[target.IsInit] {
+			container, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if name, _ := container["name"].(string); name == target.Name {
+				if err := edit(i, container); err != nil {
+					return &containerEditError{Index: i, Err: err}
+				}
+				found = true
+			}
+		}
+		if !found {
+			return fmt.Errorf("%s not found", target)
+		}
+		touched[target.IsInit] = true
+	}
+
+	for isInit := range touched {
+		if err := unstructured.SetNestedSlice(workload, lists[isInit], containerListPath(templatePath, isInit)...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type containerEnvVar struct {
+	Name      string                 `json:"name" openapi:"required"`
+	Value     string                 `json:"value"`
+	ValueFrom map[string]interface{} `json:"valueFrom" description:"EnvVarSource, exclusive with value"`
+}
+
+// containerResources changes resource requests and limits by resource name. An empty quantity
+// removes the entry.
+type containerResources struct {
+	Requests map[string]string `json:"requests"`
+	Limits   map[string]string `json:"limits"`
+}
+
+// containerEdit changes one container or initContainer. Env vars are set or replaced by name,
+// resources are merged by resource name and probes are replaced whole.
+type containerEdit struct {
+	Name           string                 `json:"name" openapi:"required"`
+	IsInit         bool                   `json:"isInitContainer"`
+	Image          string                 `json:"image"`
+	Env            []containerEnvVar      `json:"env" description:"Variables set or replaced by name"`
+	RemoveEnv      []string               `json:"removeEnv" description:"Names of variables to remove"`
+	Resources      *containerResources    `json:"resources"`
+	LivenessProbe  map[string]interface{} `json:"livenessProbe" description:"Probe replacing the current one"`
+	ReadinessProbe map[string]interface{} `json:"readinessProbe" description:"Probe replacing the current one"`
+	StartupProbe   map[string]interface{} `json:"startupProbe" description:"Probe replacing the current one"`
+	RemoveProbes   []string               `json:"removeProbes" description:"Probes to remove: livenessProbe, readinessProbe or startupProbe"`
+}
+
+type updateWorkloadContainersRequest struct {
+	Containers []containerEdit `json:"containers" openapi:"required"`
+	DryRun     bool            `json:"dryRun" description:"Return the pod template diff without updating the workload"`
+}
+
+func (e containerEdit) probes() map[string]map[string]interface{} {
+	return map[string]map[string]interface{}{
+		"livenessProbe":  e.LivenessProbe,
+		"readinessProbe": e.ReadinessProbe,
+		"startupProbe":   e.StartupProbe,
+	}
+}
+
+// changes lists what an edit changes: image, env, resources and probes.
+func (e containerEdit) changes() []string {
+	var changes []string
+	if e.Image != "" {
+		changes = append(changes, "image")
+	}
+	if len(e.Env) > 0 || len(e.RemoveEnv) > 0 {
+		changes = append(changes, "env")
+	}
+	if e.Resources != nil && (len(e.Resources.Requests) > 0 || len(e.Resources.Limits) > 0) {
+		changes = append(changes, "resources")
+	}
+	if e.LivenessProbe != nil || e.ReadinessProbe != nil || e.StartupProbe != nil || len(e.RemoveProbes) > 0 {
+		changes = append(changes, "probes")
+	}
+	return changes
+}
+
+// decodeStrict decodes a JSON object into a Kubernetes type, rejecting unknown fields.
+func decodeStrict(raw map[string]interface{}, into interface{}) error {
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(into)
+}
+
+func validateProbe(raw map[string]interface{}) error {
+	var probe corev1.Probe
+	if err := decodeStrict(raw, &probe); err != nil {
+		return err
+	}
+	handlers := 0
+	for _, set := range []bool{probe.Exec != nil, probe.HTTPGet != nil, probe.TCPSocket != nil, probe.GRPC != nil} {
+		if set {
+			handlers++
+		}
+	}
+	if handlers != 1 {
+		return fmt.Errorf("probe must specify exactly one of exec, httpGet, tcpSocket or grpc")
+	}
+	return nil
+}
+
+// validateContainerEdits checks the edits of a request before they are applied.
+func validateContainerEdits(edits []containerEdit) []response.FieldError {
+	var errs []response.FieldError
+	addError := func(field, format string, args ...interface{}) {
+		errs = append(errs, response.FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	seen := map[containerTarget]bool{}
+	for i, edit := range edits {
+		field := fmt.Sprintf("containers[%d]", i)
+		target := containerTarget{Name: edit.Name, IsInit: edit.IsInit}
+		if seen[target] {
+			addError(field+".name", "%s is listed more than once", target)
+		}
+		seen[target] = true
+		if len(edit.changes()) == 0 {
+			addError(field, "no changes requested")
+		}
+
+		for j, env := range edit.Env {
+			envField := fmt.Sprintf("%s.env[%d]", field, j)
+			if strings.TrimSpace(env.Name) == "" {
+				addError(envField+".name", "is required")
+			}
+			if env.ValueFrom != nil {
+				if env.Value != "" {
+					addError(envField, "value and valueFrom are mutually exclusive")
+				}
+				var source corev1.EnvVarSource
+				if err := decodeStrict(env.ValueFrom, &source); err != nil {
+					addError(envField+".valueFrom", "%v", err)
+				}
+			}
+		}
+
+		if edit.Resources != nil {
+			for _, group := range []struct {
+				name       string
+				quantities map[string]string
+			}{{"requests", edit.Resources.Requests}, {"limits", edit.Resources.Limits}} {
+				for name, quantity := range group.quantities {
+					if quantity == "" {
+						continue
+					}
+					if _, err := resource.ParseQuantity(quantity); err != nil {
+						addError(fmt.Sprintf("%s.resources.%s.%s", field, group.name, name), "invalid quantity %q", quantity)
+					}
+				}
+			}
+		}
+
+		for _, probeField := range containerProbeFields {
+			if probe := edit.probes()[probeField]; probe != nil {
+				if err := validateProbe(probe); err != nil {
+					addError(field+"."+probeField, "%v", err)
+				}
+			}
+		}
+		for j, probeField := range edit.RemoveProbes {
+			if !slices.Contains(containerProbeFields, probeField) {
+				addError(fmt.Sprintf("%s.removeProbes[%d]", field, j), "must be one of %s", strings.Join(containerProbeFields, ", "))
+			} else if edit.probes()[probeField] != nil {
+				addError(fmt.Sprintf("%s.removeProbes[%d]", field, j), "%s is also being set", probeField)
+			}
+		}
+	}
+	return errs
+}
+
+// mergeEnv sets and removes env vars of a container by name, keeping the order of the others.
+func mergeEnv(container map[string]interface{}, set []containerEnvVar, remove []string) {
+	env, _, _ := unstructured.NestedSlice(container, "env")
+	removed := map[string]bool{}
+	for _, name := range remove {
+		removed[name] = true
+	}
+
+	merged := make([]interface{}, 0, len(env)+len(set))
+	index := map[string]int{}
+	for _, raw := range env {
+		envVar, _ := raw.(map[string]interface{})
+		name, _ := envVar["name"].(string)
+		if removed[name] {
+			continue
+		}
+		index[name] = len(merged)
+		merged = append(merged, raw)
+	}
+	for _, envVar := range set {
+		value := map[string]interface{}{"name": envVar.Name}
+		if envVar.ValueFrom != nil {
+			value["valueFrom"] = envVar.ValueFrom
+		} else {
+			value["value"] = envVar.Value
+		}
+		if i, ok := index[envVar.Name]; ok {
+			merged[i] = value
+			continue
+		}
+		index[envVar.Name] = len(merged)
+		merged = append(merged, value)
+	}
+
+	if len(merged) == 0 {
+		delete(container, "env")
+		return
+	}
+	container["env"] = merged
+}
+
+// mergeResources changes the requests and limits of a container and checks that no request
+// exceeds its limit.
+func mergeResources(container map[string]interface{}, change containerResources) error {
+	resources, _, _ := unstructured.NestedMap(container, "resources")
+	if resources == nil {
+		resources = map[string]interface{}{}
+	}
+	for group, quantities := range map[string]map[string]string{"requests": change.Requests, "limits": change.Limits} {
+		current, _, _ := unstructured.NestedStringMap(resources, group)
+		if current == nil {
+			current = map[string]string{}
+		}
+		for name, quantity := range quantities {
+			if quantity == "" {
+				delete(current, name)
+			} else {
+				current[name] = quantity
+			}
+		}
+		if len(current) == 0 {
+			delete(resources, group)
+			continue
+		}
+		values := make(map[string]interface{}, len(current))
+		for name, quantity := range current {
+			values[name] = quantity
+		}
+		resources[group] = values
+	}
+
+	requests, _, _ := unstructured.NestedStringMap(resources, "requests")
+	limits, _, _ := unstructured.NestedStringMap(resources, "limits")
+	for name, request := range requests {
+		limit, ok := limits[name]
+		if !ok {
+			continue
+		}
+		requestQuantity, err := resource.ParseQuantity(request)
+		if err != nil {
+			return fmt.Errorf("invalid %s request %q", name, request)
+		}
+		limitQuantity, err := resource.ParseQuantity(limit)
+		if err != nil {
+			return fmt.Errorf("invalid %s limit %q", name, limit)
+		}
+		if requestQuantity.Cmp(limitQuantity) > 0 {
+			return fmt.Errorf("%s request %s exceeds its limit %s", name, request, limit)
+		}
+	}
+
+	if len(resources) == 0 {
+		delete(container, "resources")
+		return nil
+	}
+	container["resources"] = resources
+	return nil
+}
+
+// apply changes a container as requested by the edit.
+func (e containerEdit) apply(container map[string]interface{}) error {
+	if e.Image != "" {
+		container["image"] = e.Image
+	}
+	if len(e.Env) > 0 || len(e.RemoveEnv) > 0 {
+		mergeEnv(container, e.Env, e.RemoveEnv)
+	}
+	if e.Resources != nil {
+		if err := mergeResources(container, *e.Resources); err != nil {
+			return err
+		}
+	}
+	for _, probeField := range e.RemoveProbes {
+		delete(container, probeField)
+	}
+	for probeField, probe := range e.probes() {
+		if probe != nil {
+			container[probeField] = probe
+		}
+	}
+	return nil
+}
+
+// containerEditChangeCause describes container edits, like kubectl set image for image-only edits.
+func containerEditChangeCause(edits []containerEdit) string {
+	parts := make([]string, 0, len(edits))
+	imageOnly := true
+	for _, edit := range edits {
+		changes := edit.changes()
+		if len(changes) != 1 || changes[0] != "image" {
+			imageOnly = false
+		}
+		parts = append(parts, fmt.Sprintf("%s(%s)", edit.Name, strings.Join(changes, ",")))
+	}
+	if imageOnly {
+		updates := make([]rolloutImageUpdate, len(edits))
+		for i, edit := range edits {
+			updates[i] = rolloutImageUpdate{Container: edit.Name, Image: edit.Image}
+		}
+		return imageChangeCause(updates)
+	}
+	return "edit containers " + strings.Join(parts, " ")
+}
+
+// UpdateWorkloadContainers edits the images, env vars, resources and probes of a workload's
+// containers and initContainers in one workload update. A dry run returns the template diff.
+func UpdateWorkloadContainers(c *gin.Context) {
+	namespace := c.Param("namespace")
+	workloadType := c.Param("type")
+	name := c.Param("name")
+
+	var req updateWorkloadContainersRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request payload")
+		return
+	}
+	if len(req.Containers) == 0 {
+		response.BadRequest(c, "containers is required")
+		return
+	}
+	if errs := validateContainerEdits(req.Containers); len(errs) > 0 {
+		response.FieldErrors(c, http.StatusBadRequest, "Invalid container edits", errorCodeInvalidContainerEdit, errs)
+		return
+	}
+
+	info, err := ResolveWorkloadType(workloadType)
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	workload, err := GetDynamicClient().Resource(info.GVR).Namespace(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			response.NotFound(c, workloadType)
+			return
+		}
+		logger.Log.Error("Failed to get workload for container update",
+			zap.String("namespace", namespace),
+			zap.String("type", workloadType),
+			zap.String("name", name),
+			zap.Error(err),
+		)
+		response.InternalError(c, err)
+		return
+	}
+
+	// A Rollout releases its workload's template changes and records them in its release audit;
+	// those go through /rollout/set-image instead.
+	rollouts, err := GetDynamicClient().Resource(rolloutGVR).Namespace(namespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		logger.Log.Error("Failed to list rollouts for container update",
+			zap.String("namespace", namespace),
+			zap.Error(err),
+		)
+		response.InternalError(c, err)
+		return
+	}
+	if rollouts != nil {
+		if bound := rolloutBoundTo(rollouts.Items, info.Kind, name); bound != "" {
+			response.Error(c, http.StatusConflict,
+				fmt.Sprintf("%s %s is bound to rollout %s; use POST /api/v1/rollout/set-image/%s/%s instead", workloadType, name, bound, namespace, bound),
+				nil, errorCodeWorkloadBoundToRollout)
+			return
+		}
+	}
+
+	original := workload.DeepCopy()
+	targets := make([]containerTarget, len(req.Containers))
+	for i, edit := range req.Containers {
+		targets[i] = containerTarget{Name: edit.Name, IsInit: edit.IsInit}
+	}
+	previousImages := make([]string, len(req.Containers))
+	err = editContainers(workload.Object, info.TemplatePath, targets, func(i int, container map[string]interface{}) error {
+		previousImages[i], _ = container["image"].(string)
+		return req.Containers[i].apply(container)
+	})
+	if err != nil {
+		var editErr *containerEditError
+		if errors.As(err, &editErr) {
+			response.FieldErrors(c, http.StatusBadRequest, "Invalid container edits", errorCodeInvalidContainerEdit, []response.FieldError{
+				{Field: fmt.Sprintf("containers[%d]", editErr.Index), Message: editErr.Error()},
+			})
+			return
+		}
+		response.Error(c, http.StatusNotFound, err.Error(), nil, errorCodeContainerNotFound)
+		return
+	}
+
+	containers := make([]gin.H, 0, len(req.Containers))
+	for i, edit := range req.Containers {
+		containers = append(containers, gin.H{
+			"name":            edit.Name,
+			"isInitContainer": edit.IsInit,
+			"changes":         edit.changes(),
+			"previousImage":   previousImages[i],
+		})
+	}
+	result := gin.H{
+		"namespace":  namespace,
+		"type":       workloadType,
+		"name":       name,
+		"containers": containers,
+	}
+
+	if req.DryRun {
+		before, _, _ := unstructured.NestedMap(original.Object, info.TemplatePath...)
+		after, _, _ := unstructured.NestedMap(workload.Object, info.TemplatePath...)
+		templateDiff, err := diffTemplates(name+" (current)", name+" (updated)", normalizeTemplate(before), normalizeTemplate(after))
+		if err != nil {
+			response.InternalError(c, err)
+			return
+		}
+		result["message"] = "Dry run, workload not updated"
+		result["dryRun"] = true
+		result["diff"] = templateDiff
+		response.Success(c, result)
+		return
+	}
+
+	setChangeCause(workload, containerEditChangeCause(req.Containers))
+	if _, err := GetDynamicClient().Resource(info.GVR).Namespace(namespace).Update(context.TODO(), workload, metav1.UpdateOptions{}); err != nil {
+		logger.Log.Error("Failed to update workload containers",
+			zap.String("namespace", namespace),
+			zap.String("type", workloadType),
+			zap.String("name", name),
+			zap.Error(err),
+		)
+		response.InternalError(c, err)
+		return
+	}
+
+	logger.Log.Info("Successfully updated workload containers",
+		zap.String("namespace", namespace),
+		zap.String("type", workloadType),
+		zap.String("name", name),
+		zap.Int("containers", len(req.Containers)),
+	)
+
+	result["message"] = fmt.Sprintf("Successfully updated containers of %s %s", workloadType, name)
+	response.Success(c, result)
+}
@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+func containerWorkload(templatePath ...string) map[string]interface{} {
+	workload := map[string]interface{}{}
+	unstructured.SetNestedField(workload, map[string]interface{}{
+		"spec": map[string]interface{}{
+			"containers": []interface{}{map[string]interface{}{
+				"name":  "app",
+				"image": "app:v1",
+				"env": []interface{}{
+					map[string]interface{}{"name": "A", "value": "1"},
+					map[string]interface{}{"name": "B", "value": "2"},
+					map[string]interface{}{"name": "C", "value": "3"},
+				},
+				"resources": map[string]interface{}{
+					"requests": map[string]interface{}{"cpu": "100m", "memory": "128Mi"},
+					"limits":   map[string]interface{}{"cpu": "500m"},
+				},
+				"livenessProbe": map[string]interface{}{"tcpSocket": map[string]interface{}{"port": int64(8080)}},
+			}},
+		},
+	}, templatePath...)
+	return workload
+}
+
+func TestContainerEditApply(t *testing.T) {
+	templatePath := []string{"spec", "template", "jobTemplate", "spec", "template"}
+	workload := containerWorkload(templatePath...)
+	edit := containerEdit{
+		Name:           "app",
+		Image:          "app:v2",
+		Env:            []containerEnvVar{{Name: "B", Value: "20"}, {Name: "D", ValueFrom: map[string]interface{}{"fieldRef": map[string]interface{}{"fieldPath": "status.podIP"}}}},
+		RemoveEnv:      []string{"A"},
+		Resources:      &containerResources{Requests: map[string]string{"cpu": "200m", "memory": ""}, Limits: map[string]string{"memory": "256Mi"}},
+		ReadinessProbe: map[string]interface{}{"httpGet": map[string]interface{}{"path": "/healthz", "port": int64(8080)}},
+		RemoveProbes:   []string{"livenessProbe"},
+	}
+	err := editContainers(workload, templatePath, []containerTarget{{Name: "app"}}, func(_ int, container map[string]interface{}) error {
+		return edit.apply(container)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	containers, _, _ := unstructured.NestedSlice(workload, containerListPath(templatePath, false)...)
+	container := containers[0].(map[string]interface{})
+	if container["image"] != "app:v2" {
+		t.Errorf("image = %v", container["image"])
+	}
+	var names []string
+	for _, raw := range container["env"].([]interface{}) {
+		envVar := raw.(map[string]interface{})
+		names = append(names, envVar["name"].(string))
+		if envVar["name"] == "B" && envVar["value"] != "20" {
+			t.Errorf("B = %v", envVar["value"])
+		}
+	}
+	if len(names) != 3 || names[0] != "B" || names[1] != "C" || names[2] != "D" {
+		t.Errorf("env = %v", names)
+	}
+	if requests, _, _ := unstructured.NestedStringMap(container, "resources", "requests"); len(requests) != 1 || requests["cpu"] != "200m" {
+		t.Errorf("requests = %v", requests)
+	}
+	if _, ok := container["livenessProbe"]; ok {
+		t.Error("livenessProbe should be removed")
+	}
+	if _, ok := container["readinessProbe"]; !ok {
+		t.Error("readinessProbe should be set")
+	}
+}
+
+func TestContainerEditRequestAboveLimit(t *testing.T) {
+	workload := containerWorkload(defaultTemplatePath...)
+	edit := containerEdit{Name: "app", Image: "app:v2", Resources: &containerResources{Requests: map[string]string{"cpu": "1"}}}
+	err := editContainers(workload, defaultTemplatePath, []containerTarget{{Name: "app"}}, func(_ int, container map[string]interface{}) error {
+		return edit.apply(container)
+	})
+	if editErr, ok := err.(*containerEditError); !ok || editErr.Index != 0 {
+		t.Fatalf("err = %v, want a containerEditError", err)
+	}
+	containers, _, _ := unstructured.NestedSlice(workload, containerListPath(defaultTemplatePath, false)...)
+	if image := containers[0].(map[string]interface{})["image"]; image != "app:v1" {
+		t.Errorf("failed edit changed the workload: image = %v", image)
+	}
+}
+
+func TestValidateContainerEdits(t *testing.T) {
+	errs := validateContainerEdits([]containerEdit{
+		{Name: "app", Env: []containerEnvVar{{Name: "A", Value: "1", ValueFrom: map[string]interface{}{"fieldRef": map[string]interface{}{"fieldPath": "x"}}}}},
+		{Name: "app", Resources: &containerResources{Limits: map[string]string{"cpu": "lots"}}},
+		{Name: "sidecar", LivenessProbe: map[string]interface{}{"periodSeconds": 5}, RemoveProbes: []string{"probe"}},
+		{Name: "init", IsInit: true},
+	})
+	fields := map[string]bool{}
+	for _, err := range errs {
+		fields[err.Field] = true
+	}
+	for _, field := range []string{
+		"containers[0].env[0]",
+		"containers[1].name",
+		"containers[1].resources.limits.cpu",
+		"containers[2].livenessProbe",
+		"containers[2].removeProbes[0]",
+		"containers[3]",
+	} {
+		if !fields[field] {
+			t.Errorf("missing error for %s in %v", field, errs)
+		}
+	}
+
+	if errs := validateContainerEdits([]containerEdit{{Name: "app", StartupProbe: map[string]interface{}{"exec": map[string]interface{}{"command": []interface{}{"true"}}}}}); len(errs) != 0 {
+		t.Errorf("valid edit errs = %v", errs)
+	}
+}
+
+func TestContainerEditChangeCause(t *testing.T) {
+	if cause := containerEditChangeCause([]containerEdit{{Name: "app", Image: "app:v2"}}); cause != "set image app=app:v2" {
+		t.Errorf("image-only cause = %q", cause)
+	}
+	cause := containerEditChangeCause([]containerEdit{{Name: "app", Image: "app:v2", RemoveEnv: []string{"A"}}, {Name: "sidecar", RemoveProbes: []string{"startupProbe"}}})
+	if cause != "edit containers app(image,env) sidecar(probes)" {
+		t.Errorf("cause = %q", cause)
+	}
+}
+
+func TestUpdateWorkloadContainersRejectsRolloutBound(t *testing.T) {
+	deployment := func(name string) runtime.Object {
+		obj := &unstructured.Unstructured{Object: containerWorkload("spec", "template")}
+		obj.SetAPIVersion("apps/v1")
+		obj.SetKind("Deployment")
+		obj.SetNamespace("default")
+		obj.SetName(name)
+		return obj
+	}
+	rollout := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "rollouts.kruise.io/v1beta1",
+		"kind":       "Rollout",
+		"metadata":   map[string]interface{}{"name": "web-rollout", "namespace": "default"},
+		"spec": map[string]interface{}{"workloadRef": map[string]interface{}{
+			"apiVersion": "apps/v1", "kind": "Deployment", "name": "web",
+		}},
+	}}
+
+	previous := dynamicClient
+	defer func() { dynamicClient = previous }()
+	dynamicClient = dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{rolloutGVR: "RolloutList"},
+		deployment("web"), deployment("worker"), rollout)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/api/v1/workload/:namespace/:type/:name/containers", UpdateWorkloadContainers)
+	update := func(name string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		body := `{"containers":[{"name":"app","image":"app:v2"}],"dryRun":true}`
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/workload/default/deployment/"+name+"/containers", strings.NewReader(body)))
+		return w
+	}
+
+	w := update("web")
+	var failure struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &failure)
+	if w.Code != http.StatusConflict || failure.Code != errorCodeWorkloadBoundToRollout || !strings.Contains(failure.Message, "/rollout/set-image/default/web-rollout") {
+		t.Errorf("bound workload = %d %s, want 409 %s pointing to set-image", w.Code, w.Body.String(), errorCodeWorkloadBoundToRollout)
+	}
+
+	if w := update("worker"); w.Code != http.StatusOK {
+		t.Errorf("unbound workload = %d %s, want 200", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,296 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+	"github.com/openkruise/kruise-dashboard/extensions-backend/pkg/logger"
+	"github.com/openkruise/kruise-dashboard/extensions-backend/pkg/response"
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	metricsv1beta1 "k8s.io/metrics/pkg/apis/metrics/v1beta1"
+	metricsv "k8s.io/metrics/pkg/client/clientset/versioned"
+)
+
+const errorCodeMetricsUnavailable = "METRICS_UNAVAILABLE"
+
+// ResourceAmounts is an amount of CPU and memory.
+type ResourceAmounts struct {
+	CPUMillis   int64 `json:"cpuMillis"`
+	MemoryBytes int64 `json:"memoryBytes"`
+}
+
+func (a *ResourceAmounts) add(b ResourceAmounts) {
+	a.CPUMillis += b.CPUMillis
+	a.MemoryBytes += b.MemoryBytes
+}
+
+// ResourceMetrics compares CPU and memory usage with the requests and limits of the measured
+// containers. A limit is 0 when any measured container has no limit for the resource, and the
+// percentages are omitted when their request or limit is 0.
+type ResourceMetrics struct {
+	Usage                ResourceAmounts `json:"usage"`
+	Requests             ResourceAmounts `json:"requests"`
+	Limits               ResourceAmounts `json:"limits"`
+	CPURequestPercent    *float64        `json:"cpuRequestPercent,omitempty"`
+	CPULimitPercent      *float64        `json:"cpuLimitPercent,omitempty"`
+	MemoryRequestPercent *float64        `json:"memoryRequestPercent,omitempty"`
+	MemoryLimitPercent   *float64        `json:"memoryLimitPercent,omitempty"`
+
+	cpuUnlimited, memoryUnlimited bool
+}
+
+// ContainerMetrics is the usage of one container summed over the measured pods.
+type ContainerMetrics struct {
+	Name string `json:"name"`
+	ResourceMetrics
+}
+
+// RevisionMetrics is the usage of the pods of one rollout revision.
+type RevisionMetrics struct {
+	Name            string `json:"name"`
+	Role            string `json:"role" openapi:"enum=stable|canary|blue|green|previous"`
+	PodTemplateHash string `json:"podTemplateHash"`
+	Pods            int    `json:"pods"`
+	ResourceMetrics
+	Containers []ContainerMetrics `json:"containers"`
+}
+
+// WorkloadMetrics is the CPU and memory usage of a workload's pods from the metrics API.
+// MeasuredPods counts the pods metrics-server reported, which excludes pods not yet scraped.
+type WorkloadMetrics struct {
+	Namespace    string `json:"namespace"`
+	Kind         string `json:"kind"`
+	Name         string `json:"name"`
+	Pods         int    `json:"pods"`
+	MeasuredPods int    `json:"measuredPods"`
+	ResourceMetrics
+	Containers []ContainerMetrics `json:"containers"`
+	Revisions  []RevisionMetrics  `json:"revisions,omitempty"`
+}
+
+func newMetricsClient() (metricsv.Interface, error) {
+	config, err := getRestConfig()
+	if err != nil {
+		return nil, err
+	}
+	return metricsv.NewForConfig(config)
+}
+
+// listPodMetrics returns the metrics of the pods in a namespace matching a label selector, by pod
+// name.
+func listPodMetrics(namespace, labelSelector string) (map[string]metricsv1beta1.PodMetrics, error) {
+	client, err := newMetricsClient()
+	if err != nil {
+		return nil, err
+	}
+	list, err := client.MetricsV1beta1().PodMetricses(namespace).List(context.TODO(), metav1.ListOptions{LabelSelector: labelSelector})
+	if err != nil {
+		return nil, err
+	}
+	byName := make(map[string]metricsv1beta1.PodMetrics, len(list.Items))
+	for _, podMetrics := range list.Items {
+		byName[podMetrics.Name] = podMetrics
+	}
+	return byName, nil
+}
+
+func percent(used, total int64) *float64 {
+	if total == 0 {
+		return nil
+	}
+	value := 100 * float64(used) / float64(total)
+	return &value
+}
+
+// addContainer adds the usage of a container and its resources from the pod spec.
+func (m *ResourceMetrics) addContainer(usage ResourceAmounts, spec map[string]interface{}) {
+	m.Usage.add(usage)
+	requests, _, _ := unstructured.NestedStringMap(spec, "resources", "requests")
+	limits, _, _ := unstructured.NestedStringMap(spec, "resources", "limits")
+	m.Requests.add(parseResourceAmounts(requests))
+	containerLimits := parseResourceAmounts(limits)
+	m.Limits.add(containerLimits)
+	m.cpuUnlimited = m.cpuUnlimited || containerLimits.CPUMillis == 0
+	m.memoryUnlimited = m.memoryUnlimited || containerLimits.MemoryBytes == 0
+}
+
+func (m *ResourceMetrics) finish() {
+	if m.cpuUnlimited {
+		m.Limits.CPUMillis = 0
+	}
+	if m.memoryUnlimited {
+		m.Limits.MemoryBytes = 0
+	}
+	m.CPURequestPercent = percent(m.Usage.CPUMillis, m.Requests.CPUMillis)
+	m.CPULimitPercent = percent(m.Usage.CPUMillis, m.Limits.CPUMillis)
+	m.MemoryRequestPercent = percent(m.Usage.MemoryBytes, m.Requests.MemoryBytes)
+	m.MemoryLimitPercent = percent(m.Usage.MemoryBytes, m.Limits.MemoryBytes)
+}
+
+func parseResourceAmounts(list map[string]string) ResourceAmounts {
+	var amounts ResourceAmounts
+	if cpu, err := resource.ParseQuantity(list["cpu"]); err == nil {
+		amounts.CPUMillis = cpu.MilliValue()
+	}
+	if memory, err := resource.ParseQuantity(list["memory"]); err == nil {
+		amounts.MemoryBytes = memory.Value()
+	}
+	return amounts
+}
+
+// aggregatePodMetrics sums the usage of pods, overall and by container, against the requests and
+// limits of their containers. Pods without metrics are skipped.
+func aggregatePodMetrics(pods []interface{}, metrics map[string]metricsv1beta1.PodMetrics) (ResourceMetrics, []ContainerMetrics, int) {
+	var total ResourceMetrics
+	byContainer := map[string]*ContainerMetrics{}
+	measured := 0
+	for _, raw := range pods {
+		pod, ok := raw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _, _ := unstructured.NestedString(pod, "metadata", "name")
+		podMetrics, ok := metrics[name]
+		if !ok {
+			continue
+		}
+		measured++
+
+		specs := map[string]map[string]interface{}{}
+		containers, _, _ := unstructured.NestedSlice(pod, "spec", "containers")
+		for _, rawContainer := range containers {
+			container, _ := rawContainer.(map[string]interface{})
+			containerName, _ := container["name"].(string)
+			specs[containerName] = container
+		}
+		for _, containerMetrics := range podMetrics.Containers {
+			usage := ResourceAmounts{
+				CPUMillis:   containerMetrics.Usage.Cpu().MilliValue(),
+				MemoryBytes: containerMetrics.Usage.Memory().Value(),
+			}
+			spec := specs[containerMetrics.Name]
+			total.addContainer(usage, spec)
+			if byContainer[containerMetrics.Name] == nil {
+				byContainer[containerMetrics.Name] = &ContainerMetrics{Name: containerMetrics.Name}
+			}
+			byContainer[containerMetrics.Name].addContainer(usage, spec)
+		}
+	}
+
+	total.finish()
+	containers := make([]ContainerMetrics, 0, len(byContainer))
+	for _, container := range byContainer {
+		container.finish()
+		containers = append(containers, *container)
+	}
+	sort.Slice(containers, func(i, j int) bool { return containers[i].Name < containers[j].Name })
+	return total, containers, measured
+}
+
+// respondMetricsUnavailable reports that the metrics API could not be queried.
+func respondMetricsUnavailable(c *gin.Context, namespace string, err error) {
+	logger.Log.Warn("Failed to list pod metrics (is metrics-server installed?)",
+		zap.String("namespace", namespace),
+		zap.Error(err),
+	)
+	response.Error(c, http.StatusServiceUnavailable, "Pod metrics are not available", err, errorCodeMetricsUnavailable)
+}
+
+// GetWorkloadMetrics returns the CPU and memory usage of a workload's pods, overall and by
+// container, compared with their requests and limits.
+func GetWorkloadMetrics(c *gin.Context) {
+	workload, _, info, ok := getWorkloadV2(c)
+	if !ok {
+		return
+	}
+	namespace, name := workload.GetNamespace(), workload.GetName()
+
+	pods, err := listWorkloadPods(namespace, name, info, workload)
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+	metrics, err := listPodMetrics(namespace, workloadPodSelector(info, workload))
+	if err != nil {
+		respondMetricsUnavailable(c, namespace, err)
+		return
+	}
+
+	total, containers, measured := aggregatePodMetrics(pods, metrics)
+	response.Success(c, WorkloadMetrics{
+		Namespace:       namespace,
+		Kind:            info.Kind,
+		Name:            name,
+		Pods:            len(pods),
+		MeasuredPods:    measured,
+		ResourceMetrics: total,
+		Containers:      containers,
+	})
+}
+
+// GetRolloutMetrics returns the CPU and memory usage of a rollout workload's pods, overall and
+// broken down by revision (stable and canary, or blue and green) and container.
+func GetRolloutMetrics(c *gin.Context) {
+	rollout, ok := getRolloutV2(c)
+	if !ok {
+		return
+	}
+	namespace := rollout.GetNamespace()
+	items, revisions, ok := rolloutWorkloadPodsV2(c, rollout)
+	if !ok {
+		return
+	}
+	workloadRef := extractWorkloadRefFromRollout(rollout)
+	refKind, _ := workloadRef["kind"].(string)
+	refName, _ := workloadRef["name"].(string)
+
+	// The pods were selected from the workload already; metrics are listed for the namespace and
+	// matched by pod name.
+	metrics, err := listPodMetrics(namespace, "")
+	if err != nil {
+		respondMetricsUnavailable(c, namespace, err)
+		return
+	}
+
+	total, containers, measured := aggregatePodMetrics(items, metrics)
+	result := WorkloadMetrics{
+		Namespace:       namespace,
+		Kind:            refKind,
+		Name:            refName,
+		Pods:            len(items),
+		MeasuredPods:    measured,
+		ResourceMetrics: total,
+		Containers:      containers,
+		Revisions:       []RevisionMetrics{},
+	}
+
+	podsByName := make(map[string]interface{}, len(items))
+	for _, raw := range items {
+		pod, _ := raw.(map[string]interface{})
+		name, _, _ := unstructured.NestedString(pod, "metadata", "name")
+		podsByName[name] = raw
+	}
+	for _, revision := range revisions {
+		revisionPods := make([]interface{}, 0, len(revision.Pods))
+		for _, pod := range revision.Pods {
+			if raw, ok := podsByName[pod.Name]; ok {
+				revisionPods = append(revisionPods, raw)
+			}
+		}
+		usage, revisionContainers, _ := aggregatePodMetrics(revisionPods, metrics)
+		result.Revisions = append(result.Revisions, RevisionMetrics{
+			Name:            revision.Name,
+			Role:            revision.Role,
+			PodTemplateHash: revision.PodTemplateHash,
+			Pods:            len(revisionPods),
+			ResourceMetrics: usage,
+			Containers:      revisionContainers,
+		})
+	}
+	response.Success(c, result)
+}
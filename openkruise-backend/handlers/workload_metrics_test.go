@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	metricsv1beta1 "k8s.io/metrics/pkg/apis/metrics/v1beta1"
+)
+
+func metricsTestPod(name string, containers ...interface{}) interface{} {
+	return map[string]interface{}{
+		"metadata": map[string]interface{}{"name": name},
+		"spec":     map[string]interface{}{"containers": containers},
+	}
+}
+
+func metricsTestContainer(name, cpuRequest, cpuLimit, memoryRequest, memoryLimit string) interface{} {
+	requests, limits := map[string]interface{}{}, map[string]interface{}{}
+	for key, value := range map[string]string{"cpu": cpuRequest, "memory": memoryRequest} {
+		if value != "" {
+			requests[key] = value
+		}
+	}
+	for key, value := range map[string]string{"cpu": cpuLimit, "memory": memoryLimit} {
+		if value != "" {
+			limits[key] = value
+		}
+	}
+	return map[string]interface{}{
+		"name":      name,
+		"resources": map[string]interface{}{"requests": requests, "limits": limits},
+	}
+}
+
+func metricsTestUsage(pod string, usage map[string][2]string) metricsv1beta1.PodMetrics {
+	podMetrics := metricsv1beta1.PodMetrics{ObjectMeta: metav1.ObjectMeta{Name: pod}}
+	for name, values := range usage {
+		podMetrics.Containers = append(podMetrics.Containers, metricsv1beta1.ContainerMetrics{
+			Name: name,
+			Usage: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse(values[0]),
+				corev1.ResourceMemory: resource.MustParse(values[1]),
+			},
+		})
+	}
+	return podMetrics
+}
+
+func TestAggregatePodMetrics(t *testing.T) {
+	pods := []interface{}{
+		metricsTestPod("web-1",
+			metricsTestContainer("app", "100m", "200m", "128Mi", "256Mi"),
+			metricsTestContainer("sidecar", "50m", "", "64Mi", "64Mi"),
+		),
+		metricsTestPod("web-2",
+			metricsTestContainer("app", "100m", "200m", "128Mi", "256Mi"),
+			metricsTestContainer("sidecar", "50m", "", "64Mi", "64Mi"),
+		),
+		metricsTestPod("web-3", metricsTestContainer("app", "100m", "200m", "128Mi", "256Mi")),
+	}
+	metrics := map[string]metricsv1beta1.PodMetrics{
+		"web-1": metricsTestUsage("web-1", map[string][2]string{"app": {"50m", "64Mi"}, "sidecar": {"10m", "32Mi"}}),
+		"web-2": metricsTestUsage("web-2", map[string][2]string{"app": {"150m", "128Mi"}, "sidecar": {"10m", "32Mi"}}),
+	}
+
+	total, containers, measured := aggregatePodMetrics(pods, metrics)
+	if measured != 2 {
+		t.Errorf("measured = %d, want 2 (web-3 has no metrics)", measured)
+	}
+	if total.Usage.CPUMillis != 220 || total.Requests.CPUMillis != 300 {
+		t.Errorf("cpu usage/requests = %d/%d, want 220/300", total.Usage.CPUMillis, total.Requests.CPUMillis)
+	}
+	// The sidecar has no CPU limit, so the CPU limit of the total is unbounded.
+	if total.Limits.CPUMillis != 0 || total.CPULimitPercent != nil {
+		t.Errorf("cpu limit = %d (%v), want unbounded", total.Limits.CPUMillis, total.CPULimitPercent)
+	}
+	if total.Limits.MemoryBytes != 2*320*1024*1024 || total.MemoryLimitPercent == nil || *total.MemoryLimitPercent != 40 {
+		t.Errorf("memory limit = %d (%v), want 640Mi at 40%%", total.Limits.MemoryBytes, total.MemoryLimitPercent)
+	}
+
+	if len(containers) != 2 || containers[0].Name != "app" || containers[1].Name != "sidecar" {
+		t.Fatalf("containers = %+v", containers)
+	}
+	app := containers[0]
+	if app.Usage.CPUMillis != 200 || app.CPURequestPercent == nil || *app.CPURequestPercent != 100 || app.CPULimitPercent == nil || *app.CPULimitPercent != 50 {
+		t.Errorf("app = %+v", app.ResourceMetrics)
+	}
+	if containers[1].Limits.CPUMillis != 0 || containers[1].Limits.MemoryBytes != 2*64*1024*1024 {
+		t.Errorf("sidecar limits = %+v", containers[1].Limits)
+	}
+}
+
+func TestAggregatePodMetricsWithoutMetrics(t *testing.T) {
+	pods := []interface{}{metricsTestPod("web-1", metricsTestContainer("app", "100m", "", "", ""))}
+	total, containers, measured := aggregatePodMetrics(pods, nil)
+	if measured != 0 || len(containers) != 0 || total.Requests.CPUMillis != 0 || total.CPURequestPercent != nil {
+		t.Errorf("got %+v %+v %d, want empty", total, containers, measured)
+	}
+}
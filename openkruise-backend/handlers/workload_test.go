@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+func TestGetWorkloadView(t *testing.T) {
+	previous := dynamicClient
+	defer func() { dynamicClient = previous }()
+	dynamicClient = dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata":   map[string]interface{}{"name": "web", "namespace": "default"},
+		"spec":       map[string]interface{}{"replicas": int64(2)},
+		"status":     map[string]interface{}{"replicas": int64(2), "updatedReplicas": int64(2), "availableReplicas": int64(2)},
+	}})
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/api/v1/workload/:namespace/:type/:name", GetWorkload)
+	get := func(query string) (int, map[string]interface{}) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/workload/default/deployment/web"+query, nil))
+		var resp struct {
+			Data map[string]interface{} `json:"data"`
+		}
+		_ = json.Unmarshal(w.Body.Bytes(), &resp)
+		return w.Code, resp.Data
+	}
+
+	code, full := get("")
+	if code != http.StatusOK {
+		t.Fatalf("status = %d", code)
+	}
+	if _, ok := full["health"]; ok || full["kind"] != "Deployment" {
+		t.Errorf("full view = %v, want the unmodified object", full)
+	}
+
+	code, summary := get("?view=summary")
+	health, _ := summary["health"].(map[string]interface{})
+	if code != http.StatusOK || health["status"] != healthHealthy {
+		t.Errorf("summary view = %d %v, want health %s", code, summary, healthHealthy)
+	}
+
+	if code, _ := get("?view=raw"); code != http.StatusBadRequest {
+		t.Errorf("status for an unknown view = %d, want 400", code)
+	}
+}
@@ -2,8 +2,26 @@ package handlers
 
 import (
 	"fmt"
+	"os"
+	"sort"
+	"strings"
 
+	"github.com/gin-gonic/gin"
+	"github.com/openkruise/kruise-dashboard/extensions-backend/pkg/logger"
+	"github.com/openkruise/kruise-dashboard/extensions-backend/pkg/response"
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	broadcastJobControllerUIDLabel = "broadcastjob-controller-uid"
+
+	workloadTypeSourceBuiltin   = "builtin"
+	workloadTypeSourceDiscovery = "discovery"
+	workloadTypeSourceConfig    = "config"
 )
 
 // WorkloadTypeInfo holds metadata about a workload type
@@ -12,44 +30,76 @@ type WorkloadTypeInfo struct {
 	Kind        string
 	Scalable    bool
 	Restartable bool
+	// SelectorPath and TemplatePath locate the label selector and pod template in the object.
+	SelectorPath []string
+	TemplatePath []string
+	// ControllerUIDLabel is set for kinds without a label selector, whose controller labels each
+	// pod with the workload's UID instead.
+	ControllerUIDLabel string
+	Source             string
 }
 
+var (
+	defaultSelectorPath = []string{"spec", "selector"}
+	defaultTemplatePath = []string{"spec", "template"}
+)
+
 var workloadTypeRegistry = map[string]WorkloadTypeInfo{
 	"deployment": {
-		GVR:         schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"},
-		Kind:        "Deployment",
-		Scalable:    true,
-		Restartable: true,
+		GVR:          schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"},
+		Kind:         "Deployment",
+		Scalable:     true,
+		Restartable:  true,
+		SelectorPath: defaultSelectorPath,
+		TemplatePath: defaultTemplatePath,
+		Source:       workloadTypeSourceBuiltin,
 	},
 	"cloneset": {
-		GVR:         schema.GroupVersionResource{Group: "apps.kruise.io", Version: "v1alpha1", Resource: "clonesets"},
-		Kind:        "CloneSet",
-		Scalable:    true,
-		Restartable: true,
+		GVR:          schema.GroupVersionResource{Group: "apps.kruise.io", Version: "v1alpha1", Resource: "clonesets"},
+		Kind:         "CloneSet",
+		Scalable:     true,
+		Restartable:  true,
+		SelectorPath: defaultSelectorPath,
+		TemplatePath: defaultTemplatePath,
+		Source:       workloadTypeSourceBuiltin,
 	},
 	"statefulset": {
-		GVR:         schema.GroupVersionResource{Group: "apps.kruise.io", Version: "v1beta1", Resource: "statefulsets"},
-		Kind:        "StatefulSet",
-		Scalable:    true,
-		Restartable: true,
+		GVR:          schema.GroupVersionResource{Group: "apps.kruise.io", Version: "v1beta1", Resource: "statefulsets"},
+		Kind:         "StatefulSet",
+		Scalable:     true,
+		Restartable:  true,
+		SelectorPath: defaultSelectorPath,
+		TemplatePath: defaultTemplatePath,
+		Source:       workloadTypeSourceBuiltin,
 	},
 	"daemonset": {
-		GVR:         schema.GroupVersionResource{Group: "apps.kruise.io", Version: "v1alpha1", Resource: "daemonsets"},
-		Kind:        "DaemonSet",
-		Scalable:    false,
-		Restartable: true,
+		GVR:          schema.GroupVersionResource{Group: "apps.kruise.io", Version: "v1alpha1", Resource: "daemonsets"},
+		Kind:         "DaemonSet",
+		Scalable:     false,
+		Restartable:  true,
+		SelectorPath: defaultSelectorPath,
+		TemplatePath: defaultTemplatePath,
+		Source:       workloadTypeSourceBuiltin,
 	},
 	"broadcastjob": {
-		GVR:         schema.GroupVersionResource{Group: "apps.kruise.io", Version: "v1alpha1", Resource: "broadcastjobs"},
-		Kind:        "BroadcastJob",
-		Scalable:    false,
-		Restartable: false,
+		GVR:          schema.GroupVersionResource{Group: "apps.kruise.io", Version: "v1alpha1", Resource: "broadcastjobs"},
+		Kind:         "BroadcastJob",
+		Scalable:     false,
+		Restartable:  false,
+		SelectorPath: defaultSelectorPath,
+		TemplatePath: defaultTemplatePath,
+		// BroadcastJob has no spec.selector; kruise labels its pods with the job's UID.
+		ControllerUIDLabel: broadcastJobControllerUIDLabel,
+		Source:             workloadTypeSourceBuiltin,
 	},
 	"advancedcronjob": {
-		GVR:         schema.GroupVersionResource{Group: "apps.kruise.io", Version: "v1alpha1", Resource: "advancedcronjobs"},
-		Kind:        "AdvancedCronJob",
-		Scalable:    false,
-		Restartable: false,
+		GVR:          schema.GroupVersionResource{Group: "apps.kruise.io", Version: "v1alpha1", Resource: "advancedcronjobs"},
+		Kind:         "AdvancedCronJob",
+		Scalable:     false,
+		Restartable:  false,
+		SelectorPath: defaultSelectorPath,
+		TemplatePath: []string{"spec", "template", "jobTemplate", "spec", "template"},
+		Source:       workloadTypeSourceBuiltin,
 	},
 }
 
@@ -62,3 +112,224 @@ func ResolveWorkloadType(workloadType string) (WorkloadTypeInfo, error) {
 	}
 	return info, nil
 }
+
+// ResolveWorkloadKind finds the registered workload type for an object kind, e.g. a workloadRef kind.
+func ResolveWorkloadKind(kind string) (string, WorkloadTypeInfo, bool) {
+	if info, ok := workloadTypeRegistry[strings.ToLower(kind)]; ok {
+		return strings.ToLower(kind), info, true
+	}
+	for name, info := range workloadTypeRegistry {
+		if strings.EqualFold(info.Kind, kind) {
+			return name, info, true
+		}
+	}
+	return "", WorkloadTypeInfo{}, false
+}
+
+// workloadTypeConfig is one entry of the WORKLOAD_TYPES_CONFIG file, declaring a custom workload CRD.
+type workloadTypeConfig struct {
+	Name         string `json:"name"`
+	Group        string `json:"group"`
+	Version      string `json:"version"`
+	Resource     string `json:"resource"`
+	Kind         string `json:"kind"`
+	Scalable     bool   `json:"scalable"`
+	Restartable  bool   `json:"restartable"`
+	SelectorPath string `json:"selectorPath"`
+	TemplatePath string `json:"templatePath"`
+}
+
+type workloadTypesConfigFile struct {
+	WorkloadTypes []workloadTypeConfig `json:"workloadTypes"`
+}
+
+func splitFieldPath(path string, fallback []string) []string {
+	path = strings.Trim(strings.TrimSpace(path), ".")
+	if path == "" {
+		return fallback
+	}
+	return strings.Split(path, ".")
+}
+
+// parseWorkloadTypesConfig parses a YAML or JSON workload types config.
+func parseWorkloadTypesConfig(data []byte) (map[string]WorkloadTypeInfo, error) {
+	var file workloadTypesConfigFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]WorkloadTypeInfo, len(file.WorkloadTypes))
+	for i, entry := range file.WorkloadTypes {
+		if entry.Group == "" || entry.Resource == "" || entry.Kind == "" {
+			return nil, fmt.Errorf("workloadTypes[%d]: group, resource and kind are required", i)
+		}
+		name := strings.ToLower(entry.Name)
+		if name == "" {
+			name = strings.ToLower(entry.Kind)
+		}
+		result[name] = WorkloadTypeInfo{
+			GVR:          schema.GroupVersionResource{Group: entry.Group, Version: entry.Version, Resource: entry.Resource},
+			Kind:         entry.Kind,
+			Scalable:     entry.Scalable,
+			Restartable:  entry.Restartable,
+			SelectorPath: splitFieldPath(entry.SelectorPath, defaultSelectorPath),
+			TemplatePath: splitFieldPath(entry.TemplatePath, defaultTemplatePath),
+			Source:       workloadTypeSourceConfig,
+		}
+	}
+	return result, nil
+}
+
+// discoverWorkloadTypes returns a copy of registry with each type pinned to the preferred served
+// version of its resource. Types whose resource is not served are dropped. Config entries with an
+// explicit version are kept as declared.
+func discoverWorkloadTypes(dc discovery.DiscoveryInterface, registry map[string]WorkloadTypeInfo) (map[string]WorkloadTypeInfo, error) {
+	groups, err := dc.ServerGroups()
+	if err != nil {
+		return nil, err
+	}
+
+	// Versions of each group, preferred version first.
+	groupVersions := map[string][]string{}
+	for _, group := range groups.Groups {
+		versions := []string{group.PreferredVersion.Version}
+		for _, v := range group.Versions {
+			if v.Version != group.PreferredVersion.Version {
+				versions = append(versions, v.Version)
+			}
+		}
+		groupVersions[group.Name] = versions
+	}
+
+	resourceCache := map[string]*metav1.APIResourceList{}
+	served := func(group, version, resource string) bool {
+		gv := schema.GroupVersion{Group: group, Version: version}.String()
+		list, ok := resourceCache[gv]
+		if !ok {
+			fetched, fetchErr := dc.ServerResourcesForGroupVersion(gv)
+			if fetchErr != nil {
+				fetched = nil
+			}
+			resourceCache[gv] = fetched
+			list = fetched
+		}
+		if list == nil {
+			return false
+		}
+		for _, r := range list.APIResources {
+			if r.Name == resource {
+				return true
+			}
+		}
+		return false
+	}
+
+	result := make(map[string]WorkloadTypeInfo, len(registry))
+	for name, info := range registry {
+		if info.Source == workloadTypeSourceConfig && info.GVR.Version != "" {
+			result[name] = info
+			continue
+		}
+		found := false
+		for _, version := range groupVersions[info.GVR.Group] {
+			if served(info.GVR.Group, version, info.GVR.Resource) {
+				if info.Source == workloadTypeSourceBuiltin {
+					info.Source = workloadTypeSourceDiscovery
+				}
+				info.GVR.Version = version
+				found = true
+				break
+			}
+		}
+		if !found {
+			logger.Log.Info("Workload type not served by cluster, disabling",
+				zap.String("type", name),
+				zap.String("group", info.GVR.Group),
+				zap.String("resource", info.GVR.Resource),
+			)
+			continue
+		}
+		result[name] = info
+	}
+	return result, nil
+}
+
+// InitWorkloadTypes populates the workload type registry from the optional WORKLOAD_TYPES_CONFIG
+// file and API discovery. It must be called after InitK8sClient and before serving requests.
+// If discovery fails, the built-in versions are kept.
+func InitWorkloadTypes() error {
+	registry := make(map[string]WorkloadTypeInfo, len(workloadTypeRegistry))
+	for name, info := range workloadTypeRegistry {
+		registry[name] = info
+	}
+
+	if path := os.Getenv("WORKLOAD_TYPES_CONFIG"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("read workload types config: %w", err)
+		}
+		custom, err := parseWorkloadTypesConfig(data)
+		if err != nil {
+			return fmt.Errorf("parse workload types config: %w", err)
+		}
+		for name, info := range custom {
+			registry[name] = info
+		}
+	}
+
+	discovered, err := discoverWorkloadTypes(GetK8sClient().Discovery(), registry)
+	if err != nil {
+		logger.Log.Warn("API discovery failed, using static workload type versions", zap.Error(err))
+		for name, info := range registry {
+			if info.GVR.Version == "" {
+				logger.Log.Warn("Workload type has no version and cannot be discovered, disabling", zap.String("type", name))
+				delete(registry, name)
+			}
+		}
+		workloadTypeRegistry = registry
+		return nil
+	}
+	workloadTypeRegistry = discovered
+	return nil
+}
+
+// kruiseWorkloadTypes returns the registered types listed by ListAllWorkloads: everything except
+// the core apps/v1 kinds.
+func kruiseWorkloadTypes() []WorkloadTypeInfo {
+	types := make([]WorkloadTypeInfo, 0, len(workloadTypeRegistry))
+	for _, info := range workloadTypeRegistry {
+		if info.GVR.Group == "apps" {
+			continue
+		}
+		types = append(types, info)
+	}
+	return types
+}
+
+// ListWorkloadTypes returns the workload types available in this cluster and their capabilities.
+func ListWorkloadTypes(c *gin.Context) {
+	names := make([]string, 0, len(workloadTypeRegistry))
+	for name := range workloadTypeRegistry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	types := make([]gin.H, 0, len(names))
+	for _, name := range names {
+		info := workloadTypeRegistry[name]
+		types = append(types, gin.H{
+			"type":         name,
+			"kind":         info.Kind,
+			"group":        info.GVR.Group,
+			"version":      info.GVR.Version,
+			"resource":     info.GVR.Resource,
+			"scalable":     info.Scalable,
+			"restartable":  info.Restartable,
+			"selectorPath": strings.Join(info.SelectorPath, "."),
+			"templatePath": strings.Join(info.TemplatePath, "."),
+			"source":       info.Source,
+		})
+	}
+
+	response.Success(c, types)
+}
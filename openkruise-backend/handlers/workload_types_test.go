@@ -2,8 +2,17 @@ package handlers
 
 import (
 	"testing"
+
+	"github.com/openkruise/kruise-dashboard/extensions-backend/pkg/logger"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	k8stesting "k8s.io/client-go/testing"
 )
 
+func init() {
+	_ = logger.InitLogger()
+}
+
 func TestResolveWorkloadType(t *testing.T) {
 	tests := []struct {
 		name          string
@@ -137,3 +146,75 @@ func TestExtractLabelSelector(t *testing.T) {
 		})
 	}
 }
+
+func TestParseWorkloadTypesConfig(t *testing.T) {
+	data := []byte(`
+workloadTypes:
+  - name: RollingSet
+    group: apps.example.com
+    resource: rollingsets
+    kind: RollingSet
+    scalable: true
+    selectorPath: spec.podSelector
+`)
+	types, err := parseWorkloadTypesConfig(data)
+	if err != nil {
+		t.Fatalf("parseWorkloadTypesConfig() unexpected error: %v", err)
+	}
+	info, ok := types["rollingset"]
+	if !ok {
+		t.Fatalf("rollingset not registered, got %v", types)
+	}
+	if !info.Scalable || info.Restartable {
+		t.Errorf("capabilities = (%v, %v), want (true, false)", info.Scalable, info.Restartable)
+	}
+	if len(info.SelectorPath) != 2 || info.SelectorPath[1] != "podSelector" {
+		t.Errorf("SelectorPath = %v, want [spec podSelector]", info.SelectorPath)
+	}
+	if len(info.TemplatePath) != 2 || info.TemplatePath[1] != "template" {
+		t.Errorf("TemplatePath = %v, want default [spec template]", info.TemplatePath)
+	}
+
+	if _, err := parseWorkloadTypesConfig([]byte(`workloadTypes: [{name: broken}]`)); err == nil {
+		t.Error("expected error for entry without group, resource and kind")
+	}
+}
+
+func TestDiscoverWorkloadTypes(t *testing.T) {
+	dc := &fakediscovery.FakeDiscovery{Fake: &k8stesting.Fake{}}
+	dc.Resources = []*metav1.APIResourceList{
+		{
+			GroupVersion: "apps/v1",
+			APIResources: []metav1.APIResource{{Name: "deployments"}},
+		},
+		{
+			GroupVersion: "apps.kruise.io/v1beta1",
+			APIResources: []metav1.APIResource{{Name: "statefulsets"}},
+		},
+		{
+			GroupVersion: "apps.kruise.io/v1alpha1",
+			APIResources: []metav1.APIResource{{Name: "clonesets"}, {Name: "statefulsets"}},
+		},
+	}
+
+	types, err := discoverWorkloadTypes(dc, workloadTypeRegistry)
+	if err != nil {
+		t.Fatalf("discoverWorkloadTypes() unexpected error: %v", err)
+	}
+
+	if got := types["statefulset"].GVR.Version; got != "v1beta1" {
+		t.Errorf("statefulset version = %q, want preferred v1beta1", got)
+	}
+	if got := types["cloneset"].GVR.Version; got != "v1alpha1" {
+		t.Errorf("cloneset version = %q, want v1alpha1", got)
+	}
+	if got := types["cloneset"].Source; got != workloadTypeSourceDiscovery {
+		t.Errorf("cloneset source = %q, want %q", got, workloadTypeSourceDiscovery)
+	}
+	if _, ok := types["broadcastjob"]; ok {
+		t.Error("broadcastjob is not served and should be dropped")
+	}
+	if _, ok := workloadTypeRegistry["broadcastjob"]; !ok {
+		t.Error("discoverWorkloadTypes must not modify the input registry")
+	}
+}
@@ -29,6 +29,21 @@ func main() {
 		log.Fatalf("Failed to initialize Kubernetes client: %v", err)
 	}
 
+	// Resolve workload type versions from API discovery and the optional config file
+	if err := handlers.InitWorkloadTypes(); err != nil {
+		log.Fatalf("Failed to initialize workload types: %v", err)
+	}
+
+	// Select the optional storage and network usage provider for cluster metrics
+	if err := handlers.InitClusterUsageProvider(); err != nil {
+		log.Fatalf("Failed to initialize cluster usage provider: %v", err)
+	}
+
+	// Start sampling cluster, node and workload usage for the history endpoints
+	if err := handlers.InitMetricsHistory(); err != nil {
+		log.Fatalf("Failed to initialize metrics history: %v", err)
+	}
+
 	// Set Gin mode from environment
 	ginMode := os.Getenv("GIN_MODE")
 	if ginMode == "" {
@@ -52,13 +67,34 @@ func main() {
 	config.AllowMethods = []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}
 	config.AllowHeaders = []string{"Origin", "Content-Type", "Accept", "Authorization"}
 	r.Use(cors.New(config))
+	// Reject request bodies that do not match the generated API schema
+	r.Use(handlers.ValidateRequestBodies())
 
 	// API routes
+	registerRoutes(r)
+
+	port := os.Getenv("PORT")
+	if port == "" {
+		port = "8080"
+	}
+	r.Run(":" + port)
+}
+
+// registerRoutes adds the API routes to r.
+func registerRoutes(r *gin.Engine) {
 	api := r.Group("/api/v1")
 	{
 		// Cluster endpoints
 		api.GET("/cluster/metrics", handlers.GetClusterMetrics)
+		api.GET("/cluster/metrics/history", handlers.GetClusterMetricsHistory)
+		api.GET("/nodes/:name/metrics/history", handlers.GetNodeMetricsHistory)
 		api.GET("/namespaces", handlers.ListNamespaces)
+		api.GET("/namespaces/:namespace/overview", handlers.GetNamespaceOverview)
+		api.GET("/workload-types", handlers.ListWorkloadTypes)
+		api.GET("/openapi.json", handlers.ServeOpenAPI(r))
+		// All-namespaces listing with server-side filters
+		api.GET("/workloads", handlers.ListClusterWorkloads)
+		api.GET("/rollouts", handlers.ListClusterRollouts)
 		// Rollout management endpoints
 		rollout := api.Group("/rollout")
 		{
@@ -69,22 +105,51 @@ func main() {
 			rollout.GET("/status/:namespace/:name", handlers.GetRolloutStatus)
 			rollout.GET("/history/:namespace/:name", handlers.GetRolloutHistory)
 			rollout.GET("/:namespace/:name/analysis", handlers.GetRolloutAnalysis)
+			rollout.GET("/:namespace/:name/steps", handlers.GetRolloutSteps)
+			rollout.GET("/:namespace/:name/traffic", handlers.GetRolloutTrafficRouting)
+			rollout.GET("/:namespace/:name/batchrelease", handlers.GetRolloutBatchRelease)
+			rollout.GET("/:namespace/:name/diff", handlers.GetRolloutRevisionDiff)
+			rollout.PUT("/:namespace/:name/steps", handlers.UpdateRolloutSteps)
+			rollout.POST("/jump/:namespace/:name", handlers.JumpRolloutStep)
 			rollout.POST("/pause/:namespace/:name", handlers.PauseRollout)
 			rollout.POST("/resume/:namespace/:name", handlers.ResumeRollout)
 			rollout.POST("/undo/:namespace/:name", handlers.UndoRollout)
 			rollout.POST("/restart/:namespace/:name", handlers.RestartRollout)
 			rollout.POST("/promote/:namespace/:name", handlers.PromoteRollout)
 			rollout.POST("/approve/:namespace/:name", handlers.ApproveRollout)
+			rollout.POST("/promote-full/:namespace/:name", handlers.PromoteFullRollout)
 			rollout.POST("/enable/:namespace/:name", handlers.EnableRollout)
 			rollout.POST("/disable/:namespace/:name", handlers.DisableRollout)
 			rollout.POST("/abort/:namespace/:name", handlers.AbortRollout)
 			rollout.POST("/retry/:namespace/:name", handlers.RetryRollout)
 			rollout.POST("/rollback/:namespace/:name", handlers.RollbackRollout)
 			rollout.POST("/set-image/:namespace/:name", handlers.SetRolloutImage)
+			rollout.POST("/create/:namespace", handlers.CreateRollout)
 			rollout.GET("/list/:namespace", handlers.ListAllRollouts)
 			rollout.GET("/active/:namespace", handlers.ListActiveRollouts)
 		}
 
+		// Image pre-warming endpoints
+		api.GET("/nodeimages", handlers.ListNodeImages)
+		imagePullJob := api.Group("/imagepulljob")
+		{
+			imagePullJob.GET("/:namespace", handlers.ListImagePullJobs)
+			imagePullJob.POST("/:namespace", handlers.CreateImagePullJob)
+			imagePullJob.GET("/:namespace/:name", handlers.GetImagePullJob)
+		}
+
+		// Availability policy endpoints
+		pub := api.Group("/podunavailablebudget")
+		{
+			pub.GET("/:namespace", handlers.ListPodUnavailableBudgets)
+			pub.GET("/:namespace/:name", handlers.GetPodUnavailableBudget)
+		}
+		podProbeMarker := api.Group("/podprobemarker")
+		{
+			podProbeMarker.GET("/:namespace", handlers.ListPodProbeMarkers)
+			podProbeMarker.GET("/:namespace/:name", handlers.GetPodProbeMarker)
+		}
+
 		// Workload management endpoints
 		workload := api.Group("/workload")
 		{
@@ -92,15 +157,45 @@ func main() {
 			workload.GET(":namespace/:type/:name", handlers.GetWorkload)
 			workload.GET(":namespace/:type", handlers.ListWorkloads)
 			workload.GET(":namespace/:type/:name/pods", handlers.GetWorkloadPods)
+			workload.GET(":namespace/:type/:name/metrics/history", handlers.GetWorkloadMetricsHistory)
 			workload.POST(":namespace/:type/:name/scale", handlers.ScaleWorkload)
+			workload.GET(":namespace/:type/:name/hpa", handlers.GetWorkloadHPA)
+			workload.POST(":namespace/:type/:name/hpa", handlers.UpdateWorkloadHPA)
 			workload.POST(":namespace/:type/:name/restart", handlers.RestartWorkload)
+			workload.POST(":namespace/:type/:name/containers", handlers.UpdateWorkloadContainers)
+			workload.POST(":namespace/:type/:name/recreate-containers", handlers.RecreateWorkloadContainers)
+			workload.GET(":namespace/:type/:name/recreate-containers/:batch", handlers.GetWorkloadContainerRecreateStatus)
+			workload.POST(":namespace/:type/:name/suspend", handlers.SuspendWorkload)
+			workload.POST(":namespace/:type/:name/resume", handlers.ResumeWorkload)
+			workload.POST(":namespace/:type/:name/trigger", handlers.TriggerWorkload)
+			workload.GET(":namespace/:type/:name/jobs", handlers.ListWorkloadJobs)
+			workload.GET(":namespace/:type/:name/nodes", handlers.GetWorkloadNodeStatus)
+			workload.POST(":namespace/:type/:name/rerun", handlers.RerunWorkload)
 			workload.DELETE(":namespace/:type/:name", handlers.DeleteWorkload)
 		}
 	}
 
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
+	// Typed API; /api/v1 stays unchanged for existing clients
+	apiV2 := r.Group("/api/v2")
+	{
+		rolloutV2 := apiV2.Group("/rollouts")
+		{
+			rolloutV2.GET("/:namespace", handlers.ListRolloutsV2)
+			rolloutV2.GET("/:namespace/:name", handlers.GetRolloutV2)
+			rolloutV2.GET("/:namespace/:name/history", handlers.GetRolloutHistoryV2)
+			rolloutV2.GET("/:namespace/:name/pods", handlers.GetRolloutPodsV2)
+			rolloutV2.GET("/:namespace/:name/revisions", handlers.GetRolloutRevisionsV2)
+			rolloutV2.GET("/:namespace/:name/diff", handlers.GetRolloutRevisionDiff)
+			rolloutV2.GET("/:namespace/:name/batchrelease", handlers.GetRolloutBatchRelease)
+			rolloutV2.GET("/:namespace/:name/events", handlers.GetRolloutEventsV2)
+			rolloutV2.GET("/:namespace/:name/metrics", handlers.GetRolloutMetrics)
+		}
+		workloadV2 := apiV2.Group("/workloads")
+		{
+			workloadV2.GET("/:namespace/:type", handlers.ListWorkloadsV2)
+			workloadV2.GET("/:namespace/:type/:name", handlers.GetWorkloadV2)
+			workloadV2.GET("/:namespace/:type/:name/pods", handlers.GetWorkloadPodsV2)
+			workloadV2.GET("/:namespace/:type/:name/metrics", handlers.GetWorkloadMetrics)
+		}
 	}
-	r.Run(":" + port)
 }
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/openkruise/kruise-dashboard/extensions-backend/pkg/openapi"
+)
+
+func TestOpenAPIOperationIDsUnique(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	registerRoutes(r)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/openapi.json", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET /api/v1/openapi.json = %d", w.Code)
+	}
+	var doc openapi.Document
+	if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("unmarshal document: %v", err)
+	}
+
+	seen := map[string]string{}
+	count := 0
+	for path, methods := range doc.Paths {
+		for method, item := range methods {
+			count++
+			route := method + " " + path
+			if item.OperationID == "" {
+				t.Errorf("%s has no operationId", route)
+				continue
+			}
+			if other, ok := seen[item.OperationID]; ok {
+				t.Errorf("operationId %q is used by both %s and %s", item.OperationID, other, route)
+			}
+			seen[item.OperationID] = route
+		}
+	}
+	if count != len(r.Routes()) {
+		t.Errorf("document has %d operations, route table has %d", count, len(r.Routes()))
+	}
+}
@@ -0,0 +1,224 @@
+// Package diff compares decoded JSON documents as RFC 6902 JSON patches and compares texts as
+// unified diffs.
+package diff
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Operation is an RFC 6902 JSON patch operation.
+type Operation struct {
+	Op    string      `json:"op" openapi:"enum=add|remove|replace"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// JSONPatch returns the operations that turn from into to. Both are decoded JSON values (maps,
+// slices and scalars). Arrays are compared index by index.
+func JSONPatch(from, to interface{}) []Operation {
+	ops := []Operation{}
+	return appendPatch(ops, "", from, to)
+}
+
+func appendPatch(ops []Operation, path string, from, to interface{}) []Operation {
+	switch fromValue := from.(type) {
+	case map[string]interface{}:
+		toValue, ok := to.(map[string]interface{})
+		if !ok {
+			break
+		}
+		keys := make([]string, 0, len(fromValue)+len(toValue))
+		for key := range fromValue {
+			keys = append(keys, key)
+		}
+		for key := range toValue {
+			if _, ok := fromValue[key]; !ok {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			childPath := path + "/" + escapePointer(key)
+			fromChild, inFrom := fromValue[key]
+			toChild, inTo := toValue[key]
+			switch {
+			case !inTo:
+				ops = append(ops, Operation{Op: "remove", Path: childPath})
+			case !inFrom:
+				ops = append(ops, Operation{Op: "add", Path: childPath, Value: toChild})
+			default:
+				ops = appendPatch(ops, childPath, fromChild, toChild)
+			}
+		}
+		return ops
+	case []interface{}:
+		toValue, ok := to.([]interface{})
+		if !ok {
+			break
+		}
+		common := min(len(fromValue), len(toValue))
+		for i := 0; i < common; i++ {
+			ops = appendPatch(ops, path+"/"+strconv.Itoa(i), fromValue[i], toValue[i])
+		}
+		for i := common; i < len(toValue); i++ {
+			ops = append(ops, Operation{Op: "add", Path: path + "/" + strconv.Itoa(i), Value: toValue[i]})
+		}
+		// Remove from the end so that earlier indexes stay valid.
+		for i := len(fromValue) - 1; i >= common; i-- {
+			ops = append(ops, Operation{Op: "remove", Path: path + "/" + strconv.Itoa(i)})
+		}
+		return ops
+	}
+
+	if !reflect.DeepEqual(from, to) {
+		ops = append(ops, Operation{Op: "replace", Path: path, Value: to})
+	}
+	return ops
+}
+
+// escapePointer escapes a key for use as a JSON pointer token.
+func escapePointer(key string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(key, "~", "~0"), "/", "~1")
+}
+
+// SplitPointer splits a JSON pointer into its unescaped tokens.
+func SplitPointer(pointer string) []string {
+	if pointer == "" {
+		return nil
+	}
+	tokens := strings.Split(strings.TrimPrefix(pointer, "/"), "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens
+}
+
+// Unified returns a unified diff of two texts with the given lines of context, or "" if they are
+// equal.
+func Unified(fromName, toName, from, to string, context int) string {
+	a := splitLines(from)
+	b := splitLines(to)
+	edits := lineEdits(a, b)
+
+	changed := false
+	for _, e := range edits {
+		if e.kind != ' ' {
+			changed = true
+			break
+		}
+	}
+	if !changed {
+		return ""
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", fromName, toName)
+	for start := 0; start < len(edits); {
+		// Find the next change and the extent of its hunk.
+		first := start
+		for first < len(edits) && edits[first].kind == ' ' {
+			first++
+		}
+		if first == len(edits) {
+			break
+		}
+		hunkStart := max(first-context, start)
+		hunkEnd := first
+		for i := first; i < len(edits); i++ {
+			if edits[i].kind != ' ' {
+				hunkEnd = i + 1
+				continue
+			}
+			if i-hunkEnd >= 2*context {
+				break
+			}
+		}
+		hunkEnd = min(hunkEnd+context, len(edits))
+
+		fromStart, toStart := edits[hunkStart].fromLine, edits[hunkStart].toLine
+		fromCount, toCount := 0, 0
+		for _, e := range edits[hunkStart:hunkEnd] {
+			if e.kind != '+' {
+				fromCount++
+			}
+			if e.kind != '-' {
+				toCount++
+			}
+		}
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n", hunkRange(fromStart, fromCount), hunkRange(toStart, toCount))
+		for _, e := range edits[hunkStart:hunkEnd] {
+			out.WriteByte(e.kind)
+			out.WriteString(e.text)
+			out.WriteByte('\n')
+		}
+		start = hunkEnd
+	}
+	return out.String()
+}
+
+type lineEdit struct {
+	kind     byte // ' ', '-' or '+'
+	text     string
+	fromLine int // 1-based line in from where this edit sits
+	toLine   int // 1-based line in to where this edit sits
+}
+
+// lineEdits computes a shortest edit script between a and b from their longest common subsequence.
+func lineEdits(a, b []string) []lineEdit {
+	n, m := len(a), len(b)
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	edits := make([]lineEdit, 0, n+m)
+	i, j := 0, 0
+	for i < n || j < m {
+		switch {
+		case i < n && j < m && a[i] == b[j]:
+			edits = append(edits, lineEdit{kind: ' ', text: a[i], fromLine: i + 1, toLine: j + 1})
+			i++
+			j++
+		case i < n && (j == m || lcs[i+1][j] >= lcs[i][j+1]):
+			// Deletions go before insertions, as in diff(1).
+			edits = append(edits, lineEdit{kind: '-', text: a[i], fromLine: i + 1, toLine: j + 1})
+			i++
+		default:
+			edits = append(edits, lineEdit{kind: '+', text: b[j], fromLine: i + 1, toLine: j + 1})
+			j++
+		}
+	}
+	return edits
+}
+
+func hunkRange(start, count int) string {
+	if count == 0 {
+		// An empty range names the line before it.
+		return fmt.Sprintf("%d,0", start-1)
+	}
+	if count == 1 {
+		return strconv.Itoa(start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+func splitLines(text string) []string {
+	text = strings.TrimSuffix(text, "\n")
+	if text == "" {
+		return nil
+	}
+	return strings.Split(text, "\n")
+}
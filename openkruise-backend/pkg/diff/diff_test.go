@@ -0,0 +1,57 @@
+package diff
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestJSONPatch(t *testing.T) {
+	from := map[string]interface{}{
+		"labels": map[string]interface{}{"app": "web", "a/b": "x"},
+		"containers": []interface{}{
+			map[string]interface{}{"name": "app", "image": "app:v1"},
+			map[string]interface{}{"name": "sidecar", "image": "proxy:v1"},
+		},
+	}
+	to := map[string]interface{}{
+		"labels": map[string]interface{}{"app": "web", "tier": "front"},
+		"containers": []interface{}{
+			map[string]interface{}{"name": "app", "image": "app:v2"},
+		},
+	}
+
+	want := []Operation{
+		{Op: "replace", Path: "/containers/0/image", Value: "app:v2"},
+		{Op: "remove", Path: "/containers/1"},
+		{Op: "remove", Path: "/labels/a~1b"},
+		{Op: "add", Path: "/labels/tier", Value: "front"},
+	}
+	if got := JSONPatch(from, to); !reflect.DeepEqual(got, want) {
+		t.Errorf("JSONPatch() = %+v, want %+v", got, want)
+	}
+	if got := JSONPatch(from, from); len(got) != 0 {
+		t.Errorf("JSONPatch(equal) = %+v, want none", got)
+	}
+}
+
+func TestSplitPointer(t *testing.T) {
+	if got := SplitPointer("/metadata/annotations/a~1b~0c"); !reflect.DeepEqual(got, []string{"metadata", "annotations", "a/b~c"}) {
+		t.Errorf("SplitPointer() = %v", got)
+	}
+}
+
+func TestUnified(t *testing.T) {
+	from := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk\n"
+	to := "a\nb\nc\nD\ne\nf\ng\nh\ni\nj\nk\nl\n"
+
+	// Changes more than twice the context apart get separate hunks.
+	want := "--- from\n+++ to\n" +
+		"@@ -1,7 +1,7 @@\n a\n b\n c\n-d\n+D\n e\n f\n g\n" +
+		"@@ -9,3 +9,4 @@\n i\n j\n k\n+l\n"
+	if got := Unified("from", "to", from, to, 3); got != want {
+		t.Errorf("Unified() =\n%s\nwant\n%s", got, want)
+	}
+	if got := Unified("from", "to", from, from, 3); got != "" {
+		t.Errorf("Unified(equal) = %q, want empty", got)
+	}
+}
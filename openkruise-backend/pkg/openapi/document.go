@@ -0,0 +1,213 @@
+package openapi
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// Route is one entry of the router's route table.
+type Route struct {
+	Method  string
+	Path    string
+	Handler string
+}
+
+// Parameter is an OpenAPI parameter object.
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// Operation describes a route beyond what the route table knows: its summary, query parameters
+// and the Go values whose types are the request and response bodies.
+type Operation struct {
+	Summary  string
+	Query    []Parameter
+	Request  interface{}
+	Response interface{}
+	// Stream marks server-sent event endpoints, which have no JSON response body.
+	Stream bool
+}
+
+// Key returns the lookup key of a route's Operation, e.g. "POST /api/v1/rollout/pause/:namespace/:name".
+func Key(method, path string) string {
+	return method + " " + path
+}
+
+// Document is an OpenAPI 3 document.
+type Document struct {
+	OpenAPI string                          `json:"openapi"`
+	Info    Info                            `json:"info"`
+	Paths   map[string]map[string]*PathItem `json:"paths"`
+}
+
+// Info is the OpenAPI info object.
+type Info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+// PathItem is an OpenAPI operation object.
+type PathItem struct {
+	OperationID string               `json:"operationId"`
+	Summary     string               `json:"summary,omitempty"`
+	Tags        []string             `json:"tags,omitempty"`
+	Parameters  []Parameter          `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+}
+
+// RequestBody is an OpenAPI request body object.
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+// Response is an OpenAPI response object.
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+// MediaType is an OpenAPI media type object.
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Config holds the document-wide settings of Build.
+type Config struct {
+	Title   string
+	Version string
+	// ErrorResponse is a value of the error body type shared by all endpoints.
+	ErrorResponse interface{}
+}
+
+// Build generates the document for every route in routes. Routes without an Operation are still
+// listed, with their handler name as summary and an untyped response.
+func Build(cfg Config, routes []Route, operations map[string]Operation) *Document {
+	doc := &Document{
+		OpenAPI: "3.0.3",
+		Info:    Info{Title: cfg.Title, Version: cfg.Version},
+		Paths:   map[string]map[string]*PathItem{},
+	}
+	errorSchema := SchemaOf(cfg.ErrorResponse)
+
+	sorted := append([]Route{}, routes...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Path != sorted[j].Path {
+			return sorted[i].Path < sorted[j].Path
+		}
+		return sorted[i].Method < sorted[j].Method
+	})
+
+	for _, route := range sorted {
+		op := operations[Key(route.Method, route.Path)]
+		path, pathParams := convertPath(route.Path)
+		item := &PathItem{
+			OperationID: operationID(route),
+			Summary:     op.Summary,
+			Tags:        routeTags(route.Path),
+			Responses:   map[string]*Response{},
+		}
+		if item.Summary == "" {
+			item.Summary = handlerName(route.Handler)
+		}
+		for _, name := range pathParams {
+			item.Parameters = append(item.Parameters, Parameter{Name: name, In: "path", Required: true, Schema: &Schema{Type: "string"}})
+		}
+		item.Parameters = append(item.Parameters, op.Query...)
+
+		if schema := SchemaOf(op.Request); schema != nil {
+			item.RequestBody = &RequestBody{
+				Required: len(schema.Required) > 0,
+				Content:  map[string]MediaType{"application/json": {Schema: schema}},
+			}
+		}
+
+		if op.Stream {
+			item.Responses["200"] = &Response{
+				Description: "Server-sent event stream",
+				Content:     map[string]MediaType{"text/event-stream": {Schema: &Schema{Type: "string"}}},
+			}
+		} else {
+			data := SchemaOf(op.Response)
+			if data == nil {
+				data = &Schema{}
+			}
+			item.Responses["200"] = &Response{
+				Description: "OK",
+				Content: map[string]MediaType{"application/json": {Schema: &Schema{
+					Type:       "object",
+					Properties: map[string]*Schema{"data": data},
+					Required:   []string{"data"},
+				}}},
+			}
+		}
+		if errorSchema != nil {
+			item.Responses["default"] = &Response{
+				Description: "Error",
+				Content:     map[string]MediaType{"application/json": {Schema: errorSchema}},
+			}
+		}
+
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = map[string]*PathItem{}
+		}
+		doc.Paths[path][strings.ToLower(route.Method)] = item
+	}
+	return doc
+}
+
+// HasBody reports whether requests of method carry a body that should be validated.
+func HasBody(method string) bool {
+	return method == http.MethodPost || method == http.MethodPut || method == http.MethodPatch
+}
+
+// convertPath turns gin's ":name" segments into OpenAPI "{name}" and returns the parameter names.
+func convertPath(path string) (string, []string) {
+	segments := strings.Split(path, "/")
+	params := []string{}
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			name := segment[1:]
+			params = append(params, name)
+			segments[i] = "{" + name + "}"
+		}
+	}
+	return strings.Join(segments, "/"), params
+}
+
+// routeTags groups routes by API version and first path segment, e.g. "v1 rollout".
+func routeTags(path string) []string {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	if len(segments) >= 3 && segments[0] == "api" {
+		return []string{segments[1] + " " + segments[2]}
+	}
+	return nil
+}
+
+func handlerName(handler string) string {
+	name := handler[strings.LastIndex(handler, ".")+1:]
+	return strings.TrimSuffix(name, "-fm")
+}
+
+// operationID derives the ID from the method and path, e.g. "getApiV2RolloutsByNamespaceByNameDiff",
+// so it stays unique when one handler serves several routes.
+func operationID(route Route) string {
+	var id strings.Builder
+	id.WriteString(strings.ToLower(route.Method))
+	for _, segment := range strings.Split(route.Path, "/") {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			id.WriteString("By")
+			segment = segment[1:]
+		}
+		for _, word := range strings.FieldsFunc(segment, func(r rune) bool { return r == '-' || r == '_' || r == '.' }) {
+			id.WriteString(strings.ToUpper(word[:1]) + word[1:])
+		}
+	}
+	return id.String()
+}
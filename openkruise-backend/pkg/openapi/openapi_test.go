@@ -0,0 +1,115 @@
+package openapi
+
+import (
+	"testing"
+)
+
+type testContainer struct {
+	Name string `json:"name" openapi:"required"`
+}
+
+type testRequest struct {
+	Image       string            `json:"image" openapi:"required"`
+	Policy      string            `json:"policy" openapi:"enum=Fail|Ignore"`
+	Parallelism int               `json:"parallelism" openapi:"min=0,max=10"`
+	Legacy      bool              `json:"legacy" openapi:"deprecated"`
+	Containers  []testContainer   `json:"containers"`
+	Selector    map[string]string `json:"selector"`
+	Deadline    *int64            `json:"deadline"`
+	internal    string
+}
+
+type testEmbedded struct {
+	testContainer
+	Extra string `json:"extra,omitempty"`
+}
+
+func TestSchemaOf(t *testing.T) {
+	schema := SchemaOf(testRequest{})
+	if schema.Type != "object" || len(schema.Properties) != 7 {
+		t.Fatalf("SchemaOf() = %+v, want object with 7 properties", schema)
+	}
+	if len(schema.Required) != 1 || schema.Required[0] != "image" {
+		t.Errorf("Required = %v, want [image]", schema.Required)
+	}
+	if got := schema.Properties["policy"].Enum; len(got) != 2 || got[1] != "Ignore" {
+		t.Errorf("policy enum = %v", got)
+	}
+	if !schema.Properties["legacy"].Deprecated {
+		t.Error("legacy should be deprecated")
+	}
+	if !schema.Properties["deadline"].Nullable || schema.Properties["deadline"].Format != "int64" {
+		t.Errorf("deadline = %+v", schema.Properties["deadline"])
+	}
+	if schema.Properties["containers"].Items.Required[0] != "name" {
+		t.Errorf("containers items = %+v", schema.Properties["containers"].Items)
+	}
+
+	embedded := SchemaOf(testEmbedded{})
+	if _, ok := embedded.Properties["name"]; !ok {
+		t.Errorf("embedded struct fields should be flattened, got %+v", embedded.Properties)
+	}
+}
+
+func TestValidateJSON(t *testing.T) {
+	schema := SchemaOf(testRequest{})
+
+	tests := []struct {
+		name       string
+		body       string
+		wantFields []string
+	}{
+		{name: "valid", body: `{"image":"nginx","policy":"Fail","parallelism":2,"containers":[{"name":"app"}],"selector":{"app":"web"},"deadline":null}`},
+		{name: "empty body", body: ``, wantFields: []string{"image"}},
+		{name: "invalid json", body: `{"image":`, wantFields: []string{""}},
+		{name: "unknown field", body: `{"image":"nginx","isInit":true}`, wantFields: []string{"isInit"}},
+		{name: "wrong types", body: `{"image":1,"parallelism":"2"}`, wantFields: []string{"image", "parallelism"}},
+		{name: "enum and range", body: `{"image":"nginx","policy":"Retry","parallelism":11}`, wantFields: []string{"parallelism", "policy"}},
+		{name: "nested", body: `{"image":"nginx","containers":[{"name":"app"},{}],"selector":{"app":1}}`, wantFields: []string{"containers[1].name", "selector.app"}},
+		{name: "fractional integer", body: `{"image":"nginx","parallelism":1.5}`, wantFields: []string{"parallelism"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := ValidateJSON(schema, []byte(tt.body))
+			if len(errs) != len(tt.wantFields) {
+				t.Fatalf("ValidateJSON() = %+v, want fields %v", errs, tt.wantFields)
+			}
+			for i, field := range tt.wantFields {
+				if errs[i].Field != field {
+					t.Errorf("errs[%d].Field = %q, want %q", i, errs[i].Field, field)
+				}
+			}
+		})
+	}
+}
+
+func TestBuild(t *testing.T) {
+	routes := []Route{
+		{Method: "POST", Path: "/api/v1/rollout/set-image/:namespace/:name", Handler: "github.com/x/handlers.SetRolloutImage"},
+		{Method: "GET", Path: "/api/v1/namespaces", Handler: "github.com/x/handlers.ListNamespaces"},
+	}
+	ops := map[string]Operation{
+		Key("POST", "/api/v1/rollout/set-image/:namespace/:name"): {Summary: "Set image", Request: testRequest{}},
+	}
+
+	doc := Build(Config{Title: "test", Version: "v1"}, routes, ops)
+	item := doc.Paths["/api/v1/rollout/set-image/{namespace}/{name}"]["post"]
+	if item == nil {
+		t.Fatalf("set-image operation missing, paths = %v", doc.Paths)
+	}
+	if item.Summary != "Set image" || item.OperationID != "postApiV1RolloutSetImageByNamespaceByName" || len(item.Parameters) != 2 {
+		t.Errorf("set-image operation = %+v", item)
+	}
+	if item.RequestBody == nil || !item.RequestBody.Required {
+		t.Errorf("set-image request body = %+v", item.RequestBody)
+	}
+	if item.Tags[0] != "v1 rollout" {
+		t.Errorf("Tags = %v, want [v1 rollout]", item.Tags)
+	}
+
+	undocumented := doc.Paths["/api/v1/namespaces"]["get"]
+	if undocumented == nil || undocumented.Summary != "ListNamespaces" {
+		t.Errorf("undocumented route = %+v, want handler name as summary", undocumented)
+	}
+}
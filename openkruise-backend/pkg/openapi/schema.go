@@ -0,0 +1,142 @@
+// Package openapi generates an OpenAPI 3 document from the route table and the Go types of
+// request and response bodies, and validates request payloads against the generated schemas.
+package openapi
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Schema is the subset of the OpenAPI 3 schema object the generator emits.
+type Schema struct {
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties interface{}        `json:"additionalProperties,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Deprecated           bool               `json:"deprecated,omitempty"`
+}
+
+// fieldOptions are the options of an `openapi` struct tag, e.g.
+// `openapi:"required,enum=Fail|Ignore,min=1,max=600,deprecated"`.
+type fieldOptions struct {
+	required   bool
+	deprecated bool
+	enum       []string
+	min        *float64
+	max        *float64
+}
+
+func parseFieldOptions(tag string) fieldOptions {
+	var opts fieldOptions
+	for _, part := range strings.Split(tag, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "required":
+			opts.required = true
+		case "deprecated":
+			opts.deprecated = true
+		case "enum":
+			opts.enum = strings.Split(value, "|")
+		case "min":
+			if v, err := strconv.ParseFloat(value, 64); err == nil {
+				opts.min = &v
+			}
+		case "max":
+			if v, err := strconv.ParseFloat(value, 64); err == nil {
+				opts.max = &v
+			}
+		}
+	}
+	return opts
+}
+
+// SchemaOf returns the schema of v's type. A nil v yields nil.
+func SchemaOf(v interface{}) *Schema {
+	if v == nil {
+		return nil
+	}
+	return schemaForType(reflect.TypeOf(v), map[reflect.Type]bool{})
+}
+
+func schemaForType(t reflect.Type, visiting map[reflect.Type]bool) *Schema {
+	switch t.Kind() {
+	case reflect.Ptr:
+		schema := schemaForType(t.Elem(), visiting)
+		schema.Nullable = true
+		return schema
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: schemaForType(t.Elem(), visiting)}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: schemaForType(t.Elem(), visiting)}
+	case reflect.Struct:
+		if visiting[t] {
+			return &Schema{Type: "object"}
+		}
+		visiting[t] = true
+		defer delete(visiting, t)
+
+		schema := &Schema{Type: "object", Properties: map[string]*Schema{}, AdditionalProperties: false}
+		addStructFields(schema, t, visiting)
+		return schema
+	}
+	// interface{} and anything else accepts any value.
+	return &Schema{}
+}
+
+func addStructFields(schema *Schema, t reflect.Type, visiting map[reflect.Type]bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		jsonTag := field.Tag.Get("json")
+		if jsonTag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(jsonTag, ",")
+
+		// Embedded structs without a json name are flattened, as encoding/json does.
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			addStructFields(schema, field.Type, visiting)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		property := schemaForType(field.Type, visiting)
+		opts := parseFieldOptions(field.Tag.Get("openapi"))
+		property.Deprecated = opts.deprecated
+		property.Enum = opts.enum
+		property.Minimum = opts.min
+		property.Maximum = opts.max
+		if description := field.Tag.Get("description"); description != "" {
+			property.Description = description
+		}
+		if opts.required {
+			schema.Required = append(schema.Required, name)
+		}
+		schema.Properties[name] = property
+	}
+}
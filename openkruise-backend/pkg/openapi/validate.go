@@ -0,0 +1,162 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// FieldError is a schema violation at a field path such as "containers[0]" or "nodeSelector.pool".
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidateJSON decodes body and validates it against schema. An empty body is validated as an
+// empty object.
+func ValidateJSON(schema *Schema, body []byte) []FieldError {
+	if len(bytes.TrimSpace(body)) == 0 {
+		body = []byte("{}")
+	}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return []FieldError{{Field: "", Message: "invalid JSON: " + err.Error()}}
+	}
+	return Validate(schema, value, "")
+}
+
+// Validate checks a decoded JSON value (numbers as json.Number) against schema.
+func Validate(schema *Schema, value interface{}, path string) []FieldError {
+	if schema == nil || value == nil {
+		return nil
+	}
+
+	fail := func(format string, args ...interface{}) []FieldError {
+		return []FieldError{{Field: path, Message: fmt.Sprintf(format, args...)}}
+	}
+
+	switch schema.Type {
+	case "object":
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			return fail("must be an object")
+		}
+		return validateObject(schema, obj, path)
+	case "array":
+		items, ok := value.([]interface{})
+		if !ok {
+			return fail("must be an array")
+		}
+		var errs []FieldError
+		for i, item := range items {
+			errs = append(errs, Validate(schema.Items, item, fmt.Sprintf("%s[%d]", path, i))...)
+		}
+		return errs
+	case "string":
+		s, ok := value.(string)
+		if !ok {
+			return fail("must be a string")
+		}
+		if len(schema.Enum) > 0 && !containsString(schema.Enum, s) {
+			return fail("must be one of: %s", strings.Join(schema.Enum, ", "))
+		}
+	case "integer", "number":
+		n, ok := value.(json.Number)
+		if !ok {
+			if schema.Type == "integer" {
+				return fail("must be an integer")
+			}
+			return fail("must be a number")
+		}
+		var f float64
+		if schema.Type == "integer" {
+			i, err := n.Int64()
+			if err != nil {
+				return fail("must be an integer")
+			}
+			f = float64(i)
+		} else {
+			parsed, err := n.Float64()
+			if err != nil {
+				return fail("must be a number")
+			}
+			f = parsed
+		}
+		if schema.Minimum != nil && f < *schema.Minimum {
+			return fail("must be >= %v", *schema.Minimum)
+		}
+		if schema.Maximum != nil && f > *schema.Maximum {
+			return fail("must be <= %v", *schema.Maximum)
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return fail("must be a boolean")
+		}
+	}
+	return nil
+}
+
+func validateObject(schema *Schema, obj map[string]interface{}, path string) []FieldError {
+	var errs []FieldError
+	for _, name := range schema.Required {
+		if v, ok := obj[name]; !ok || isEmptyValue(v) {
+			errs = append(errs, FieldError{Field: joinPath(path, name), Message: "is required"})
+		}
+	}
+
+	names := make([]string, 0, len(obj))
+	for name := range obj {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		fieldPath := joinPath(path, name)
+		if property, ok := schema.Properties[name]; ok {
+			errs = append(errs, Validate(property, obj[name], fieldPath)...)
+			continue
+		}
+		switch additional := schema.AdditionalProperties.(type) {
+		case *Schema:
+			errs = append(errs, Validate(additional, obj[name], fieldPath)...)
+		case bool:
+			if !additional {
+				errs = append(errs, FieldError{Field: fieldPath, Message: "unknown field"})
+			}
+		}
+	}
+	return errs
+}
+
+// isEmptyValue treats null, "" and [] as missing for required fields.
+func isEmptyValue(v interface{}) bool {
+	switch value := v.(type) {
+	case nil:
+		return true
+	case string:
+		return strings.TrimSpace(value) == ""
+	case []interface{}:
+		return len(value) == 0
+	}
+	return false
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
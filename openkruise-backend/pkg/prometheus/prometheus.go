@@ -0,0 +1,98 @@
+// Package prometheus runs instant queries against the Prometheus HTTP API.
+package prometheus
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Client queries one Prometheus server.
+type Client struct {
+	// BaseURL is the server address, e.g. http://prometheus.monitoring:9090.
+	BaseURL    string
+	HTTPClient *http.Client
+}
+
+type queryResponse struct {
+	Status string `json:"status"`
+	Error  string `json:"error"`
+	Data   struct {
+		ResultType string          `json:"resultType"`
+		Result     json.RawMessage `json:"result"`
+	} `json:"data"`
+}
+
+type vectorSample struct {
+	Value [2]interface{} `json:"value"`
+}
+
+// parseSampleValue parses the value of a [timestamp, "value"] pair.
+func parseSampleValue(pair [2]interface{}) (float64, error) {
+	raw, ok := pair[1].(string)
+	if !ok {
+		return 0, fmt.Errorf("unexpected sample value %v", pair[1])
+	}
+	return strconv.ParseFloat(raw, 64)
+}
+
+// Query runs an instant query and returns the sum of the resulting vector, or the scalar. ok is
+// false when the vector is empty, which usually means the exporter providing the metric is not
+// installed.
+func (c *Client) Query(ctx context.Context, query string) (value float64, ok bool, err error) {
+	endpoint := strings.TrimSuffix(c.BaseURL, "/") + "/api/v1/query?" + url.Values{"query": {query}}.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return 0, false, err
+	}
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return 0, false, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return 0, false, err
+	}
+
+	var parsed queryResponse
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return 0, false, fmt.Errorf("prometheus returned %s: %w", resp.Status, err)
+	}
+	if parsed.Status != "success" {
+		return 0, false, fmt.Errorf("prometheus query failed: %s", parsed.Error)
+	}
+
+	switch parsed.Data.ResultType {
+	case "scalar":
+		var pair [2]interface{}
+		if err := json.Unmarshal(parsed.Data.Result, &pair); err != nil {
+			return 0, false, err
+		}
+		value, err := parseSampleValue(pair)
+		return value, err == nil, err
+	case "vector":
+		var samples []vectorSample
+		if err := json.Unmarshal(parsed.Data.Result, &samples); err != nil {
+			return 0, false, err
+		}
+		for _, sample := range samples {
+			v, err := parseSampleValue(sample.Value)
+			if err != nil {
+				return 0, false, err
+			}
+			value += v
+		}
+		return value, len(samples) > 0, nil
+	}
+	return 0, false, fmt.Errorf("unsupported result type %q", parsed.Data.ResultType)
+}
@@ -0,0 +1,49 @@
+package prometheus
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestQuery(t *testing.T) {
+	responses := map[string]string{
+		"vector":  `{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[1700000000,"1.5"]},{"metric":{},"value":[1700000000,"2"]}]}}`,
+		"scalar":  `{"status":"success","data":{"resultType":"scalar","result":[1700000000,"42"]}}`,
+		"empty":   `{"status":"success","data":{"resultType":"vector","result":[]}}`,
+		"invalid": `{"status":"error","errorType":"bad_data","error":"parse error"}`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/query" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(responses[r.URL.Query().Get("query")]))
+	}))
+	defer server.Close()
+	client := &Client{BaseURL: server.URL + "/"}
+
+	tests := []struct {
+		query   string
+		want    float64
+		wantOK  bool
+		wantErr bool
+	}{
+		{query: "vector", want: 3.5, wantOK: true},
+		{query: "scalar", want: 42, wantOK: true},
+		{query: "empty"},
+		{query: "invalid", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			value, ok, err := client.Query(context.Background(), tt.query)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Query() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if value != tt.want || ok != tt.wantOK {
+				t.Errorf("Query() = %v, %v, want %v, %v", value, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
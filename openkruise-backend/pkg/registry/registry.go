@@ -0,0 +1,396 @@
+// Package registry resolves container image references to manifest digests through the registry
+// HTTP API v2, authenticating with bearer tokens or basic credentials when the registry asks for
+// them.
+package registry
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	dockerHubDomain   = "docker.io"
+	dockerHubRegistry = "registry-1.docker.io"
+	dockerHubAuthHost = "auth.docker.io"
+	defaultTag        = "latest"
+
+	// maxManifestBytes is the largest manifest hashed when a registry omits Docker-Content-Digest;
+	// registries reject larger manifests. maxTokenBytes bounds a token endpoint response.
+	maxManifestBytes = 4 << 20
+	maxTokenBytes    = 1 << 20
+)
+
+// manifestMediaTypes are accepted for manifests, preferring indexes so that the digest of a
+// multi-platform image is the one a pull by tag would record.
+var manifestMediaTypes = []string{
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+}
+
+// Reference is a parsed image reference.
+type Reference struct {
+	// Registry is the host serving the image, e.g. registry-1.docker.io for Docker Hub.
+	Registry   string
+	Repository string
+	Tag        string
+	Digest     string
+}
+
+// ParseReference parses an image reference the way container runtimes do: the first path
+// component is a registry if it contains a dot or a port or is localhost, otherwise the image is
+// on Docker Hub. Without tag or digest the tag is latest.
+func ParseReference(image string) (Reference, error) {
+	var ref Reference
+	name := strings.TrimSpace(image)
+	if name == "" {
+		return ref, fmt.Errorf("empty image reference")
+	}
+	if at := strings.Index(name, "@"); at >= 0 {
+		ref.Digest = name[at+1:]
+		name = name[:at]
+		if !strings.Contains(ref.Digest, ":") {
+			return ref, fmt.Errorf("invalid digest in %q", image)
+		}
+	}
+	if colon := strings.LastIndex(name, ":"); colon > strings.LastIndex(name, "/") {
+		ref.Tag = name[colon+1:]
+		name = name[:colon]
+	}
+
+	ref.Registry = dockerHubDomain
+	if slash := strings.Index(name, "/"); slash >= 0 {
+		first := name[:slash]
+		if strings.ContainsAny(first, ".:") || first == "localhost" {
+			ref.Registry = first
+			name = name[slash+1:]
+		}
+	}
+	if ref.Registry == dockerHubDomain {
+		ref.Registry = dockerHubRegistry
+		if !strings.Contains(name, "/") {
+			name = "library/" + name
+		}
+	}
+	if name == "" || name != strings.ToLower(name) {
+		return ref, fmt.Errorf("invalid repository in %q", image)
+	}
+	ref.Repository = name
+	if ref.Tag == "" && ref.Digest == "" {
+		ref.Tag = defaultTag
+	}
+	return ref, nil
+}
+
+// Credentials authenticate to one registry.
+type Credentials struct {
+	Username string
+	Password string
+}
+
+// Keychain holds credentials by registry host, as found in the auths of a docker config file.
+type Keychain map[string]Credentials
+
+// ParseDockerConfig reads the credentials of a kubernetes.io/dockerconfigjson secret
+// ({"auths": {...}}) or of a legacy kubernetes.io/dockercfg secret.
+func ParseDockerConfig(data []byte) (Keychain, error) {
+	type entry struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+		Auth     string `json:"auth"`
+	}
+	var config struct {
+		Auths map[string]entry `json:"auths"`
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, err
+	}
+	if config.Auths == nil {
+		if err := json.Unmarshal(data, &config.Auths); err != nil {
+			return nil, err
+		}
+	}
+
+	keychain := Keychain{}
+	for server, e := range config.Auths {
+		creds := Credentials{Username: e.Username, Password: e.Password}
+		if e.Auth != "" {
+			decoded, err := base64.StdEncoding.DecodeString(e.Auth)
+			if err != nil {
+				return nil, fmt.Errorf("invalid auth for %s: %w", server, err)
+			}
+			creds.Username, creds.Password, _ = strings.Cut(string(decoded), ":")
+		}
+		keychain[registryHost(server)] = creds
+	}
+	return keychain, nil
+}
+
+// registryHost strips the scheme and path that docker config keys may carry, and maps the
+// Docker Hub aliases, such as https://index.docker.io/v1/, to the registry host.
+func registryHost(server string) string {
+	host := server
+	if _, rest, found := strings.Cut(host, "://"); found {
+		host = rest
+	}
+	host, _, _ = strings.Cut(host, "/")
+	if host == dockerHubDomain || host == "index.docker.io" {
+		return dockerHubRegistry
+	}
+	return host
+}
+
+// Resolver resolves image references to digests.
+type Resolver struct {
+	Client *http.Client
+	// TokenRealmHosts may issue bearer tokens for any registry. A registry may always use a realm
+	// on its own host, and Docker Hub its auth.docker.io. Realms must use https.
+	TokenRealmHosts []string
+}
+
+// NewClient returns an HTTP client for registries that refuses to connect to loopback,
+// link-local (such as cloud metadata endpoints) and unspecified addresses, whatever host name,
+// realm or redirect leads there. Private networks stay reachable for in-cluster registries.
+func NewClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(_, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(host)
+			if ip == nil || ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsUnspecified() || ip.IsMulticast() {
+				return fmt.Errorf("connection to %s is not allowed", host)
+			}
+			return nil
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = dialer.DialContext
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
+
+// Resolve returns the manifest digest of image. An image pinned by digest is checked to exist.
+// Credentials for the image's registry are taken from keychain, which may be nil.
+func (r *Resolver) Resolve(ctx context.Context, image string, keychain Keychain) (string, error) {
+	ref, err := ParseReference(image)
+	if err != nil {
+		return "", err
+	}
+	manifest := ref.Tag
+	if ref.Digest != "" {
+		manifest = ref.Digest
+	}
+	manifestURL := fmt.Sprintf("https://%s/v2/%s/manifests/%s", ref.Registry, ref.Repository, manifest)
+
+	authorization := ""
+	resp, err := r.request(ctx, http.MethodHead, manifestURL, authorization)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusUnauthorized {
+		creds, hasCreds := keychain[ref.Registry]
+		if authorization, err = r.authorize(ctx, ref.Registry, resp.Header.Get("WWW-Authenticate"), creds, hasCreds); err != nil {
+			return "", fmt.Errorf("authenticate to %s: %w", ref.Registry, err)
+		}
+		if resp, err = r.request(ctx, http.MethodHead, manifestURL, authorization); err != nil {
+			return "", err
+		}
+		resp.Body.Close()
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return "", fmt.Errorf("manifest %s not found in %s/%s", manifest, ref.Registry, ref.Repository)
+	case resp.StatusCode != http.StatusOK:
+		return "", fmt.Errorf("registry %s returned %s for %s", ref.Registry, resp.Status, image)
+	}
+	digest := resp.Header.Get("Docker-Content-Digest")
+	if digest == "" {
+		// Some registries only send the digest for GET; hash the manifest instead.
+		if digest, err = r.digestFromBody(ctx, manifestURL, authorization); err != nil {
+			return "", err
+		}
+	}
+	if ref.Digest != "" && digest != ref.Digest {
+		return "", fmt.Errorf("registry returned digest %s for %s", digest, image)
+	}
+	return digest, nil
+}
+
+func (r *Resolver) client() *http.Client {
+	if r.Client != nil {
+		return r.Client
+	}
+	return http.DefaultClient
+}
+
+func (r *Resolver) request(ctx context.Context, method, target, authorization string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, target, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", strings.Join(manifestMediaTypes, ", "))
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	return r.client().Do(req)
+}
+
+func (r *Resolver) digestFromBody(ctx context.Context, manifestURL, authorization string) (string, error) {
+	resp, err := r.request(ctx, http.MethodGet, manifestURL, authorization)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("registry returned %s for %s", resp.Status, manifestURL)
+	}
+	if digest := resp.Header.Get("Docker-Content-Digest"); digest != "" {
+		return digest, nil
+	}
+	hash := sha256.New()
+	n, err := io.Copy(hash, io.LimitReader(resp.Body, maxManifestBytes+1))
+	if err != nil {
+		return "", err
+	}
+	// A truncated manifest would hash to a wrong digest.
+	if n > maxManifestBytes {
+		return "", fmt.Errorf("manifest %s exceeds %d bytes", manifestURL, maxManifestBytes)
+	}
+	return "sha256:" + hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// authorize answers the challenge of a 401 response with the Authorization header to retry with:
+// basic credentials, or a bearer token fetched from the challenge's realm.
+func (r *Resolver) authorize(ctx context.Context, registry, challenge string, creds Credentials, hasCreds bool) (string, error) {
+	scheme, params, _ := strings.Cut(challenge, " ")
+	switch {
+	case strings.EqualFold(scheme, "Basic"):
+		if !hasCreds {
+			return "", fmt.Errorf("registry requires credentials, add an imagePullSecret for it")
+		}
+		return "Basic " + basicAuth(creds), nil
+	case strings.EqualFold(scheme, "Bearer"):
+		token, err := r.token(ctx, registry, parseChallenge(params), creds, hasCreds)
+		if err != nil {
+			return "", err
+		}
+		return "Bearer " + token, nil
+	}
+	return "", fmt.Errorf("unsupported authentication challenge %q", challenge)
+}
+
+func basicAuth(creds Credentials) string {
+	return base64.StdEncoding.EncodeToString([]byte(creds.Username + ":" + creds.Password))
+}
+
+// checkRealm allows a token realm only over https, on the registry's own host or on a trusted
+// token host, so that a registry cannot point the backend at arbitrary URLs.
+func (r *Resolver) checkRealm(registry, realm string) error {
+	u, err := url.Parse(realm)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("invalid token realm %q", realm)
+	}
+	if u.Scheme != "https" {
+		return fmt.Errorf("token realm %s does not use https", realm)
+	}
+	if u.Host == registry || (registry == dockerHubRegistry && u.Host == dockerHubAuthHost) {
+		return nil
+	}
+	for _, host := range r.TokenRealmHosts {
+		if u.Hostname() == host || u.Host == host {
+			return nil
+		}
+	}
+	return fmt.Errorf("token realm host %s is not trusted for registry %s", u.Host, registry)
+}
+
+// token fetches a bearer token from the realm of a challenge, sending the credentials when there
+// are any and anonymously otherwise.
+func (r *Resolver) token(ctx context.Context, registry string, values map[string]string, creds Credentials, hasCreds bool) (string, error) {
+	realm := values["realm"]
+	if realm == "" {
+		return "", fmt.Errorf("authentication challenge has no realm")
+	}
+	if err := r.checkRealm(registry, realm); err != nil {
+		return "", err
+	}
+	query := url.Values{}
+	for _, key := range []string{"service", "scope"} {
+		if values[key] != "" {
+			query.Set(key, values[key])
+		}
+	}
+	tokenURL := realm
+	if len(query) > 0 {
+		tokenURL += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, tokenURL, nil)
+	if err != nil {
+		return "", err
+	}
+	if hasCreds {
+		req.Header.Set("Authorization", "Basic "+basicAuth(creds))
+	}
+	resp, err := r.client().Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("token endpoint returned %s", resp.Status)
+	}
+	var body struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxTokenBytes)).Decode(&body); err != nil {
+		return "", err
+	}
+	if body.Token != "" {
+		return body.Token, nil
+	}
+	return body.AccessToken, nil
+}
+
+// parseChallenge parses the comma-separated key="value" parameters of a WWW-Authenticate header.
+func parseChallenge(params string) map[string]string {
+	values := map[string]string{}
+	for params != "" {
+		key, rest, found := strings.Cut(params, "=")
+		if !found {
+			break
+		}
+		key = strings.TrimSpace(key)
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			end := strings.Index(rest[1:], `"`)
+			if end < 0 {
+				break
+			}
+			value = rest[1 : end+1]
+			rest = rest[end+2:]
+		} else {
+			value, rest, _ = strings.Cut(rest, ",")
+		}
+		values[strings.ToLower(key)] = value
+		params = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(rest), ","))
+	}
+	return values
+}
@@ -0,0 +1,205 @@
+package registry
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseReference(t *testing.T) {
+	tests := []struct {
+		image string
+		want  Reference
+	}{
+		{"nginx", Reference{Registry: "registry-1.docker.io", Repository: "library/nginx", Tag: "latest"}},
+		{"bitnami/redis:7.2", Reference{Registry: "registry-1.docker.io", Repository: "bitnami/redis", Tag: "7.2"}},
+		{"ghcr.io/org/app:v1", Reference{Registry: "ghcr.io", Repository: "org/app", Tag: "v1"}},
+		{"localhost:5000/app", Reference{Registry: "localhost:5000", Repository: "app", Tag: "latest"}},
+		{"quay.io/app@sha256:abc", Reference{Registry: "quay.io", Repository: "app", Digest: "sha256:abc"}},
+		{"quay.io/app:v1@sha256:abc", Reference{Registry: "quay.io", Repository: "app", Tag: "v1", Digest: "sha256:abc"}},
+	}
+	for _, tt := range tests {
+		got, err := ParseReference(tt.image)
+		if err != nil {
+			t.Errorf("ParseReference(%q) error: %v", tt.image, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseReference(%q) = %+v, want %+v", tt.image, got, tt.want)
+		}
+	}
+
+	for _, image := range []string{"", "App:v1", "app@latest"} {
+		if _, err := ParseReference(image); err == nil {
+			t.Errorf("ParseReference(%q) should fail", image)
+		}
+	}
+}
+
+func TestResolve(t *testing.T) {
+	const digest = "sha256:0123456789abcdef"
+	var server *httptest.Server
+	server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/token":
+			if r.URL.Query().Get("scope") != "repository:app:pull" {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			_, _ = w.Write([]byte(`{"token":"t0k"}`))
+		case r.Header.Get("Authorization") != "Bearer t0k":
+			w.Header().Set("WWW-Authenticate", `Bearer realm="`+server.URL+`/token",service="test",scope="repository:app:pull"`)
+			w.WriteHeader(http.StatusUnauthorized)
+		case r.URL.Path == "/v2/app/manifests/v1" || r.URL.Path == "/v2/app/manifests/"+digest:
+			w.Header().Set("Docker-Content-Digest", digest)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	host := strings.TrimPrefix(server.URL, "https://")
+	resolver := &Resolver{Client: server.Client()}
+
+	got, err := resolver.Resolve(context.Background(), host+"/app:v1", nil)
+	if err != nil || got != digest {
+		t.Errorf("Resolve(tag) = %q, %v; want %s", got, err, digest)
+	}
+	if got, err := resolver.Resolve(context.Background(), host+"/app@"+digest, nil); err != nil || got != digest {
+		t.Errorf("Resolve(digest) = %q, %v", got, err)
+	}
+	if _, err := resolver.Resolve(context.Background(), host+"/app:v2", nil); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("Resolve(missing tag) error = %v", err)
+	}
+}
+
+func TestResolveHashesBoundedManifest(t *testing.T) {
+	manifest := []byte(`{"schemaVersion":2}`)
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// No Docker-Content-Digest: the digest is computed from the body.
+		if r.Method != http.MethodGet {
+			return
+		}
+		if strings.HasSuffix(r.URL.Path, "/huge") {
+			_, _ = w.Write(make([]byte, maxManifestBytes+1))
+			return
+		}
+		_, _ = w.Write(manifest)
+	}))
+	defer server.Close()
+
+	host := strings.TrimPrefix(server.URL, "https://")
+	resolver := &Resolver{Client: server.Client()}
+	sum := sha256.Sum256(manifest)
+	if got, err := resolver.Resolve(context.Background(), host+"/app:v1", nil); err != nil || got != "sha256:"+hex.EncodeToString(sum[:]) {
+		t.Errorf("Resolve() = %q, %v; want the body digest", got, err)
+	}
+	if _, err := resolver.Resolve(context.Background(), host+"/app:huge", nil); err == nil || !strings.Contains(err.Error(), "exceeds") {
+		t.Errorf("Resolve(oversized manifest) error = %v", err)
+	}
+}
+
+func TestResolveWithCredentials(t *testing.T) {
+	const digest = "sha256:0123456789abcdef"
+	basic := "Basic " + base64.StdEncoding.EncodeToString([]byte("robot:secret"))
+	var server *httptest.Server
+	server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/token":
+			if r.Header.Get("Authorization") != basic {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			_, _ = w.Write([]byte(`{"access_token":"private"}`))
+		case strings.HasPrefix(r.URL.Path, "/v2/basic/"):
+			if r.Header.Get("Authorization") != basic {
+				w.Header().Set("WWW-Authenticate", `Basic realm="registry"`)
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Header().Set("Docker-Content-Digest", digest)
+		case r.Header.Get("Authorization") != "Bearer private":
+			w.Header().Set("WWW-Authenticate", `Bearer realm="`+server.URL+`/token",scope="repository:app:pull"`)
+			w.WriteHeader(http.StatusUnauthorized)
+		default:
+			w.Header().Set("Docker-Content-Digest", digest)
+		}
+	}))
+	defer server.Close()
+
+	host := strings.TrimPrefix(server.URL, "https://")
+	resolver := &Resolver{Client: server.Client()}
+	keychain := Keychain{host: {Username: "robot", Password: "secret"}}
+
+	for _, image := range []string{host + "/app:v1", host + "/basic:v1"} {
+		if got, err := resolver.Resolve(context.Background(), image, keychain); err != nil || got != digest {
+			t.Errorf("Resolve(%s) = %q, %v; want %s", image, got, err, digest)
+		}
+		if _, err := resolver.Resolve(context.Background(), image, nil); err == nil {
+			t.Errorf("Resolve(%s) without credentials should fail", image)
+		}
+	}
+}
+
+func TestCheckRealm(t *testing.T) {
+	resolver := &Resolver{TokenRealmHosts: []string{"auth.example.com"}}
+	tests := []struct {
+		registry string
+		realm    string
+		wantErr  bool
+	}{
+		{registry: "ghcr.io", realm: "https://ghcr.io/token"},
+		{registry: "registry.local:5000", realm: "https://registry.local:5000/auth"},
+		{registry: dockerHubRegistry, realm: "https://auth.docker.io/token"},
+		{registry: "registry.example.com", realm: "https://auth.example.com/token"},
+		{registry: "ghcr.io", realm: "http://ghcr.io/token", wantErr: true},
+		{registry: "evil.example.org", realm: "https://169.254.169.254/latest/meta-data", wantErr: true},
+		{registry: "quay.io", realm: "https://auth.docker.io/token", wantErr: true},
+		{registry: "ghcr.io", realm: "not a url", wantErr: true},
+	}
+	for _, tt := range tests {
+		if err := resolver.checkRealm(tt.registry, tt.realm); (err != nil) != tt.wantErr {
+			t.Errorf("checkRealm(%s, %s) error = %v, wantErr %v", tt.registry, tt.realm, err, tt.wantErr)
+		}
+	}
+}
+
+func TestParseDockerConfig(t *testing.T) {
+	auth := base64.StdEncoding.EncodeToString([]byte("user:pa:ss"))
+	keychain, err := ParseDockerConfig([]byte(`{"auths":{"https://index.docker.io/v1/":{"auth":"` + auth + `"},"ghcr.io":{"username":"bot","password":"pat"}}}`))
+	if err != nil {
+		t.Fatalf("ParseDockerConfig() error = %v", err)
+	}
+	if got := keychain[dockerHubRegistry]; got != (Credentials{Username: "user", Password: "pa:ss"}) {
+		t.Errorf("Docker Hub credentials = %+v", got)
+	}
+	if got := keychain["ghcr.io"]; got != (Credentials{Username: "bot", Password: "pat"}) {
+		t.Errorf("ghcr.io credentials = %+v", got)
+	}
+
+	legacy, err := ParseDockerConfig([]byte(`{"quay.io":{"auth":"` + auth + `"}}`))
+	if err != nil || legacy["quay.io"].Username != "user" {
+		t.Errorf("legacy dockercfg = %+v, %v", legacy, err)
+	}
+}
+
+func TestNewClientRefusesLoopback(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	if _, err := NewClient(time.Second).Get(server.URL); err == nil || !strings.Contains(err.Error(), "not allowed") {
+		t.Errorf("Get(loopback) error = %v, want the connection refused", err)
+	}
+}
+
+func TestParseChallenge(t *testing.T) {
+	values := parseChallenge(`realm="https://auth.example.com/token",service="registry.example.com",scope="repository:a/b:pull,push"`)
+	if values["realm"] != "https://auth.example.com/token" || values["service"] != "registry.example.com" || values["scope"] != "repository:a/b:pull,push" {
+		t.Errorf("parseChallenge = %v", values)
+	}
+}
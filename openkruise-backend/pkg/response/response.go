@@ -11,9 +11,16 @@ import (
 
 // ErrorResponse represents a standard error response
 type ErrorResponse struct {
-	TraceID string `json:"trace_id"`
+	TraceID string       `json:"trace_id"`
+	Message string       `json:"message"`
+	Code    string       `json:"code,omitempty"`
+	Errors  []FieldError `json:"errors,omitempty"`
+}
+
+// FieldError describes why a single request field was rejected
+type FieldError struct {
+	Field   string `json:"field"`
 	Message string `json:"message"`
-	Code    string `json:"code,omitempty"`
 }
 
 // SuccessResponse represents a standard success response
@@ -65,6 +72,21 @@ func NotFound(c *gin.Context, resource string) {
 	Error(c, http.StatusNotFound, resource+" not found", nil, "NOT_FOUND")
 }
 
+// ValidationFailed sends a 400 error response listing the rejected fields
+func ValidationFailed(c *gin.Context, errors []FieldError) {
+	FieldErrors(c, http.StatusBadRequest, "Request body does not match the API schema", "VALIDATION_FAILED", errors)
+}
+
+// FieldErrors sends an error response listing the request fields that caused it
+func FieldErrors(c *gin.Context, statusCode int, message, code string, errors []FieldError) {
+	c.JSON(statusCode, ErrorResponse{
+		TraceID: uuid.New().String(),
+		Message: message,
+		Code:    code,
+		Errors:  errors,
+	})
+}
+
 // Unauthorized sends a 401 error response
 func Unauthorized(c *gin.Context, message string) {
 	Error(c, http.StatusUnauthorized, message, nil, "UNAUTHORIZED")
@@ -109,3 +109,27 @@ func TestNotFound(t *testing.T) {
 		t.Errorf("Message = %q, want 'workload not found'", resp.Message)
 	}
 }
+
+func TestValidationFailed(t *testing.T) {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("POST", "/test", nil)
+
+	ValidationFailed(c, []FieldError{{Field: "image", Message: "is required"}})
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Status code = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+
+	var resp ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+
+	if resp.Code != "VALIDATION_FAILED" {
+		t.Errorf("Code = %q, want 'VALIDATION_FAILED'", resp.Code)
+	}
+	if len(resp.Errors) != 1 || resp.Errors[0].Field != "image" {
+		t.Errorf("Errors = %+v, want one error for 'image'", resp.Errors)
+	}
+}
@@ -0,0 +1,242 @@
+// Package timeseries keeps fixed-size histories of numeric samples in memory, keyed by series
+// name, with downsampling on read and snapshots to disk.
+package timeseries
+
+import (
+	"encoding/gob"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Point is one sample of a series. All points of a series carry the same number of values.
+type Point struct {
+	Time   time.Time
+	Values []float64
+}
+
+// Ring holds the latest points of a series, overwriting the oldest once full. Its storage grows
+// with the points added, so short-lived series stay small.
+type Ring struct {
+	points   []Point
+	capacity int
+	start    int
+}
+
+// NewRing returns a ring holding up to capacity points.
+func NewRing(capacity int) *Ring {
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &Ring{capacity: capacity}
+}
+
+// Add appends a point, dropping the oldest when the ring is full.
+func (r *Ring) Add(p Point) {
+	if len(r.points) < r.capacity {
+		r.points = append(r.points, p)
+		return
+	}
+	r.points[r.start] = p
+	r.start = (r.start + 1) % r.capacity
+}
+
+// Len returns the number of points held.
+func (r *Ring) Len() int {
+	return len(r.points)
+}
+
+// Points returns the points held, oldest first.
+func (r *Ring) Points() []Point {
+	points := make([]Point, 0, len(r.points))
+	for i := range r.points {
+		points = append(points, r.points[(r.start+i)%len(r.points)])
+	}
+	return points
+}
+
+// Last returns the newest point, or false if the ring is empty.
+func (r *Ring) Last() (Point, bool) {
+	if len(r.points) == 0 {
+		return Point{}, false
+	}
+	return r.points[(r.start+len(r.points)-1)%len(r.points)], true
+}
+
+// Store is a set of rings of the same capacity, safe for concurrent use.
+type Store struct {
+	mu        sync.RWMutex
+	capacity  int
+	maxSeries int
+	series    map[string]*Ring
+}
+
+// NewStore returns a store keeping up to capacity points per series and at most maxSeries series;
+// a maxSeries of 0 means no limit.
+func NewStore(capacity, maxSeries int) *Store {
+	return &Store{capacity: capacity, maxSeries: maxSeries, series: map[string]*Ring{}}
+}
+
+// Add appends a point to a series, creating the series on first use. It returns false, dropping
+// the point, when the series is new and the store already holds maxSeries series.
+func (s *Store) Add(key string, p Point) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ring, ok := s.series[key]
+	if !ok {
+		if s.maxSeries > 0 && len(s.series) >= s.maxSeries {
+			return false
+		}
+		ring = NewRing(s.capacity)
+		s.series[key] = ring
+	}
+	ring.Add(p)
+	return true
+}
+
+// Keys returns the names of the series held, sorted.
+func (s *Store) Keys() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	keys := make([]string, 0, len(s.series))
+	for key := range s.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Query returns the points of a series at or after since, averaged into buckets of step. A step
+// of 0 returns the raw points. The result is empty for an unknown series.
+func (s *Store) Query(key string, since time.Time, step time.Duration) []Point {
+	s.mu.RLock()
+	ring, ok := s.series[key]
+	var points []Point
+	if ok {
+		points = ring.Points()
+	}
+	s.mu.RUnlock()
+
+	kept := make([]Point, 0, len(points))
+	for _, p := range points {
+		if !p.Time.Before(since) {
+			kept = append(kept, p)
+		}
+	}
+	if step <= 0 {
+		return kept
+	}
+	return Downsample(kept, step)
+}
+
+// Downsample averages points, oldest first, into buckets of step, as aligned by time.Truncate. Each
+// bucket is stamped with its start time.
+func Downsample(points []Point, step time.Duration) []Point {
+	result := make([]Point, 0, len(points))
+	var bucket time.Time
+	var sums []float64
+	n := 0
+	flush := func() {
+		if n == 0 {
+			return
+		}
+		values := make([]float64, len(sums))
+		for i, sum := range sums {
+			values[i] = sum / float64(n)
+		}
+		result = append(result, Point{Time: bucket, Values: values})
+	}
+	for _, p := range points {
+		start := p.Time.Truncate(step)
+		if n == 0 || !start.Equal(bucket) {
+			flush()
+			bucket, sums, n = start, make([]float64, len(p.Values)), 0
+		}
+		for i := range sums {
+			if i < len(p.Values) {
+				sums[i] += p.Values[i]
+			}
+		}
+		n++
+	}
+	flush()
+	return result
+}
+
+// Prune removes the series whose newest point is older than before, such as deleted workloads.
+func (s *Store) Prune(before time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key, ring := range s.series {
+		if last, ok := ring.Last(); !ok || last.Time.Before(before) {
+			delete(s.series, key)
+		}
+	}
+}
+
+// Encode writes the points of every series to w in the format read by Decode.
+func (s *Store) Encode(w io.Writer) error {
+	s.mu.RLock()
+	snapshot := make(map[string][]Point, len(s.series))
+	for key, ring := range s.series {
+		snapshot[key] = ring.Points()
+	}
+	s.mu.RUnlock()
+	return gob.NewEncoder(w).Encode(snapshot)
+}
+
+// Decode adds the points written by Encode, skipping those older than since. Series longer
+// than the store's capacity keep their newest points.
+func (s *Store) Decode(r io.Reader, since time.Time) error {
+	var snapshot map[string][]Point
+	if err := gob.NewDecoder(r).Decode(&snapshot); err != nil {
+		return err
+	}
+	for key, points := range snapshot {
+		for _, p := range points {
+			if !p.Time.Before(since) {
+				s.Add(key, p)
+			}
+		}
+	}
+	return nil
+}
+
+// Save writes a snapshot to path, replacing the file atomically so that a crash never leaves a
+// truncated snapshot behind.
+func (s *Store) Save(path string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := s.Encode(tmp); err != nil {
+		tmp.Close()
+		return fmt.Errorf("encode snapshot: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Load reads a snapshot written by Save, skipping points older than since. A missing file is not
+// an error.
+func (s *Store) Load(path string, since time.Time) error {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	defer f.Close()
+	if err := s.Decode(f, since); err != nil {
+		return fmt.Errorf("decode snapshot: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package timeseries
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var epoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func at(seconds int, values ...float64) Point {
+	return Point{Time: epoch.Add(time.Duration(seconds) * time.Second), Values: values}
+}
+
+func TestRing(t *testing.T) {
+	ring := NewRing(3)
+	if _, ok := ring.Last(); ok {
+		t.Error("Last() on an empty ring should report false")
+	}
+	if large := NewRing(2880); cap(large.points) != 0 {
+		t.Errorf("a new ring allocated %d points up front", cap(large.points))
+	}
+	for i := 0; i < 5; i++ {
+		ring.Add(at(i, float64(i)))
+	}
+	want := []Point{at(2, 2), at(3, 3), at(4, 4)}
+	if got := ring.Points(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Points() = %v, want %v", got, want)
+	}
+	if last, _ := ring.Last(); !reflect.DeepEqual(last, at(4, 4)) {
+		t.Errorf("Last() = %v", last)
+	}
+	if ring.Len() != 3 {
+		t.Errorf("Len() = %d, want 3", ring.Len())
+	}
+}
+
+func TestDownsample(t *testing.T) {
+	points := []Point{at(0, 1, 10), at(30, 3, 30), at(60, 5, 50), at(150, 7, 70)}
+	want := []Point{at(0, 2, 20), at(60, 5, 50), at(120, 7, 70)}
+	if got := Downsample(points, time.Minute); !reflect.DeepEqual(got, want) {
+		t.Errorf("Downsample() = %v, want %v", got, want)
+	}
+	if got := Downsample(nil, time.Minute); len(got) != 0 {
+		t.Errorf("Downsample(nil) = %v, want empty", got)
+	}
+}
+
+func TestStoreQueryAndPrune(t *testing.T) {
+	store := NewStore(10, 0)
+	for i := 0; i < 4; i++ {
+		store.Add("cluster", at(i*30, float64(i)))
+	}
+	store.Add("node/a", at(0, 1))
+
+	if got := store.Query("cluster", epoch.Add(time.Minute), 0); !reflect.DeepEqual(got, []Point{at(60, 2), at(90, 3)}) {
+		t.Errorf("Query(since) = %v", got)
+	}
+	if got := store.Query("cluster", epoch, time.Minute); !reflect.DeepEqual(got, []Point{at(0, 0.5), at(60, 2.5)}) {
+		t.Errorf("Query(step) = %v", got)
+	}
+	if got := store.Query("missing", epoch, 0); len(got) != 0 {
+		t.Errorf("Query(missing) = %v, want empty", got)
+	}
+
+	store.Prune(epoch.Add(time.Minute))
+	if got := store.Keys(); !reflect.DeepEqual(got, []string{"cluster"}) {
+		t.Errorf("Keys() after Prune = %v, want [cluster]", got)
+	}
+}
+
+func TestStoreMaxSeries(t *testing.T) {
+	store := NewStore(10, 2)
+	if !store.Add("cluster", at(0, 1)) || !store.Add("node/a", at(0, 1)) {
+		t.Fatal("Add() below the series limit should succeed")
+	}
+	if store.Add("node/b", at(0, 1)) {
+		t.Error("Add() of a new series at the limit should be refused")
+	}
+	if !store.Add("cluster", at(30, 2)) {
+		t.Error("Add() to an existing series at the limit should succeed")
+	}
+	store.Prune(epoch.Add(time.Second))
+	if !store.Add("node/b", at(60, 1)) {
+		t.Error("Add() should succeed again once pruning frees a slot")
+	}
+}
+
+func TestStoreSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.gob")
+	store := NewStore(10, 0)
+	for i := 0; i < 4; i++ {
+		store.Add("cluster", at(i*30, float64(i)))
+	}
+	if err := store.Save(path); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	restored := NewStore(2, 0)
+	if err := restored.Load(path, epoch.Add(30*time.Second)); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	// The point before since is skipped and the smaller capacity keeps the newest points.
+	if got := restored.Query("cluster", epoch, 0); !reflect.DeepEqual(got, []Point{at(60, 2), at(90, 3)}) {
+		t.Errorf("restored points = %v", got)
+	}
+
+	if err := NewStore(1, 0).Load(filepath.Join(t.TempDir(), "missing"), epoch); err != nil {
+		t.Errorf("Load(missing) error = %v, want nil", err)
+	}
+}